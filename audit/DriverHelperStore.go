@@ -0,0 +1,143 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+// DriverHelperStore records the writes made through it; reads go straight to the embedded store.
+type DriverHelperStore struct {
+	model.DriverHelperStore
+	run runner
+}
+
+var _ model.DriverHelperStore = (*DriverHelperStore)(nil)
+
+func (s *DriverHelperStore) CreateDriverHelper(ctx context.Context, dh *model.DriverHelper) error {
+	input := *dh
+	return s.run(ctx, func(tx model.Stores) error {
+		attempt := input
+		if err := tx.DriverHelpers.CreateDriverHelper(ctx, &attempt); err != nil {
+			return err
+		}
+		*dh = attempt
+		return recordDriverHelper(ctx, tx, attempt.ID, model.OperationCreate, model.DriverHelper{}, attempt)
+	})
+}
+
+func (s *DriverHelperStore) UpdateDriverHelper(ctx context.Context, dh *model.DriverHelper) error {
+	input := *dh
+	return s.run(ctx, func(tx model.Stores) error {
+		attempt := input
+		before, err := tx.DriverHelpers.DriverHelperByID(ctx, attempt.ID)
+		if err != nil {
+			return err
+		}
+		if err := tx.DriverHelpers.UpdateDriverHelper(ctx, &attempt); err != nil {
+			return err
+		}
+		*dh = attempt
+		return recordDriverHelper(ctx, tx, attempt.ID, model.OperationUpdate, before, attempt)
+	})
+}
+
+func (s *DriverHelperStore) PatchDriverHelper(ctx context.Context, dh *model.DriverHelper, columns []string) error {
+	input := *dh
+	return s.run(ctx, func(tx model.Stores) error {
+		attempt := input
+		before, err := tx.DriverHelpers.DriverHelperByID(ctx, attempt.ID)
+		if err != nil {
+			return err
+		}
+		if err := tx.DriverHelpers.PatchDriverHelper(ctx, &attempt, columns); err != nil {
+			return err
+		}
+		*dh = attempt
+		return recordDriverHelper(ctx, tx, attempt.ID, model.OperationUpdate, before, attempt)
+	})
+}
+
+func (s *DriverHelperStore) SubmitPoliceVerification(ctx context.Context, id uuid.UUID, expectedVersion int, date time.Time, documentPath string) (model.DriverHelper, error) {
+	var after model.DriverHelper
+	err := s.run(ctx, func(tx model.Stores) error {
+		before, err := tx.DriverHelpers.DriverHelperByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if after, err = tx.DriverHelpers.SubmitPoliceVerification(ctx, id, expectedVersion, date, documentPath); err != nil {
+			return err
+		}
+		return recordDriverHelper(ctx, tx, id, model.OperationUpdate, before, after)
+	})
+	return after, err
+}
+
+func (s *DriverHelperStore) RevokePoliceVerification(ctx context.Context, id uuid.UUID, expectedVersion int, reason string) (model.DriverHelper, error) {
+	var after model.DriverHelper
+	err := s.run(ctx, func(tx model.Stores) error {
+		before, err := tx.DriverHelpers.DriverHelperByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if after, err = tx.DriverHelpers.RevokePoliceVerification(ctx, id, expectedVersion, reason); err != nil {
+			return err
+		}
+		return recordDriverHelper(ctx, tx, id, model.OperationUpdate, before, after)
+	})
+	return after, err
+}
+
+func (s *DriverHelperStore) DeleteDriverHelper(ctx context.Context, id uuid.UUID, expectedVersion int, reason string) error {
+	return s.run(ctx, func(tx model.Stores) error {
+		before, err := tx.DriverHelpers.DriverHelperByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := tx.DriverHelpers.DeleteDriverHelper(ctx, id, expectedVersion, reason); err != nil {
+			return err
+		}
+		after, err := tx.DriverHelpers.DriverHelperByID(model.WithDeleted(ctx), id)
+		if err != nil {
+			return err
+		}
+		return recordDriverHelper(ctx, tx, id, model.OperationDelete, before, after)
+	})
+}
+
+func (s *DriverHelperStore) RestoreDriverHelper(ctx context.Context, id uuid.UUID) (model.DriverHelper, error) {
+	var after model.DriverHelper
+	err := s.run(ctx, func(tx model.Stores) error {
+		before, err := tx.DriverHelpers.DriverHelperByID(model.WithDeleted(ctx), id)
+		if err != nil {
+			return err
+		}
+		if after, err = tx.DriverHelpers.RestoreDriverHelper(ctx, id); err != nil {
+			return err
+		}
+		return recordDriverHelper(ctx, tx, id, model.OperationRestore, before, after)
+	})
+	return after, err
+}
+
+// PurgeDeletedDriverHelpers records a purge entry for every row it removes.
+// The entry also stands for the unassignment of the row's vehicles, which
+// the database makes without passing through the vehicle store.
+func (s *DriverHelperStore) PurgeDeletedDriverHelpers(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := s.run(ctx, func(tx model.Stores) error {
+		var err error
+		if ids, err = tx.DriverHelpers.PurgeDeletedDriverHelpers(ctx, deletedBefore); err != nil {
+			return err
+		}
+		return recordPurges(ctx, tx, model.EntityDriverHelper, ids)
+	})
+	return ids, err
+}
+
+func recordDriverHelper(ctx context.Context, tx model.Stores, id uuid.UUID, operation string, before, after model.DriverHelper) error {
+	return record(ctx, tx.Audit, model.EntityDriverHelper, id, operation, model.Diff(before, after))
+}
@@ -0,0 +1,256 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/memstore"
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+func newDriver(aadhaar, mobile, license string) model.DriverHelper {
+	expiry := time.Now().AddDate(1, 0, 0)
+	return model.DriverHelper{UserType: "Driver", FirstName: "Ravi", BloodGroup: "O+", AadharNumber: aadhaar,
+		MobileNumber: mobile, LicenseNumber: license, LicenseExpiryDate: &expiry, PoliceVerification: "No"}
+}
+
+// history returns the audit entries recorded for one entity, oldest first.
+func history(t *testing.T, store model.AuditStore, entityType string, id uuid.UUID) []model.AuditEntry {
+	t.Helper()
+	page, err := store.AuditHistory(context.Background(), entityType, id, model.AuditFilter{})
+	if err != nil {
+		t.Fatalf("AuditHistory: %v", err)
+	}
+	return page.Items
+}
+
+// checkLast fails unless entries holds want entries, the last of which
+// records operation by actor and changes field from before to after.
+func checkLast(t *testing.T, entries []model.AuditEntry, want int, operation, actor, field string, before, after any) {
+	t.Helper()
+	if len(entries) != want {
+		t.Fatalf("got %d audit entries, want %d: %+v", len(entries), want, entries)
+	}
+	last := entries[len(entries)-1]
+	if last.Operation != operation || last.Actor != actor {
+		t.Errorf("last entry = %s by %s, want %s by %s", last.Operation, last.Actor, operation, actor)
+	}
+	for _, change := range last.Changes {
+		if change.Field == field {
+			if change.Before != before || change.After != after {
+				t.Errorf("%s changed %v -> %v, want %v -> %v", field, change.Before, change.After, before, after)
+			}
+			return
+		}
+	}
+	t.Errorf("%s entry changes = %+v, want one for %s", operation, last.Changes, field)
+}
+
+func TestDriverHelperWritesRecordOneEntryEach(t *testing.T) {
+	ctx := model.WithActor(context.Background(), "clerk")
+	db := memstore.NewDB()
+	stores := Wrap(memstore.NewStores(db), memstore.NewUnitOfWork(db))
+	entries := func(id uuid.UUID) []model.AuditEntry {
+		return history(t, stores.Audit, model.EntityDriverHelper, id)
+	}
+
+	dh := newDriver("234567890124", "9876543210", "MH1220110012345")
+	if err := stores.DriverHelpers.CreateDriverHelper(ctx, &dh); err != nil {
+		t.Fatalf("CreateDriverHelper: %v", err)
+	}
+	checkLast(t, entries(dh.ID), 1, model.OperationCreate, "clerk", "first_name", "", "Ravi")
+
+	dh.FirstName = "Ravindra"
+	if err := stores.DriverHelpers.UpdateDriverHelper(ctx, &dh); err != nil {
+		t.Fatalf("UpdateDriverHelper: %v", err)
+	}
+	checkLast(t, entries(dh.ID), 2, model.OperationUpdate, "clerk", "first_name", "Ravi", "Ravindra")
+	if changes := entries(dh.ID)[1].Changes; len(changes) != 1 {
+		t.Errorf("update changes = %+v, want only first_name", changes)
+	}
+
+	patch := dh
+	patch.BloodGroup = "B+"
+	if err := stores.DriverHelpers.PatchDriverHelper(ctx, &patch, []string{"blood_group"}); err != nil {
+		t.Fatalf("PatchDriverHelper: %v", err)
+	}
+	checkLast(t, entries(dh.ID), 3, model.OperationUpdate, "clerk", "blood_group", "O+", "B+")
+
+	if err := stores.DriverHelpers.DeleteDriverHelper(ctx, dh.ID, patch.Version, "left"); err != nil {
+		t.Fatalf("DeleteDriverHelper: %v", err)
+	}
+	checkLast(t, entries(dh.ID), 4, model.OperationDelete, "clerk", "delete_reason", "", "left")
+
+	if _, err := stores.DriverHelpers.RestoreDriverHelper(model.WithActor(context.Background(), "supervisor"), dh.ID); err != nil {
+		t.Fatalf("RestoreDriverHelper: %v", err)
+	}
+	checkLast(t, entries(dh.ID), 5, model.OperationRestore, "supervisor", "delete_reason", "left", "")
+
+	if _, err := stores.DriverHelpers.SubmitPoliceVerification(context.Background(), dh.ID, 0, time.Now(), "docs/pv.pdf"); err != nil {
+		t.Fatalf("SubmitPoliceVerification: %v", err)
+	}
+	checkLast(t, entries(dh.ID), 6, model.OperationUpdate, AnonymousActor, "police_verification", "No", "Yes")
+}
+
+func TestFailedDriverHelperWritesRecordNothing(t *testing.T) {
+	ctx := context.Background()
+	db := memstore.NewDB()
+	uow := memstore.NewUnitOfWork(db)
+	stores := Wrap(memstore.NewStores(db), uow)
+
+	dh := newDriver("234567890124", "9876543210", "MH1220110012345")
+	if err := stores.DriverHelpers.CreateDriverHelper(ctx, &dh); err != nil {
+		t.Fatalf("CreateDriverHelper: %v", err)
+	}
+	stale := dh.Version
+	dh.FirstName = "Ravindra"
+	if err := stores.DriverHelpers.UpdateDriverHelper(ctx, &dh); err != nil {
+		t.Fatalf("UpdateDriverHelper: %v", err)
+	}
+	const recorded = 2
+
+	duplicate := newDriver("234567890124", "9876543299", "MH1220110099999")
+	duplicate.ID = uuid.New()
+	boom := errors.New("boom")
+	failing := &DriverHelperStore{
+		DriverHelperStore: stores.DriverHelpers,
+		run: func(ctx context.Context, fn func(tx model.Stores) error) error {
+			return uow.WithTx(ctx, func(tx model.Stores) error {
+				tx.Audit = failingAuditStore{AuditStore: tx.Audit, err: boom}
+				return fn(tx)
+			})
+		},
+	}
+
+	tests := []struct {
+		name  string
+		id    uuid.UUID
+		write func() error
+	}{
+		{name: "create with a taken aadhaar number", id: duplicate.ID, write: func() error {
+			return stores.DriverHelpers.CreateDriverHelper(ctx, &duplicate)
+		}},
+		{name: "update at a stale version", id: dh.ID, write: func() error {
+			stale := dh
+			stale.Version, stale.FirstName = 1, "Ravi Kumar"
+			return stores.DriverHelpers.UpdateDriverHelper(ctx, &stale)
+		}},
+		{name: "delete at a stale version", id: dh.ID, write: func() error {
+			return stores.DriverHelpers.DeleteDriverHelper(ctx, dh.ID, stale, "left")
+		}},
+		{name: "restore of a live row", id: dh.ID, write: func() error {
+			_, err := stores.DriverHelpers.RestoreDriverHelper(ctx, dh.ID)
+			return err
+		}},
+		{name: "write inside a rolled back transaction", id: dh.ID, write: func() error {
+			return NewUnitOfWork(uow).WithTx(ctx, func(tx model.Stores) error {
+				if err := tx.DriverHelpers.DeleteDriverHelper(ctx, dh.ID, 0, "left"); err != nil {
+					return err
+				}
+				return boom
+			})
+		}},
+		{name: "update whose entry cannot be recorded", id: dh.ID, write: func() error {
+			update := dh
+			update.FirstName = "Ravi Kumar"
+			return failing.UpdateDriverHelper(ctx, &update)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.write(); err == nil {
+				t.Fatal("write succeeded")
+			}
+			want := 0
+			if tt.id == dh.ID {
+				want = recorded
+			}
+			if got := history(t, stores.Audit, model.EntityDriverHelper, tt.id); len(got) != want {
+				t.Errorf("got %d audit entries, want %d: %+v", len(got), want, got)
+			}
+		})
+	}
+
+	current, err := stores.DriverHelpers.DriverHelperByID(ctx, dh.ID)
+	if err != nil {
+		t.Fatalf("DriverHelperByID: %v", err)
+	}
+	if current.FirstName != "Ravindra" || current.Version != dh.Version {
+		t.Errorf("row = %s at version %d, want the failed writes undone (Ravindra at %d)", current.FirstName, current.Version, dh.Version)
+	}
+}
+
+type failingAuditStore struct {
+	model.AuditStore
+	err error
+}
+
+func (s failingAuditStore) RecordAudit(ctx context.Context, entry *model.AuditEntry) error {
+	return s.err
+}
+
+func TestPurgeRecordsOneEntryPerRow(t *testing.T) {
+	ctx := model.WithActor(context.Background(), "retention-purger")
+	db := memstore.NewDB()
+	uow := memstore.NewUnitOfWork(db)
+	stores := Wrap(memstore.NewStores(db), uow)
+
+	var purged []uuid.UUID
+	for i, ids := range [][3]string{
+		{"234567890124", "9876543210", "MH1220110012345"},
+		{"345678901238", "9876543211", "MH1220110012346"},
+		{"456789012341", "9876543212", "MH1220110012347"},
+	} {
+		dh := newDriver(ids[0], ids[1], ids[2])
+		if err := stores.DriverHelpers.CreateDriverHelper(ctx, &dh); err != nil {
+			t.Fatalf("CreateDriverHelper: %v", err)
+		}
+		if i == 2 {
+			continue
+		}
+		if err := stores.DriverHelpers.DeleteDriverHelper(ctx, dh.ID, 0, "left"); err != nil {
+			t.Fatalf("DeleteDriverHelper: %v", err)
+		}
+		purged = append(purged, dh.ID)
+	}
+
+	failing := &DriverHelperStore{
+		DriverHelperStore: stores.DriverHelpers,
+		run: func(ctx context.Context, fn func(tx model.Stores) error) error {
+			return uow.WithTx(ctx, func(tx model.Stores) error {
+				tx.Audit = failingAuditStore{AuditStore: tx.Audit, err: errors.New("boom")}
+				return fn(tx)
+			})
+		},
+	}
+	if _, err := failing.PurgeDeletedDriverHelpers(ctx, time.Now().Add(time.Hour)); err == nil {
+		t.Fatal("PurgeDeletedDriverHelpers succeeded without recording its entries")
+	}
+	if _, err := stores.DriverHelpers.DriverHelperByID(model.WithDeleted(ctx), purged[0]); err != nil {
+		t.Fatalf("a purge whose entries could not be recorded removed the row: %v", err)
+	}
+
+	if ids, err := stores.DriverHelpers.PurgeDeletedDriverHelpers(ctx, time.Now().Add(-time.Hour)); err != nil || len(ids) != 0 {
+		t.Fatalf("PurgeDeletedDriverHelpers before the cutoff = %v, %v; want nothing purged", ids, err)
+	}
+	ids, err := stores.DriverHelpers.PurgeDeletedDriverHelpers(ctx, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("PurgeDeletedDriverHelpers: %v", err)
+	}
+	if len(ids) != len(purged) {
+		t.Fatalf("purged %v, want %v", ids, purged)
+	}
+	for _, id := range purged {
+		entries := history(t, stores.Audit, model.EntityDriverHelper, id)
+		if len(entries) != 3 {
+			t.Fatalf("got %d audit entries, want create, delete and purge: %+v", len(entries), entries)
+		}
+		if last := entries[2]; last.Operation != model.OperationPurge || last.Actor != "retention-purger" || len(last.Changes) != 0 {
+			t.Errorf("last entry = %+v, want a purge by retention-purger", last)
+		}
+	}
+}
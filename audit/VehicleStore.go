@@ -0,0 +1,111 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+// VehicleStore records the writes made through it; reads go straight to the embedded store.
+type VehicleStore struct {
+	model.VehicleStore
+	run runner
+}
+
+var _ model.VehicleStore = (*VehicleStore)(nil)
+
+func (s *VehicleStore) CreateVehicle(ctx context.Context, v *model.Vehicle) error {
+	input := *v
+	return s.run(ctx, func(tx model.Stores) error {
+		attempt := input
+		if err := tx.Vehicles.CreateVehicle(ctx, &attempt); err != nil {
+			return err
+		}
+		*v = attempt
+		return recordVehicle(ctx, tx, attempt.ID, model.OperationCreate, model.Vehicle{}, attempt)
+	})
+}
+
+func (s *VehicleStore) UpdateVehicle(ctx context.Context, v *model.Vehicle) error {
+	input := *v
+	return s.run(ctx, func(tx model.Stores) error {
+		attempt := input
+		before, err := tx.Vehicles.VehicleByID(ctx, attempt.ID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Vehicles.UpdateVehicle(ctx, &attempt); err != nil {
+			return err
+		}
+		*v = attempt
+		return recordVehicle(ctx, tx, attempt.ID, model.OperationUpdate, before, attempt)
+	})
+}
+
+func (s *VehicleStore) PatchVehicle(ctx context.Context, v *model.Vehicle, columns []string) error {
+	input := *v
+	return s.run(ctx, func(tx model.Stores) error {
+		attempt := input
+		before, err := tx.Vehicles.VehicleByID(ctx, attempt.ID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Vehicles.PatchVehicle(ctx, &attempt, columns); err != nil {
+			return err
+		}
+		*v = attempt
+		return recordVehicle(ctx, tx, attempt.ID, model.OperationUpdate, before, attempt)
+	})
+}
+
+func (s *VehicleStore) DeleteVehicle(ctx context.Context, id uuid.UUID, expectedVersion int, reason string) error {
+	return s.run(ctx, func(tx model.Stores) error {
+		before, err := tx.Vehicles.VehicleByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := tx.Vehicles.DeleteVehicle(ctx, id, expectedVersion, reason); err != nil {
+			return err
+		}
+		after, err := tx.Vehicles.VehicleByID(model.WithDeleted(ctx), id)
+		if err != nil {
+			return err
+		}
+		return recordVehicle(ctx, tx, id, model.OperationDelete, before, after)
+	})
+}
+
+func (s *VehicleStore) RestoreVehicle(ctx context.Context, id uuid.UUID) (model.Vehicle, error) {
+	var after model.Vehicle
+	err := s.run(ctx, func(tx model.Stores) error {
+		before, err := tx.Vehicles.VehicleByID(model.WithDeleted(ctx), id)
+		if err != nil {
+			return err
+		}
+		if after, err = tx.Vehicles.RestoreVehicle(ctx, id); err != nil {
+			return err
+		}
+		return recordVehicle(ctx, tx, id, model.OperationRestore, before, after)
+	})
+	return after, err
+}
+
+// PurgeDeletedVehicles records a purge entry for every row it removes.
+func (s *VehicleStore) PurgeDeletedVehicles(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := s.run(ctx, func(tx model.Stores) error {
+		var err error
+		if ids, err = tx.Vehicles.PurgeDeletedVehicles(ctx, deletedBefore); err != nil {
+			return err
+		}
+		return recordPurges(ctx, tx, model.EntityVehicle, ids)
+	})
+	return ids, err
+}
+
+func recordVehicle(ctx context.Context, tx model.Stores, id uuid.UUID, operation string, before, after model.Vehicle) error {
+	return record(ctx, tx.Audit, model.EntityVehicle, id, operation, model.Diff(before, after))
+}
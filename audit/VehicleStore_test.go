@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/memstore"
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+func TestVehicleWritesRecordOneEntryEach(t *testing.T) {
+	ctx := model.WithActor(context.Background(), "clerk")
+	db := memstore.NewDB()
+	stores := Wrap(memstore.NewStores(db), memstore.NewUnitOfWork(db))
+	entries := func(id uuid.UUID) []model.AuditEntry {
+		return history(t, stores.Audit, model.EntityVehicle, id)
+	}
+
+	dh := newDriver("234567890124", "9876543210", "MH1220110012345")
+	if err := stores.DriverHelpers.CreateDriverHelper(ctx, &dh); err != nil {
+		t.Fatalf("CreateDriverHelper: %v", err)
+	}
+	v := model.Vehicle{VehicleNumber: "DL1PC0001", RouteNumber: "R1", TotalStudentsCapacity: 30, SeatsAvailable: 30,
+		DriverHelperID: dh.ID, InsuranceExpiryDate: time.Now().AddDate(1, 0, 0),
+		PollutionCertificateExpiryDate: time.Now().AddDate(1, 0, 0), FitnessCertificateExpiryDate: time.Now().AddDate(1, 0, 0)}
+	if err := stores.Vehicles.CreateVehicle(ctx, &v); err != nil {
+		t.Fatalf("CreateVehicle: %v", err)
+	}
+	checkLast(t, entries(v.ID), 1, model.OperationCreate, "clerk", "route_number", "", "R1")
+
+	v.RouteNumber = "R2"
+	if err := stores.Vehicles.UpdateVehicle(ctx, &v); err != nil {
+		t.Fatalf("UpdateVehicle: %v", err)
+	}
+	checkLast(t, entries(v.ID), 2, model.OperationUpdate, "clerk", "route_number", "R1", "R2")
+
+	patch := v
+	patch.SeatsAvailable = 12
+	if err := stores.Vehicles.PatchVehicle(ctx, &patch, []string{"seats_available"}); err != nil {
+		t.Fatalf("PatchVehicle: %v", err)
+	}
+	checkLast(t, entries(v.ID), 3, model.OperationUpdate, "clerk", "seats_available", 30, 12)
+
+	stale := v.Version
+	if err := stores.Vehicles.DeleteVehicle(ctx, v.ID, stale, "sold"); !errors.Is(err, model.ErrPreconditionFailed) {
+		t.Fatalf("DeleteVehicle at a stale version = %v, want %v", err, model.ErrPreconditionFailed)
+	}
+	if got := entries(v.ID); len(got) != 3 {
+		t.Fatalf("a failed delete left %d audit entries, want 3", len(got))
+	}
+
+	if err := stores.Vehicles.DeleteVehicle(ctx, v.ID, patch.Version, "sold"); err != nil {
+		t.Fatalf("DeleteVehicle: %v", err)
+	}
+	checkLast(t, entries(v.ID), 4, model.OperationDelete, "clerk", "delete_reason", "", "sold")
+
+	if _, err := stores.Vehicles.RestoreVehicle(ctx, v.ID); err != nil {
+		t.Fatalf("RestoreVehicle: %v", err)
+	}
+	checkLast(t, entries(v.ID), 5, model.OperationRestore, "clerk", "delete_reason", "sold", "")
+
+	if _, err := stores.Vehicles.RestoreVehicle(ctx, v.ID); err == nil {
+		t.Fatal("RestoreVehicle of a live vehicle succeeded")
+	}
+	if got := entries(v.ID); len(got) != 5 {
+		t.Errorf("a failed restore left %d audit entries, want 5", len(got))
+	}
+}
+
+func TestPurgeVehiclesRecordsOneEntryPerRow(t *testing.T) {
+	ctx := context.Background()
+	db := memstore.NewDB()
+	stores := Wrap(memstore.NewStores(db), memstore.NewUnitOfWork(db))
+
+	v := model.Vehicle{VehicleNumber: "DL1PC0001", RouteNumber: "R1", TotalStudentsCapacity: 30, SeatsAvailable: 30,
+		InsuranceExpiryDate: time.Now().AddDate(1, 0, 0), PollutionCertificateExpiryDate: time.Now().AddDate(1, 0, 0),
+		FitnessCertificateExpiryDate: time.Now().AddDate(1, 0, 0)}
+	if err := stores.Vehicles.CreateVehicle(ctx, &v); err != nil {
+		t.Fatalf("CreateVehicle: %v", err)
+	}
+	if err := stores.Vehicles.DeleteVehicle(ctx, v.ID, 0, "sold"); err != nil {
+		t.Fatalf("DeleteVehicle: %v", err)
+	}
+
+	ids, err := stores.Vehicles.PurgeDeletedVehicles(ctx, time.Now().Add(time.Hour))
+	if err != nil || len(ids) != 1 || ids[0] != v.ID {
+		t.Fatalf("PurgeDeletedVehicles = %v, %v; want [%s]", ids, err, v.ID)
+	}
+	entries := history(t, stores.Audit, model.EntityVehicle, v.ID)
+	if len(entries) != 3 || entries[2].Operation != model.OperationPurge || len(entries[2].Changes) != 0 {
+		t.Errorf("audit entries = %+v, want create, delete and a purge without changes", entries)
+	}
+}
@@ -0,0 +1,88 @@
+// Package audit wraps the model stores so that every create, update, delete,
+// restore and purge they perform is recorded, with a field-level diff, in a
+// model.AuditStore. Each write and its audit entries are committed in the same
+// transaction.
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+// AnonymousActor is recorded when the request context carries no actor.
+const AnonymousActor = "anonymous"
+
+// runner runs fn with unaudited stores that share one transaction. fn runs
+// again if the transaction is retried, so writes work on a fresh copy of
+// their input in every attempt.
+type runner func(ctx context.Context, fn func(tx model.Stores) error) error
+
+// Wrap returns stores that read through stores and run each write, with its
+// audit entry, in its own transaction of uow.
+func Wrap(stores model.Stores, uow model.UnitOfWork) model.Stores {
+	return model.Stores{
+		DriverHelpers: &DriverHelperStore{DriverHelperStore: stores.DriverHelpers, run: uow.WithTx},
+		Vehicles:      &VehicleStore{VehicleStore: stores.Vehicles, run: uow.WithTx},
+		Audit:         stores.Audit,
+		Webhooks:      stores.Webhooks,
+	}
+}
+
+// UnitOfWork hands each transaction stores that audit their writes within it.
+type UnitOfWork struct {
+	uow model.UnitOfWork
+}
+
+var _ model.UnitOfWork = (*UnitOfWork)(nil)
+
+func NewUnitOfWork(uow model.UnitOfWork) *UnitOfWork {
+	return &UnitOfWork{uow: uow}
+}
+
+func (u *UnitOfWork) WithTx(ctx context.Context, fn func(tx model.Stores) error) error {
+	return u.uow.WithTx(ctx, func(tx model.Stores) error {
+		run := func(ctx context.Context, fn func(tx model.Stores) error) error { return fn(tx) }
+		return fn(model.Stores{
+			DriverHelpers: &DriverHelperStore{DriverHelperStore: tx.DriverHelpers, run: run},
+			Vehicles:      &VehicleStore{VehicleStore: tx.Vehicles, run: run},
+			Audit:         tx.Audit,
+			Webhooks:      tx.Webhooks,
+		})
+	})
+}
+
+// recordPurges records one purge entry, with no field changes, per removed row.
+func recordPurges(ctx context.Context, tx model.Stores, entityType string, ids []uuid.UUID) error {
+	for _, id := range ids {
+		if err := record(ctx, tx.Audit, entityType, id, model.OperationPurge, model.Changes{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func record(ctx context.Context, store model.AuditStore, entityType string, entityID uuid.UUID, operation string, changes model.Changes) error {
+	actor := model.Actor(ctx)
+	if actor == "" {
+		actor = AnonymousActor
+	}
+
+	entry := &model.AuditEntry{
+		ID:         uuid.New(),
+		EntityType: entityType,
+		EntityID:   entityID,
+		Operation:  operation,
+		Actor:      actor,
+		Changes:    changes,
+		CreatedAt:  time.Now(),
+	}
+	if err := store.RecordAudit(ctx, entry); err != nil {
+		return fmt.Errorf("failed to record audit entry for %s %s: %w", entityType, entityID, err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+// ErrInvalidAPIKey is returned for API keys that are malformed, unknown,
+// revoked or expired.
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// API keys look like dvp_<prefix>_<secret>. The prefix identifies the key and
+// may be shown and logged; the secret never is.
+const (
+	apiKeyTag         = "dvp"
+	apiKeyPrefixBytes = 8
+	apiKeySecretBytes = 32
+)
+
+// touchInterval limits how often a key's last-used time is written.
+const touchInterval = time.Minute
+
+// GenerateAPIKey returns a new random key together with its prefix and hash.
+func GenerateAPIKey() (key, prefix string, hash []byte, err error) {
+	buf := make([]byte, apiKeyPrefixBytes+apiKeySecretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	prefix = hex.EncodeToString(buf[:apiKeyPrefixBytes])
+	key = apiKeyTag + "_" + prefix + "_" + hex.EncodeToString(buf[apiKeyPrefixBytes:])
+	return key, prefix, HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the SHA-256 digest of key, the form keys are stored and compared in.
+func HashAPIKey(key string) []byte {
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
+
+func parseAPIKey(key string) (prefix string, ok bool) {
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 || parts[0] != apiKeyTag ||
+		len(parts[1]) != 2*apiKeyPrefixBytes || len(parts[2]) != 2*apiKeySecretBytes {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// ValidateScopes adds an error to verr for every scope that is not a permission
+// an API key may hold, or that grantor does not hold itself, so minting a key
+// never escalates the caller's own permissions. Managing API keys is left to
+// users, so a leaked key cannot mint more.
+func ValidateScopes(verr *model.ValidationError, scopes []string, grantor Principal) {
+	if len(scopes) == 0 {
+		verr.Add("scopes", "scopes must not be empty")
+	}
+	for _, scope := range scopes {
+		switch perm := Permission(scope); {
+		case perm == PermAPIKeysManage:
+			verr.Add("scopes", "invalid scope: %s; API keys cannot manage API keys", scope)
+		case !slices.Contains(Permissions, perm):
+			verr.Add("scopes", "invalid scope: %s", scope)
+		case !grantor.Can(perm):
+			verr.Add("scopes", "invalid scope: %s; the caller does not hold it", scope)
+		}
+	}
+}
+
+// APIKeyAuthenticator resolves API keys to principals.
+type APIKeyAuthenticator struct {
+	Store model.APIKeyStore
+}
+
+// NewAPIKeyAuthenticator returns an authenticator that looks keys up in store.
+func NewAPIKeyAuthenticator(store model.APIKeyStore) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{Store: store}
+}
+
+// Authenticate returns the principal for key, whose subject is "api-key:<id>"
+// and whose scopes are those the key was minted with.
+func (a *APIKeyAuthenticator) Authenticate(ctx context.Context, key string) (Principal, error) {
+	prefix, ok := parseAPIKey(key)
+	if !ok {
+		return Principal{}, fmt.Errorf("%w: malformed key", ErrInvalidAPIKey)
+	}
+
+	stored, err := a.Store.APIKeyByPrefix(ctx, prefix)
+	if errors.Is(err, model.ErrNotFound) {
+		return Principal{}, fmt.Errorf("%w: unknown key", ErrInvalidAPIKey)
+	}
+	if err != nil {
+		return Principal{}, err
+	}
+	if subtle.ConstantTimeCompare(stored.KeyHash, HashAPIKey(key)) != 1 {
+		return Principal{}, fmt.Errorf("%w: unknown key", ErrInvalidAPIKey)
+	}
+	now := time.Now()
+	if !stored.Active(now) {
+		return Principal{}, fmt.Errorf("%w: key is revoked or expired", ErrInvalidAPIKey)
+	}
+
+	if stored.LastUsedAt == nil || now.Sub(*stored.LastUsedAt) >= touchInterval {
+		if err := a.Store.TouchAPIKey(ctx, stored.ID, now); err != nil {
+			slog.Warn("failed to record API key use", "prefix", prefix, "error", err)
+		}
+	}
+
+	scopes := make([]Permission, len(stored.Scopes))
+	for i, scope := range stored.Scopes {
+		scopes[i] = Permission(scope)
+	}
+	return Principal{Subject: "api-key:" + stored.ID.String(), Scopes: scopes}, nil
+}
@@ -0,0 +1,78 @@
+// Package auth identifies API callers and decides what they may do. Callers
+// are described by a Principal whose roles grant a fixed set of permissions.
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+type Role string
+
+const (
+	RoleAdmin            Role = "admin"
+	RoleTransportManager Role = "transport-manager"
+	RoleSchoolViewer     Role = "school-viewer"
+	RoleAuditor          Role = "auditor"
+)
+
+type Permission string
+
+const (
+	// PermRosterRead allows reading driver/helpers and vehicles.
+	PermRosterRead Permission = "roster:read"
+	// PermRosterWrite allows creating, updating, deleting and restoring them.
+	PermRosterWrite Permission = "roster:write"
+	// PermAuditRead allows reading change history.
+	PermAuditRead Permission = "audit:read"
+	// PermPIIRead allows seeing Aadhaar, license and phone numbers unmasked.
+	PermPIIRead Permission = "pii:read"
+	// PermAPIKeysManage allows minting, listing, rotating and revoking API keys.
+	PermAPIKeysManage Permission = "api_keys:manage"
+	// PermWebhooksManage allows registering, test-firing and inspecting webhooks.
+	PermWebhooksManage Permission = "webhooks:manage"
+)
+
+// Permissions lists every permission, in the order they are documented.
+var Permissions = []Permission{PermRosterRead, PermRosterWrite, PermAuditRead, PermPIIRead, PermAPIKeysManage, PermWebhooksManage}
+
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin:            {PermRosterRead, PermRosterWrite, PermAuditRead, PermPIIRead, PermAPIKeysManage, PermWebhooksManage},
+	RoleTransportManager: {PermRosterRead, PermRosterWrite, PermAuditRead},
+	RoleSchoolViewer:     {PermRosterRead},
+	RoleAuditor:          {PermRosterRead, PermAuditRead},
+}
+
+// Principal is an authenticated caller: a user whose roles grant permissions,
+// or an API key granted Scopes directly. Roles this package does not know grant nothing.
+type Principal struct {
+	Subject string       `json:"subject"`
+	Roles   []Role       `json:"roles,omitempty"`
+	Scopes  []Permission `json:"scopes,omitempty"`
+}
+
+// Can reports whether p was granted perm directly or through any of its roles.
+func (p Principal) Can(perm Permission) bool {
+	if slices.Contains(p.Scopes, perm) {
+		return true
+	}
+	for _, role := range p.Roles {
+		if slices.Contains(rolePermissions[role], perm) {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying p as the caller.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the caller stored by WithPrincipal, if any.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
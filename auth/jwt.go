@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/config"
+)
+
+// ErrInvalidToken is returned for tokens that are malformed, badly signed, expired
+// or otherwise unacceptable.
+var ErrInvalidToken = errors.New("invalid token")
+
+type claims struct {
+	jwt.RegisteredClaims
+	Roles []Role `json:"roles"`
+}
+
+// Verifier checks HS256 and RS256 bearer tokens against the configured keys.
+type Verifier struct {
+	hmacSecret []byte
+	rsaKey     *rsa.PublicKey
+	jwks       map[string]*rsa.PublicKey
+	parser     *jwt.Parser
+}
+
+// NewVerifier loads the keys cfg names. Tokens must be signed with HS256 or
+// RS256, carry an exp claim, and match cfg's issuer and audience when set.
+func NewVerifier(cfg config.Auth) (*Verifier, error) {
+	v := &Verifier{}
+	if cfg.HMACSecret != "" {
+		v.hmacSecret = []byte(cfg.HMACSecret)
+	}
+	if cfg.RSAPublicKeyFile != "" {
+		data, err := os.ReadFile(cfg.RSAPublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read RSA public key: %w", err)
+		}
+		if v.rsaKey, err = jwt.ParseRSAPublicKeyFromPEM(data); err != nil {
+			return nil, fmt.Errorf("failed to parse RSA public key %s: %w", cfg.RSAPublicKeyFile, err)
+		}
+	}
+	if cfg.JWKSFile != "" {
+		var err error
+		if v.jwks, err = loadJWKS(cfg.JWKSFile); err != nil {
+			return nil, err
+		}
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg()}),
+		jwt.WithLeeway(cfg.Leeway.Duration),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	v.parser = jwt.NewParser(opts...)
+	return v, nil
+}
+
+// Verify validates token and returns the principal it names in its sub and roles claims.
+func (v *Verifier) Verify(token string) (Principal, error) {
+	var c claims
+	if _, err := v.parser.ParseWithClaims(token, &c, v.key); err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if c.Subject == "" {
+		return Principal{}, fmt.Errorf("%w: missing sub claim", ErrInvalidToken)
+	}
+	return Principal{Subject: c.Subject, Roles: c.Roles}, nil
+}
+
+// key picks the verification key for t. The key types keep an RS256 public key
+// from ever being used as an HMAC secret.
+func (v *Verifier) key(t *jwt.Token) (any, error) {
+	switch t.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		if v.hmacSecret == nil {
+			return nil, errors.New("HS256 tokens are not accepted")
+		}
+		return v.hmacSecret, nil
+	case jwt.SigningMethodRS256.Alg():
+		if kid, ok := t.Header["kid"].(string); ok && kid != "" {
+			if key, ok := v.jwks[kid]; ok {
+				return key, nil
+			}
+			if v.rsaKey == nil {
+				return nil, fmt.Errorf("unknown key ID %q", kid)
+			}
+		}
+		if v.rsaKey == nil {
+			return nil, errors.New("RS256 tokens must name a key ID")
+		}
+		return v.rsaKey, nil
+	}
+	return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// loadJWKS reads the RSA signing keys of a JSON Web Key Set, indexed by key ID.
+// Keys of other types or uses are skipped.
+func loadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS file %s: %w", path, err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		if k.Kid == "" {
+			return nil, fmt.Errorf("JWKS file %s has an RSA key without a kid", path)
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key %s has an invalid modulus: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("JWKS key %s has an invalid exponent", k.Kid)
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS file %s has no RSA signing keys", path)
+	}
+	return keys, nil
+}
@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/config"
+)
+
+const testHMACSecret = "0123456789abcdef0123456789abcdef"
+
+func generateRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return key
+}
+
+func writePublicKeyPEM(t *testing.T, key *rsa.PublicKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "public.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func writeJWKS(t *testing.T, keys ...jsonWebKey) string {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) jsonWebKey {
+	return jsonWebKey{Kty: "RSA", Use: "sig", Kid: kid,
+		N: base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key any, c jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, c)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	return signed
+}
+
+func TestVerifier(t *testing.T) {
+	rsaKey, jwksKey, strangerKey := generateRSAKey(t), generateRSAKey(t), generateRSAKey(t)
+	pemPath := writePublicKeyPEM(t, &rsaKey.PublicKey)
+	jwksPath := writeJWKS(t, rsaJWK("k1", &jwksKey.PublicKey))
+	pemBytes, err := os.ReadFile(pemPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	full, err := NewVerifier(config.Auth{HMACSecret: testHMACSecret, RSAPublicKeyFile: pemPath, JWKSFile: jwksPath,
+		Issuer: "https://idp.example", Audience: "dvp"})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	jwksOnly, err := NewVerifier(config.Auth{JWKSFile: jwksPath})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	rsaOnly, err := NewVerifier(config.Auth{RSAPublicKeyFile: pemPath})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	exp := time.Now().Add(time.Hour).Unix()
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{"sub": "user-1", "roles": []string{"admin"}, "exp": exp, "iss": "https://idp.example", "aud": "dvp"}
+	}
+	without := func(claim string) jwt.MapClaims {
+		c := valid()
+		delete(c, claim)
+		return c
+	}
+	with := func(claim string, value any) jwt.MapClaims {
+		c := valid()
+		c[claim] = value
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		verifier *Verifier
+		token    string
+		wantErr  bool
+	}{
+		{name: "HS256", verifier: full, token: sign(t, jwt.SigningMethodHS256, "", []byte(testHMACSecret), valid())},
+		{name: "HS256 wrong secret", verifier: full, token: sign(t, jwt.SigningMethodHS256, "", []byte("another secret"), valid()), wantErr: true},
+		{name: "HS256 not configured", verifier: jwksOnly, token: sign(t, jwt.SigningMethodHS256, "", []byte(testHMACSecret), valid()), wantErr: true},
+		{name: "HS256 keyed with the RSA public key", verifier: rsaOnly, token: sign(t, jwt.SigningMethodHS256, "", pemBytes, valid()), wantErr: true},
+		{name: "HS512 not allowed", verifier: full, token: sign(t, jwt.SigningMethodHS512, "", []byte(testHMACSecret), valid()), wantErr: true},
+		{name: "none", verifier: full, token: sign(t, jwt.SigningMethodNone, "", jwt.UnsafeAllowNoneSignatureType, valid()), wantErr: true},
+		{name: "RS256 without kid", verifier: full, token: sign(t, jwt.SigningMethodRS256, "", rsaKey, valid())},
+		{name: "RS256 with JWKS kid", verifier: full, token: sign(t, jwt.SigningMethodRS256, "k1", jwksKey, valid())},
+		{name: "RS256 JWKS key without kid", verifier: full, token: sign(t, jwt.SigningMethodRS256, "", jwksKey, valid()), wantErr: true},
+		{name: "RS256 unknown kid falls back to the RSA key", verifier: full, token: sign(t, jwt.SigningMethodRS256, "k9", rsaKey, valid())},
+		{name: "RS256 unknown kid and stranger key", verifier: full, token: sign(t, jwt.SigningMethodRS256, "k9", strangerKey, valid()), wantErr: true},
+		{name: "RS256 unknown kid without RSA key", verifier: jwksOnly, token: sign(t, jwt.SigningMethodRS256, "k9", jwksKey, valid()), wantErr: true},
+		{name: "RS256 no kid without RSA key", verifier: jwksOnly, token: sign(t, jwt.SigningMethodRS256, "", jwksKey, valid()), wantErr: true},
+		{name: "PS256 not allowed", verifier: full, token: sign(t, jwt.SigningMethodPS256, "", rsaKey, valid()), wantErr: true},
+		{name: "missing exp", verifier: full, token: sign(t, jwt.SigningMethodHS256, "", []byte(testHMACSecret), without("exp")), wantErr: true},
+		{name: "expired", verifier: full, token: sign(t, jwt.SigningMethodHS256, "", []byte(testHMACSecret), with("exp", time.Now().Add(-time.Hour).Unix())), wantErr: true},
+		{name: "missing sub", verifier: full, token: sign(t, jwt.SigningMethodHS256, "", []byte(testHMACSecret), without("sub")), wantErr: true},
+		{name: "wrong issuer", verifier: full, token: sign(t, jwt.SigningMethodHS256, "", []byte(testHMACSecret), with("iss", "https://evil.example")), wantErr: true},
+		{name: "wrong audience", verifier: full, token: sign(t, jwt.SigningMethodHS256, "", []byte(testHMACSecret), with("aud", "other")), wantErr: true},
+		{name: "malformed", verifier: full, token: "not.a.token", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := tt.verifier.Verify(tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Errorf("Verify = %+v, %v; want %v", p, err, ErrInvalidToken)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if p.Subject != "user-1" || !p.Can(PermPIIRead) {
+				t.Errorf("Verify = %+v, want user-1 with the admin role", p)
+			}
+		})
+	}
+}
+
+func TestLoadJWKS(t *testing.T) {
+	key := generateRSAKey(t)
+	encryption := rsaJWK("enc", &key.PublicKey)
+	encryption.Use = "enc"
+
+	keys, err := loadJWKS(writeJWKS(t, rsaJWK("k1", &key.PublicKey), encryption, jsonWebKey{Kty: "EC", Kid: "ec"}))
+	if err != nil {
+		t.Fatalf("loadJWKS: %v", err)
+	}
+	if len(keys) != 1 || keys["k1"] == nil || !keys["k1"].Equal(&key.PublicKey) {
+		t.Errorf("loadJWKS = %v, want only the RSA signing key k1", keys)
+	}
+
+	tests := []struct {
+		name string
+		keys []jsonWebKey
+	}{
+		{name: "no signing keys", keys: []jsonWebKey{encryption}},
+		{name: "missing kid", keys: []jsonWebKey{rsaJWK("", &key.PublicKey)}},
+		{name: "bad modulus", keys: []jsonWebKey{{Kty: "RSA", Kid: "k1", N: "!!", E: "AQAB"}}},
+		{name: "bad exponent", keys: []jsonWebKey{{Kty: "RSA", Kid: "k1", N: "AQAB", E: ""}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadJWKS(writeJWKS(t, tt.keys...)); err == nil {
+				t.Error("loadJWKS accepted the key set")
+			}
+		})
+	}
+	if _, err := NewVerifier(config.Auth{JWKSFile: filepath.Join(t.TempDir(), "missing.json")}); err == nil {
+		t.Error("NewVerifier accepted a missing JWKS file")
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/config"
+	"github.com/arjunsaxaena/driver_vehicle_profile/controllers"
+	"github.com/arjunsaxaena/driver_vehicle_profile/migrations"
+)
+
+const migrateUsage = "usage: migrate up|down|status|goto N"
+
+// runMigrateCommand implements the `migrate` subcommand.
+func runMigrateCommand(cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	if cfg.Database.Backend != "postgres" {
+		return fmt.Errorf("migrations require the postgres backend, not %q", cfg.Database.Backend)
+	}
+
+	if err := controllers.InitDB(cfg.Database); err != nil {
+		return err
+	}
+	db := controllers.GetDB()
+	defer db.Close()
+
+	runner, err := migrations.NewRunner(context.Background(), db.DB, cfg.Database.MigrationLockTimeout.Duration)
+	if err != nil {
+		return err
+	}
+	defer runner.Close()
+
+	switch args[0] {
+	case "up":
+		err = runner.Up()
+	case "down":
+		err = runner.Down()
+	case "goto":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		version, perr := strconv.ParseUint(args[1], 10, 64)
+		if perr != nil {
+			return fmt.Errorf("invalid version %q: %w", args[1], perr)
+		}
+		err = runner.Goto(uint(version))
+	case "status":
+	default:
+		return fmt.Errorf("unknown migrate command %q; %s", args[0], migrateUsage)
+	}
+	if err != nil {
+		return fmt.Errorf("migrate %s failed: %w", args[0], err)
+	}
+
+	status, err := runner.Status()
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(os.Stdout).Encode(status)
+}
+
+// prepareSchema runs pending migrations when auto-migrate is enabled and
+// refuses to continue if the schema does not match the embedded migrations.
+func prepareSchema(cfg config.Database) error {
+	runner, err := migrations.NewRunner(context.Background(), controllers.GetDB().DB, cfg.MigrationLockTimeout.Duration)
+	if err != nil {
+		return err
+	}
+	defer runner.Close()
+
+	if cfg.AutoMigrate {
+		if err := runner.Up(); err != nil {
+			return fmt.Errorf("auto-migrate failed: %w", err)
+		}
+	}
+
+	status, err := runner.Status()
+	if err != nil {
+		return err
+	}
+	if err := status.Err(); err != nil {
+		return err
+	}
+	slog.Info("database schema up to date", "version", status.Version)
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/config"
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+// runPurger permanently removes driver/helpers and vehicles that have been
+// soft-deleted for longer than the retention window, once at startup and then
+// every purge interval until ctx is cancelled. stores must be the audited
+// stores, so that each removal leaves a purge entry in the audit log.
+func runPurger(ctx context.Context, cfg config.Retention, stores model.Stores) {
+	if cfg.DeletedRecords.Duration == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(cfg.PurgeInterval.Duration)
+	defer ticker.Stop()
+
+	for {
+		purgeDeleted(ctx, time.Now().Add(-cfg.DeletedRecords.Duration), stores)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeActor is recorded as the actor of the purger's audit entries.
+const purgeActor = "retention-purger"
+
+func purgeDeleted(ctx context.Context, deletedBefore time.Time, stores model.Stores) {
+	ctx = model.WithActor(ctx, purgeActor)
+	ids, err := stores.Vehicles.PurgeDeletedVehicles(ctx, deletedBefore)
+	if err != nil {
+		slog.Error("failed to purge deleted vehicles", "error", err)
+	} else if len(ids) > 0 {
+		slog.Info("purged deleted vehicles", "count", len(ids), "deleted_before", deletedBefore)
+	}
+
+	ids, err = stores.DriverHelpers.PurgeDeletedDriverHelpers(ctx, deletedBefore)
+	if err != nil {
+		slog.Error("failed to purge deleted driver/helpers", "error", err)
+	} else if len(ids) > 0 {
+		slog.Info("purged deleted driver/helpers", "count", len(ids), "deleted_before", deletedBefore)
+	}
+}
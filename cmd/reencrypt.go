@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/config"
+	"github.com/arjunsaxaena/driver_vehicle_profile/controllers"
+	"github.com/arjunsaxaena/driver_vehicle_profile/fieldcrypt"
+)
+
+// runReencryptCommand implements the `reencrypt` subcommand, run after
+// enabling encryption, adding a new primary key or changing the index key.
+func runReencryptCommand(cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("reencrypt", flag.ContinueOnError)
+	batchSize := flags.Int("batch-size", 500, "rows to read per query")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *batchSize <= 0 {
+		return fmt.Errorf("invalid batch size %d; must be positive", *batchSize)
+	}
+	if cfg.Database.Backend != "postgres" {
+		return fmt.Errorf("reencrypt requires the postgres backend, not %q", cfg.Database.Backend)
+	}
+
+	keyring, err := newKeyring(cfg.Encryption)
+	if err != nil {
+		return err
+	}
+	if err := controllers.InitDB(cfg.Database); err != nil {
+		return err
+	}
+	db := controllers.GetDB()
+	defer db.Close()
+	if err := prepareSchema(cfg.Database); err != nil {
+		return err
+	}
+
+	stats, err := controllers.Reencrypt(context.Background(), db, keyring, *batchSize)
+	if err != nil {
+		return fmt.Errorf("reencrypt failed after %d driver/helpers, %d audit entries and %d webhooks: %w",
+			stats.DriverHelpers, stats.AuditEntries, stats.WebhookSubscriptions, err)
+	}
+	return json.NewEncoder(os.Stdout).Encode(stats)
+}
+
+// newKeyring builds the keyring described by cfg, or returns nil when encryption is not enabled.
+func newKeyring(cfg config.Encryption) (*fieldcrypt.Keyring, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+	keys := make([]fieldcrypt.Key, len(cfg.Keys))
+	for i, entry := range cfg.Keys {
+		var err error
+		if keys[i], err = fieldcrypt.ParseKey(entry); err != nil {
+			return nil, err
+		}
+	}
+	indexKey, err := fieldcrypt.DecodeKey(cfg.IndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("encryption index key: %w", err)
+	}
+	return fieldcrypt.NewKeyring(keys, cfg.PrimaryKey, indexKey)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/config"
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+	"github.com/arjunsaxaena/driver_vehicle_profile/notify"
+	"github.com/arjunsaxaena/driver_vehicle_profile/reminders"
+)
+
+// runReminders sends expiry reminders on the configured cron schedule until
+// ctx is cancelled, waiting for a run in progress to finish.
+func runReminders(ctx context.Context, cfg config.Reminders, stores model.Stores, reminderStore model.ReminderStore) {
+	if !cfg.Enabled {
+		return
+	}
+
+	job := &reminders.Job{
+		Stores:     stores,
+		Reminders:  reminderStore,
+		Notifiers:  reminderNotifiers(cfg),
+		DaysBefore: cfg.DaysBefore,
+	}
+
+	// SkipIfStillRunning keeps a slow run from overlapping the next one.
+	scheduler := cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger)))
+	// The schedule was checked when the config was validated.
+	if _, err := scheduler.AddFunc(cfg.Schedule, func() {
+		runCtx, cancel := context.WithTimeout(ctx, cfg.Timeout.Duration)
+		defer cancel()
+		if err := job.Run(runCtx, time.Now()); err != nil {
+			slog.Error("failed to send expiry reminders", "error", err)
+		}
+	}); err != nil {
+		slog.Error("failed to schedule expiry reminders", "error", err)
+		return
+	}
+
+	slog.Info("scheduled expiry reminders", "schedule", cfg.Schedule, "notifiers", len(job.Notifiers))
+	scheduler.Start()
+	<-ctx.Done()
+	<-scheduler.Stop().Done()
+}
+
+func reminderNotifiers(cfg config.Reminders) []notify.Notifier {
+	client := &http.Client{Timeout: 30 * time.Second}
+	var notifiers []notify.Notifier
+	if cfg.Email.SMTPAddr != "" {
+		notifiers = append(notifiers, &notify.Email{
+			Addr:     cfg.Email.SMTPAddr,
+			Username: cfg.Email.Username,
+			Password: cfg.Email.Password,
+			From:     cfg.Email.From,
+			To:       cfg.Email.To,
+		})
+	}
+	if cfg.SMS.URL != "" {
+		notifiers = append(notifiers, &notify.SMS{Client: client, URL: cfg.SMS.URL, APIKey: cfg.SMS.APIKey, To: cfg.SMS.To})
+	}
+	if cfg.Webhook.URL != "" {
+		notifiers = append(notifiers, &notify.Webhook{Client: client, URL: cfg.Webhook.URL, Secret: cfg.Webhook.Secret})
+	}
+	return notifiers
+}
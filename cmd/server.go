@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/config"
+)
+
+func newServer(cfg config.Server, handler http.Handler) *http.Server {
+	server := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout.Duration,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout.Duration,
+		WriteTimeout:      cfg.WriteTimeout.Duration,
+		IdleTimeout:       cfg.IdleTimeout.Duration,
+	}
+	if cfg.TLS.Enabled() {
+		server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return server
+}
+
+// runServer serves until ctx is cancelled, then stops accepting connections
+// and waits up to cfg.ShutdownTimeout for in-flight requests to finish.
+func runServer(ctx context.Context, server *http.Server, cfg config.Server) error {
+	errCh := make(chan error, 1)
+	go func() {
+		var err error
+		if cfg.TLS.Enabled() {
+			err = server.ListenAndServeTLS(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	slog.Info("server listening", "addr", cfg.Addr, "tls", cfg.TLS.Enabled())
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down server", "timeout", cfg.ShutdownTimeout.Duration)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout.Duration)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	return <-errCh
+}
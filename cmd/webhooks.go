@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/config"
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+	"github.com/arjunsaxaena/driver_vehicle_profile/webhooks"
+)
+
+// webhookBatchSize is how many due deliveries the dispatcher claims at a time.
+const webhookBatchSize = 20
+
+func newWebhookDispatcher(cfg config.Webhooks, store model.WebhookStore) *webhooks.Dispatcher {
+	return &webhooks.Dispatcher{
+		Store:          store,
+		Client:         &http.Client{Timeout: cfg.Timeout.Duration},
+		MaxAttempts:    cfg.MaxAttempts,
+		InitialBackoff: cfg.InitialBackoff.Duration,
+		MaxBackoff:     cfg.MaxBackoff.Duration,
+		BatchSize:      webhookBatchSize,
+	}
+}
+
+// runWebhooks delivers queued webhook deliveries and emits document expiry
+// events until ctx is cancelled.
+func runWebhooks(ctx context.Context, cfg config.Webhooks, dispatcher *webhooks.Dispatcher, watcher *webhooks.ExpiryWatcher) {
+	go watcher.Run(ctx, cfg.ExpiryCheckInterval.Duration)
+	dispatcher.Run(ctx, cfg.PollInterval.Duration)
+}
@@ -0,0 +1,243 @@
+// Package compliance evaluates vehicles and driver/helpers against the
+// document rules a school transport fleet must meet: insurance, pollution
+// under control (PUC) and fitness certificates for vehicles, and a driving
+// license and up-to-date police verification for the crew.
+package compliance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+	"github.com/arjunsaxaena/driver_vehicle_profile/validation"
+)
+
+type Status string
+
+// Statuses are ordered from best to worst; an item has the worst status of its checks.
+// StatusMissing means the document was never recorded, or was revoked: unlike
+// an expired one there is no date to renew it from.
+const (
+	StatusValid    Status = "valid"
+	StatusExpiring Status = "expiring"
+	StatusExpired  Status = "expired"
+	StatusMissing  Status = "missing"
+)
+
+var statusRank = map[Status]int{StatusValid: 0, StatusExpiring: 1, StatusExpired: 2, StatusMissing: 3}
+
+// ParseStatus accepts the name of a status.
+func ParseStatus(s string) (Status, error) {
+	if _, ok := statusRank[Status(s)]; !ok {
+		return "", model.NewFieldError("status", "invalid status: %s; must be 'valid', 'expiring', 'expired' or 'missing'", s)
+	}
+	return Status(s), nil
+}
+
+type Rule string
+
+const (
+	RuleInsurance          Rule = "insurance"
+	RulePollution          Rule = "pollution_certificate"
+	RuleFitness            Rule = "fitness_certificate"
+	RuleLicense            Rule = "license"
+	RulePoliceVerification Rule = "police_verification"
+)
+
+// Check is the outcome of one rule for one vehicle or driver/helper.
+type Check struct {
+	Rule   Rule   `json:"rule"`
+	Status Status `json:"status"`
+	// DueDate is when the document expires or is due for renewal; nil if none is recorded.
+	DueDate *time.Time `json:"due_date"`
+	// DaysLeft until DueDate, negative once it has passed.
+	DaysLeft *int   `json:"days_left,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+// Report holds the checks of one vehicle or driver/helper.
+type Report struct {
+	EntityType string    `json:"entity_type"`
+	ID         uuid.UUID `json:"id"`
+	// Name is the vehicle number, or the driver/helper's full name.
+	Name   string  `json:"name"`
+	Status Status  `json:"status"`
+	Checks []Check `json:"checks"`
+}
+
+// Engine applies the rules as of a given time.
+type Engine struct {
+	// ExpiringWithin is how close to its due date a document is reported as expiring.
+	ExpiringWithin time.Duration
+	// VerificationRenewalAge is how long a police verification stays valid;
+	// zero skips the police verification rule.
+	VerificationRenewalAge time.Duration
+}
+
+// Vehicle checks v's insurance, pollution and fitness certificates.
+func (e Engine) Vehicle(v model.Vehicle, now time.Time) Report {
+	return newReport(model.EntityVehicle, v.ID, v.VehicleNumber,
+		e.check(RuleInsurance, "insurance", v.InsuranceExpiryDate, now),
+		e.check(RulePollution, "pollution under control certificate", v.PollutionCertificateExpiryDate, now),
+		e.check(RuleFitness, "fitness certificate", v.FitnessCertificateExpiryDate, now),
+	)
+}
+
+// DriverHelper checks dh's police verification and, for drivers, their license.
+func (e Engine) DriverHelper(dh model.DriverHelper, now time.Time) Report {
+	var checks []Check
+	if dh.UserType == "Driver" {
+		var expiry time.Time
+		if dh.LicenseExpiryDate != nil {
+			expiry = *dh.LicenseExpiryDate
+		}
+		checks = append(checks, e.check(RuleLicense, "driving license", expiry, now))
+	}
+	if e.VerificationRenewalAge > 0 {
+		checks = append(checks, e.policeVerification(dh, now))
+	}
+	name := strings.TrimSpace(dh.FirstName + " " + dh.LastName)
+	return newReport(model.EntityDriverHelper, dh.ID, name, checks...)
+}
+
+func (e Engine) policeVerification(dh model.DriverHelper, now time.Time) Check {
+	switch {
+	case dh.PoliceVerification == "Yes" && dh.PoliceVerificationDate != nil:
+		return e.check(RulePoliceVerification, "police verification", dh.PoliceVerificationDate.Add(e.VerificationRenewalAge), now)
+	case dh.PoliceVerificationRevokedAt != nil:
+		return Check{Rule: RulePoliceVerification, Status: StatusMissing,
+			Reason: fmt.Sprintf("police verification was revoked on %s: %s",
+				dh.PoliceVerificationRevokedAt.Format(time.DateOnly), dh.PoliceVerificationRevocationReason)}
+	default:
+		return Check{Rule: RulePoliceVerification, Status: StatusMissing, Reason: "not police verified"}
+	}
+}
+
+// check rates a document called what that is due on due.
+func (e Engine) check(rule Rule, what string, due time.Time, now time.Time) Check {
+	if due.IsZero() {
+		return Check{Rule: rule, Status: StatusMissing, Reason: fmt.Sprintf("no %s expiry date is recorded", what)}
+	}
+
+	days := validation.DaysUntil(due, now)
+	c := Check{Rule: rule, DueDate: &due, DaysLeft: &days}
+	switch date := due.Format(time.DateOnly); {
+	case validation.Expired(due, now):
+		c.Status, c.Reason = StatusExpired, fmt.Sprintf("%s expired on %s", what, date)
+	case validation.Expired(due, now.Add(e.ExpiringWithin)):
+		c.Status, c.Reason = StatusExpiring, fmt.Sprintf("%s expires on %s, in %d days", what, date, days)
+	default:
+		c.Status, c.Reason = StatusValid, fmt.Sprintf("%s is valid until %s", what, date)
+	}
+	return c
+}
+
+func newReport(entityType string, id uuid.UUID, name string, checks ...Check) Report {
+	r := Report{EntityType: entityType, ID: id, Name: name, Status: StatusValid, Checks: checks}
+	for _, c := range checks {
+		if statusRank[c.Status] > statusRank[r.Status] {
+			r.Status = c.Status
+		}
+	}
+	return r
+}
+
+// Vehicles checks every vehicle in store.
+func (e Engine) Vehicles(ctx context.Context, store model.VehicleStore, now time.Time) ([]Report, error) {
+	filter := model.VehicleFilter{ListOptions: model.ListOptions{Limit: model.MaxPageLimit}}
+	var reports []Report
+	for {
+		page, err := store.Vehicles(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range page.Items {
+			reports = append(reports, e.Vehicle(v, now))
+		}
+		if page.NextCursor == "" {
+			return reports, nil
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
+
+// DriverHelpers checks every driver/helper in store.
+func (e Engine) DriverHelpers(ctx context.Context, store model.DriverHelperStore, now time.Time) ([]Report, error) {
+	filter := model.DriverHelperFilter{ListOptions: model.ListOptions{Limit: model.MaxPageLimit}}
+	var reports []Report
+	for {
+		page, err := store.DriverHelpers(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, dh := range page.Items {
+			reports = append(reports, e.DriverHelper(dh, now))
+		}
+		if page.NextCursor == "" {
+			return reports, nil
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
+
+// WithStatus returns the reports whose overall status is status.
+func WithStatus(reports []Report, status Status) []Report {
+	matched := []Report{}
+	for _, r := range reports {
+		if r.Status == status {
+			matched = append(matched, r)
+		}
+	}
+	return matched
+}
+
+type Counts struct {
+	Total    int `json:"total"`
+	Valid    int `json:"valid"`
+	Expiring int `json:"expiring"`
+	Expired  int `json:"expired"`
+	Missing  int `json:"missing"`
+}
+
+func (c *Counts) add(status Status) {
+	c.Total++
+	switch status {
+	case StatusValid:
+		c.Valid++
+	case StatusExpiring:
+		c.Expiring++
+	case StatusExpired:
+		c.Expired++
+	case StatusMissing:
+		c.Missing++
+	}
+}
+
+// Summary counts items by overall status, and checks by rule.
+type Summary struct {
+	Vehicles      Counts          `json:"vehicles"`
+	DriverHelpers Counts          `json:"driver_helpers"`
+	Rules         map[Rule]Counts `json:"rules"`
+}
+
+func Summarize(vehicles, driverHelpers []Report) Summary {
+	s := Summary{Rules: make(map[Rule]Counts)}
+	for _, group := range []struct {
+		reports []Report
+		counts  *Counts
+	}{{vehicles, &s.Vehicles}, {driverHelpers, &s.DriverHelpers}} {
+		for _, r := range group.reports {
+			group.counts.add(r.Status)
+			for _, c := range r.Checks {
+				counts := s.Rules[c.Rule]
+				counts.add(c.Status)
+				s.Rules[c.Rule] = counts
+			}
+		}
+	}
+	return s
+}
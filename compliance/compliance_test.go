@@ -0,0 +1,123 @@
+package compliance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+func TestVehicleExpiryBoundaries(t *testing.T) {
+	e := Engine{ExpiringWithin: 30 * 24 * time.Hour}
+	now := time.Date(2025, 3, 10, 18, 30, 0, 0, time.UTC)
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name string
+		due  time.Time
+		want Status
+		days int
+	}{
+		{name: "expired yesterday", due: day(2025, 3, 9), want: StatusExpired, days: -1},
+		{name: "expires today", due: day(2025, 3, 10), want: StatusExpiring, days: 0},
+		{name: "expires tomorrow", due: day(2025, 3, 11), want: StatusExpiring, days: 1},
+		{name: "last expiring day", due: day(2025, 4, 8), want: StatusExpiring, days: 29},
+		{name: "first valid day", due: day(2025, 4, 10), want: StatusValid, days: 31},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := model.Vehicle{InsuranceExpiryDate: tt.due, PollutionCertificateExpiryDate: tt.due, FitnessCertificateExpiryDate: tt.due}
+			r := e.Vehicle(v, now)
+			if r.Status != tt.want {
+				t.Errorf("status = %s, want %s", r.Status, tt.want)
+			}
+			for _, c := range r.Checks {
+				if c.DaysLeft == nil || *c.DaysLeft != tt.days {
+					t.Errorf("%s: days left = %v, want %d", c.Rule, c.DaysLeft, tt.days)
+				}
+			}
+		})
+	}
+}
+
+func TestDriverHelperMissingDocuments(t *testing.T) {
+	e := Engine{ExpiringWithin: 30 * 24 * time.Hour, VerificationRenewalAge: 365 * 24 * time.Hour}
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	verified := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	licensed := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	revoked := time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC)
+	lapsed := time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		dh   model.DriverHelper
+		want map[Rule]Status
+	}{
+		{
+			name: "never police verified",
+			dh:   model.DriverHelper{UserType: "Helper", PoliceVerification: "No"},
+			want: map[Rule]Status{RulePoliceVerification: StatusMissing},
+		},
+		{
+			name: "verified without a date",
+			dh:   model.DriverHelper{UserType: "Helper", PoliceVerification: "Yes"},
+			want: map[Rule]Status{RulePoliceVerification: StatusMissing},
+		},
+		{
+			name: "verification revoked",
+			dh:   model.DriverHelper{UserType: "Helper", PoliceVerification: "No", PoliceVerificationRevokedAt: &revoked},
+			want: map[Rule]Status{RulePoliceVerification: StatusMissing},
+		},
+		{
+			name: "driver without a license expiry date",
+			dh:   model.DriverHelper{UserType: "Driver", PoliceVerification: "Yes", PoliceVerificationDate: &verified},
+			want: map[Rule]Status{RuleLicense: StatusMissing, RulePoliceVerification: StatusValid},
+		},
+		{
+			name: "verification lapsed",
+			dh: model.DriverHelper{UserType: "Driver", PoliceVerification: "Yes",
+				PoliceVerificationDate: &lapsed, LicenseExpiryDate: &licensed},
+			want: map[Rule]Status{RuleLicense: StatusValid, RulePoliceVerification: StatusExpired},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := e.DriverHelper(tt.dh, now)
+			if len(r.Checks) != len(tt.want) {
+				t.Fatalf("got %d checks, want %d", len(r.Checks), len(tt.want))
+			}
+			worst := StatusValid
+			for _, c := range r.Checks {
+				if c.Status != tt.want[c.Rule] {
+					t.Errorf("%s: status = %s, want %s", c.Rule, c.Status, tt.want[c.Rule])
+				}
+				if statusRank[c.Status] > statusRank[worst] {
+					worst = c.Status
+				}
+			}
+			if r.Status != worst {
+				t.Errorf("report status = %s, want %s", r.Status, worst)
+			}
+		})
+	}
+}
+
+func TestSummarizeCountsMissing(t *testing.T) {
+	reports := []Report{
+		{Status: StatusValid, Checks: []Check{{Rule: RuleLicense, Status: StatusValid}}},
+		{Status: StatusMissing, Checks: []Check{{Rule: RuleLicense, Status: StatusMissing}}},
+		{Status: StatusExpired, Checks: []Check{{Rule: RuleLicense, Status: StatusExpired}}},
+	}
+	s := Summarize(nil, reports)
+	want := Counts{Total: 3, Valid: 1, Expired: 1, Missing: 1}
+	if s.DriverHelpers != want {
+		t.Errorf("driver/helpers = %+v, want %+v", s.DriverHelpers, want)
+	}
+	if s.Rules[RuleLicense] != want {
+		t.Errorf("license rule = %+v, want %+v", s.Rules[RuleLicense], want)
+	}
+	if got := WithStatus(reports, StatusMissing); len(got) != 1 {
+		t.Errorf("WithStatus(missing) = %d reports, want 1", len(got))
+	}
+	if _, err := ParseStatus("missing"); err != nil {
+		t.Errorf("ParseStatus(missing) = %v", err)
+	}
+}
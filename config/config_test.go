@@ -0,0 +1,187 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name, file, contents string
+	}{
+		{
+			name: "yaml",
+			file: "config.yaml",
+			contents: `
+server:
+  addr: ":4000"
+  write_timeout: 45s
+log:
+  level: debug
+database:
+  max_open_conns: 50
+`,
+		},
+		{
+			name: "toml",
+			file: "config.toml",
+			contents: `
+[server]
+addr = ":4000"
+write_timeout = "45s"
+
+[log]
+level = "debug"
+
+[database]
+max_open_conns = 50
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.contents), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("DVP_LOG_LEVEL", "warn")
+			t.Setenv("DVP_DATABASE_MAX_OPEN_CONNS", "40")
+
+			cfg, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			// Defaults stand where neither the file nor the environment say otherwise.
+			if got, want := cfg.Server.ReadTimeout.Duration, 15*time.Second; got != want {
+				t.Errorf("server.read_timeout = %s, want the default %s", got, want)
+			}
+			if got, want := cfg.Database.MaxIdleConns, 5; got != want {
+				t.Errorf("database.max_idle_conns = %d, want the default %d", got, want)
+			}
+			// The file overrides the defaults...
+			if got, want := cfg.Server.Addr, ":4000"; got != want {
+				t.Errorf("server.addr = %q, want %q from the file", got, want)
+			}
+			if got, want := cfg.Server.WriteTimeout.Duration, 45*time.Second; got != want {
+				t.Errorf("server.write_timeout = %s, want %s from the file", got, want)
+			}
+			// ...and the environment overrides the file.
+			if got, want := cfg.Log.Level, "warn"; got != want {
+				t.Errorf("log.level = %q, want %q from the environment", got, want)
+			}
+			if got, want := cfg.Database.MaxOpenConns, 40; got != want {
+				t.Errorf("database.max_open_conns = %d, want %d from the environment", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsInvalidEnv(t *testing.T) {
+	t.Setenv("DVP_SERVER_READ_TIMEOUT", "soon")
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "DVP_SERVER_READ_TIMEOUT") {
+		t.Errorf("Load = %v, want an error naming DVP_SERVER_READ_TIMEOUT", err)
+	}
+}
+
+func TestApplyEnvSlices(t *testing.T) {
+	cfg := Default()
+	env := map[string]string{"DVP_REMINDERS_DAYS_BEFORE": "14, 3,", "DVP_REMINDERS_EMAIL_TO": "a@example.com,b@example.com"}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+	if err := applyEnv(&cfg, lookup); err != nil {
+		t.Fatalf("applyEnv: %v", err)
+	}
+	if got := cfg.Reminders.DaysBefore; len(got) != 2 || got[0] != 14 || got[1] != 3 {
+		t.Errorf("reminders.days_before = %v, want [14 3]", got)
+	}
+	if got := cfg.Reminders.Email.To; len(got) != 2 || got[1] != "b@example.com" {
+		t.Errorf("reminders.email.to = %v, want two addresses", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatalf("Default().Validate() = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   string
+	}{
+		{
+			name:   "unknown backend",
+			modify: func(c *Config) { c.Database.Backend = "sqlite" },
+			want:   "database.backend",
+		},
+		{
+			name:   "more idle than open connections",
+			modify: func(c *Config) { c.Database.MaxOpenConns, c.Database.MaxIdleConns = 2, 5 },
+			want:   "database.max_idle_conns",
+		},
+		{
+			name:   "half of TLS",
+			modify: func(c *Config) { c.Server.TLS.CertFile = "cert.pem" },
+			want:   "server.tls",
+		},
+		{
+			name:   "unknown log level",
+			modify: func(c *Config) { c.Log.Level = "verbose" },
+			want:   "log.level",
+		},
+		{
+			name:   "auth without keys",
+			modify: func(c *Config) { c.Auth.Enabled = true },
+			want:   "auth.hmac_secret",
+		},
+		{
+			name:   "encryption keys without a primary",
+			modify: func(c *Config) { c.Encryption.Keys, c.Encryption.IndexKey = []string{"k1:AAAA"}, "AAAA" },
+			want:   "encryption.primary_key",
+		},
+		{
+			name:   "primary key without keys",
+			modify: func(c *Config) { c.Encryption.PrimaryKey = "k1" },
+			want:   "encryption.keys",
+		},
+		{
+			name: "reminders without a notifier",
+			modify: func(c *Config) {
+				c.Reminders.Enabled = true
+			},
+			want: "reminders.webhook.url",
+		},
+		{
+			name: "reminder address injecting a header",
+			modify: func(c *Config) {
+				c.Reminders.Enabled = true
+				c.Reminders.Email = ReminderEmail{SMTPAddr: "smtp.example.com:25", From: "fleet@example.com",
+					To: []string{"office@example.com\r\nBcc: attacker@example.com"}}
+			},
+			want: "reminders.email",
+		},
+		{
+			name: "webhook backoff shrinking",
+			modify: func(c *Config) {
+				c.Webhooks.Enabled = true
+				c.Webhooks.MaxBackoff = Duration{time.Second}
+			},
+			want: "webhooks.max_backoff",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.modify(&cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want an error mentioning %s", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+type DBAPIKeyStore struct {
+	db dbtx
+}
+
+var _ model.APIKeyStore = (*DBAPIKeyStore)(nil)
+
+func NewDBAPIKeyStore(db *sqlx.DB) *DBAPIKeyStore {
+	return &DBAPIKeyStore{db: db}
+}
+
+func (s *DBAPIKeyStore) CreateAPIKey(ctx context.Context, key *model.APIKey) error {
+	sb := sqlbuilder.NewInsertBuilder()
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	sb.InsertInto("api_keys").
+		Cols("id", "name", "prefix", "key_hash", "scopes", "expires_at", "created_by", "created_at", "updated_at").
+		Values(key.ID, key.Name, key.Prefix, key.KeyHash, key.Scopes, key.ExpiresAt, key.CreatedBy, key.CreatedAt, key.UpdatedAt).
+		SQL("RETURNING *")
+
+	query, args := sb.Build()
+	if err := s.db.GetContext(ctx, key, query, args...); err != nil {
+		return fmt.Errorf("failed to insert API key: %w", translateError(err))
+	}
+	return nil
+}
+
+func (s *DBAPIKeyStore) APIKeys(ctx context.Context) ([]model.APIKey, error) {
+	sb := sqlbuilder.NewSelectBuilder()
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	sb.Select("*").From("api_keys").OrderBy("created_at", "id")
+
+	var keys []model.APIKey
+	query, args := sb.Build()
+	if err := s.db.SelectContext(ctx, &keys, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch API keys: %w", translateError(err))
+	}
+	return keys, nil
+}
+
+func (s *DBAPIKeyStore) APIKeyByPrefix(ctx context.Context, prefix string) (model.APIKey, error) {
+	sb := sqlbuilder.NewSelectBuilder()
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	sb.Select("*").From("api_keys").Where(sb.Equal("prefix", prefix))
+
+	var key model.APIKey
+	query, args := sb.Build()
+	if err := s.db.GetContext(ctx, &key, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return key, fmt.Errorf("API key with prefix %s %w", prefix, model.ErrNotFound)
+		}
+		return key, fmt.Errorf("failed to fetch API key: %w", translateError(err))
+	}
+	return key, nil
+}
+
+func (s *DBAPIKeyStore) RotateAPIKey(ctx context.Context, id uuid.UUID, prefix string, keyHash []byte) (model.APIKey, error) {
+	sb := sqlbuilder.NewUpdateBuilder()
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	sb.Update("api_keys").Set(
+		sb.Assign("prefix", prefix),
+		sb.Assign("key_hash", keyHash),
+		sb.Assign("last_used_at", nil),
+		sb.Assign("updated_at", time.Now()),
+	).Where(sb.Equal("id", id), sb.IsNull("revoked_at")).SQL("RETURNING *")
+
+	return s.updateUnrevoked(ctx, sb, id, "rotate")
+}
+
+func (s *DBAPIKeyStore) RevokeAPIKey(ctx context.Context, id uuid.UUID) (model.APIKey, error) {
+	now := time.Now()
+	sb := sqlbuilder.NewUpdateBuilder()
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	sb.Update("api_keys").Set(
+		sb.Assign("revoked_at", now),
+		sb.Assign("updated_at", now),
+	).Where(sb.Equal("id", id), sb.IsNull("revoked_at")).SQL("RETURNING *")
+
+	return s.updateUnrevoked(ctx, sb, id, "revoke")
+}
+
+// updateUnrevoked runs an update conditional on the key not being revoked and
+// explains a miss as either a missing or an already revoked key.
+func (s *DBAPIKeyStore) updateUnrevoked(ctx context.Context, sb *sqlbuilder.UpdateBuilder, id uuid.UUID, action string) (model.APIKey, error) {
+	var key model.APIKey
+	query, args := sb.Build()
+	err := s.db.GetContext(ctx, &key, query, args...)
+	if err == nil {
+		return key, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return key, fmt.Errorf("failed to %s API key: %w", action, translateError(err))
+	}
+
+	var exists bool
+	if err := s.db.GetContext(ctx, &exists, "SELECT EXISTS (SELECT 1 FROM api_keys WHERE id = $1)", id); err != nil {
+		return key, fmt.Errorf("failed to check if API key exists: %w", translateError(err))
+	}
+	if exists {
+		return key, model.RevokedError("API key", id)
+	}
+	return key, model.NotFoundError("API key", id)
+}
+
+func (s *DBAPIKeyStore) TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	sb := sqlbuilder.NewUpdateBuilder()
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	sb.Update("api_keys").Set(sb.Assign("last_used_at", usedAt)).Where(sb.Equal("id", id))
+
+	query, args := sb.Build()
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to record API key use: %w", translateError(err))
+	}
+	return nil
+}
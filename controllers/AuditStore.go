@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/fieldcrypt"
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+// DBAuditStore encrypts the recorded values of sealed driver/helper columns.
+type DBAuditStore struct {
+	db      dbtx
+	keyring *fieldcrypt.Keyring
+}
+
+var _ model.AuditStore = (*DBAuditStore)(nil)
+
+func NewDBAuditStore(db *sqlx.DB, keyring *fieldcrypt.Keyring) *DBAuditStore {
+	return &DBAuditStore{db: db, keyring: keyring}
+}
+
+func (s *DBAuditStore) RecordAudit(ctx context.Context, entry *model.AuditEntry) error {
+	if entry.ID == uuid.Nil {
+		entry.ID = uuid.New()
+	}
+
+	changes, err := sealChanges(s.keyring, entry)
+	if err != nil {
+		return err
+	}
+
+	sb := sqlbuilder.NewInsertBuilder()
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	sb.InsertInto("audit_log").
+		Cols("id", "entity_type", "entity_id", "operation", "actor", "changes", "created_at").
+		Values(entry.ID, entry.EntityType, entry.EntityID, entry.Operation, entry.Actor, changes, entry.CreatedAt)
+
+	query, args := sb.Build()
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", translateError(err))
+	}
+	return nil
+}
+
+func (s *DBAuditStore) AuditHistory(ctx context.Context, entityType string, entityID uuid.UUID, filter model.AuditFilter) (model.Page[model.AuditEntry], error) {
+	var page model.Page[model.AuditEntry]
+	if err := filter.Normalize(); err != nil {
+		return page, err
+	}
+
+	cb := sqlbuilder.NewSelectBuilder()
+	cb.SetFlavor(sqlbuilder.PostgreSQL)
+	cb.Select("COUNT(*)").From("audit_log")
+	applyAuditFilter(cb, entityType, entityID, filter)
+
+	query, args := cb.Build()
+	if err := s.db.GetContext(ctx, &page.Total, query, args...); err != nil {
+		return page, fmt.Errorf("failed to count audit entries: %w", translateError(err))
+	}
+
+	sb := sqlbuilder.NewSelectBuilder()
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	sb.Select("*").From("audit_log")
+	applyAuditFilter(sb, entityType, entityID, filter)
+	if err := applyKeyset(sb, filter.ListOptions, model.AuditEntry{}.SortKey(filter.Sort)); err != nil {
+		return page, err
+	}
+
+	var entries []model.AuditEntry
+	query, args = sb.Build()
+	if err := s.db.SelectContext(ctx, &entries, query, args...); err != nil {
+		return page, fmt.Errorf("failed to fetch audit entries: %w", translateError(err))
+	}
+
+	if len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+		last := entries[len(entries)-1]
+		page.NextCursor = model.EncodeCursor(last.SortKey(filter.Sort), last.ID)
+	}
+	for i := range entries {
+		if err := openChanges(s.keyring, &entries[i]); err != nil {
+			return page, err
+		}
+	}
+	page.Items = entries
+	return page, nil
+}
+
+func applyAuditFilter(sb *sqlbuilder.SelectBuilder, entityType string, entityID uuid.UUID, filter model.AuditFilter) {
+	sb.Where(sb.Equal("entity_type", entityType), sb.Equal("entity_id", entityID))
+	if filter.Operation != "" {
+		sb.Where(sb.Equal("operation", filter.Operation))
+	}
+}
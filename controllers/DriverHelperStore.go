@@ -1,6 +1,9 @@
 package controllers
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,180 +12,426 @@ import (
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 
+	"github.com/arjunsaxaena/driver_vehicle_profile/fieldcrypt"
 	"github.com/arjunsaxaena/driver_vehicle_profile/model"
 )
 
 type DBDriverHelperStore struct {
-	db *sqlx.DB
+	db      dbtx
+	keyring *fieldcrypt.Keyring
 }
 
-func NewDBDriverHelperStore(db *sqlx.DB) *DBDriverHelperStore {
-	return &DBDriverHelperStore{db: db}
+var _ model.DriverHelperStore = (*DBDriverHelperStore)(nil)
+
+// driverHelperWriteColumns are the columns a full update writes.
+var driverHelperWriteColumns = []string{
+	"user_type", "first_name", "last_name", "mobile_number", "aadhar_number",
+	"license_number", "license_expiry_date", "license_document_path", "police_verification",
+	"police_verification_date", "police_verification_document_path", "additional_documents_path",
+	"blood_group", "emergency_contact_name", "emergency_contact_number", "emergency_contact_relation",
+}
+
+func NewDBDriverHelperStore(db *sqlx.DB, keyring *fieldcrypt.Keyring) *DBDriverHelperStore {
+	return &DBDriverHelperStore{db: db, keyring: keyring}
 }
 
-func (s *DBDriverHelperStore) DriverHelperByID(id uuid.UUID) (model.DriverHelper, error) {
+func (s *DBDriverHelperStore) DriverHelperByID(ctx context.Context, id uuid.UUID) (model.DriverHelper, error) {
 	var dh model.DriverHelper
 	sb := sqlbuilder.NewSelectBuilder()
 	sb.SetFlavor(sqlbuilder.PostgreSQL)
-	sb.Select("*").From("driver_helpers").Where(sb.Equal("id", id))
+	sb.Select(driverHelperColumns...).From("driver_helpers").Where(sb.Equal("id", id))
+	excludeDeleted(ctx, sb)
 
 	query, args := sb.Build()
-	err := s.db.Get(&dh, query, args...)
-	return dh, err
+	if err := s.db.GetContext(ctx, &dh, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dh, model.NotFoundError("driver/helper", id)
+		}
+		return dh, fmt.Errorf("failed to fetch driver/helper: %w", translateError(err))
+	}
+	return dh, openDriverHelpers(s.keyring, &dh)
 }
 
-func (s *DBDriverHelperStore) DriverHelpers() ([]model.DriverHelper, error) {
-	var dhs []model.DriverHelper
+func (s *DBDriverHelperStore) DriverHelpers(ctx context.Context, filter model.DriverHelperFilter) (model.Page[model.DriverHelper], error) {
+	var page model.Page[model.DriverHelper]
+	if err := filter.Normalize(); err != nil {
+		return page, err
+	}
+
+	cb := sqlbuilder.NewSelectBuilder()
+	cb.SetFlavor(sqlbuilder.PostgreSQL)
+	cb.Select("COUNT(*)").From("driver_helpers")
+	applyDriverHelperFilter(cb, filter)
+	excludeDeleted(ctx, cb)
+
+	query, args := cb.Build()
+	if err := s.db.GetContext(ctx, &page.Total, query, args...); err != nil {
+		return page, fmt.Errorf("failed to count driver/helpers: %w", translateError(err))
+	}
+
 	sb := sqlbuilder.NewSelectBuilder()
 	sb.SetFlavor(sqlbuilder.PostgreSQL)
-	sb.Select("*").From("driver_helpers")
+	sb.Select(driverHelperColumns...).From("driver_helpers")
+	applyDriverHelperFilter(sb, filter)
+	excludeDeleted(ctx, sb)
+	if err := applyKeyset(sb, filter.ListOptions, model.DriverHelper{}.SortKey(filter.Sort)); err != nil {
+		return page, err
+	}
 
-	query, args := sb.Build()
-	err := s.db.Select(&dhs, query, args...)
-	return dhs, err
+	var dhs []model.DriverHelper
+	query, args = sb.Build()
+	if err := s.db.SelectContext(ctx, &dhs, query, args...); err != nil {
+		return page, fmt.Errorf("failed to fetch driver/helpers: %w", translateError(err))
+	}
+
+	if len(dhs) > filter.Limit {
+		dhs = dhs[:filter.Limit]
+		last := dhs[len(dhs)-1]
+		page.NextCursor = model.EncodeCursor(last.SortKey(filter.Sort), last.ID)
+	}
+	page.Items = dhs
+	return page, s.openAll(dhs)
+}
+
+func applyDriverHelperFilter(sb *sqlbuilder.SelectBuilder, filter model.DriverHelperFilter) {
+	if filter.UserType != "" {
+		sb.Where(sb.Equal("user_type", filter.UserType))
+	}
+	if filter.PoliceVerification != "" {
+		sb.Where(sb.Equal("police_verification", filter.PoliceVerification))
+	}
+	if filter.BloodGroup != "" {
+		sb.Where(sb.Equal("blood_group", filter.BloodGroup))
+	}
+	if filter.LicenseExpiresAfter != nil {
+		sb.Where(sb.GreaterEqualThan("license_expiry_date", *filter.LicenseExpiresAfter))
+	}
+	if filter.LicenseExpiresBefore != nil {
+		sb.Where(sb.LessEqualThan("license_expiry_date", *filter.LicenseExpiresBefore))
+	}
 }
 
-func (s *DBDriverHelperStore) Drivers() ([]model.DriverHelper, error) {
+func (s *DBDriverHelperStore) Drivers(ctx context.Context) ([]model.DriverHelper, error) {
 	var drivers []model.DriverHelper
 	sb := sqlbuilder.NewSelectBuilder()
 	sb.SetFlavor(sqlbuilder.PostgreSQL)
-	sb.Select("*").From("driver_helpers").Where(sb.Equal("user_type", "Driver"))
+	sb.Select(driverHelperColumns...).From("driver_helpers").Where(sb.Equal("user_type", "Driver"))
+	excludeDeleted(ctx, sb)
 
 	query, args := sb.Build()
-	err := s.db.Select(&drivers, query, args...)
-	return drivers, err
+	if err := s.db.SelectContext(ctx, &drivers, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch drivers: %w", translateError(err))
+	}
+	return drivers, s.openAll(drivers)
 }
 
-func (s *DBDriverHelperStore) Helpers() ([]model.DriverHelper, error) {
+func (s *DBDriverHelperStore) Helpers(ctx context.Context) ([]model.DriverHelper, error) {
 	var helpers []model.DriverHelper
 	sb := sqlbuilder.NewSelectBuilder()
 	sb.SetFlavor(sqlbuilder.PostgreSQL)
-	sb.Select("*").From("driver_helpers").Where(sb.Equal("user_type", "Helper"))
+	sb.Select(driverHelperColumns...).From("driver_helpers").Where(sb.Equal("user_type", "Helper"))
+	excludeDeleted(ctx, sb)
 
 	query, args := sb.Build()
-	err := s.db.Select(&helpers, query, args...)
-	return helpers, err
+	if err := s.db.SelectContext(ctx, &helpers, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch helpers: %w", translateError(err))
+	}
+	return helpers, s.openAll(helpers)
 }
 
-func (s *DBDriverHelperStore) VerifiedDriverHelpers() ([]model.DriverHelper, error) {
+func (s *DBDriverHelperStore) VerifiedDriverHelpers(ctx context.Context) ([]model.DriverHelper, error) {
 	var dhs []model.DriverHelper
 	sb := sqlbuilder.NewSelectBuilder()
 	sb.SetFlavor(sqlbuilder.PostgreSQL)
-	sb.Select("*").From("driver_helpers").Where(sb.Equal("police_verification", "Yes"))
+	sb.Select(driverHelperColumns...).From("driver_helpers").Where(sb.Equal("police_verification", "Yes"))
+	excludeDeleted(ctx, sb)
 
 	query, args := sb.Build()
-	err := s.db.Select(&dhs, query, args...)
-	return dhs, err
+	if err := s.db.SelectContext(ctx, &dhs, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch driver/helpers: %w", translateError(err))
+	}
+	return dhs, s.openAll(dhs)
 }
 
-func (s *DBDriverHelperStore) PendingVerificationDriverHelpers() ([]model.DriverHelper, error) {
+func (s *DBDriverHelperStore) PendingVerificationDriverHelpers(ctx context.Context) ([]model.DriverHelper, error) {
 	var dhs []model.DriverHelper
 	sb := sqlbuilder.NewSelectBuilder()
 	sb.SetFlavor(sqlbuilder.PostgreSQL)
-	sb.Select("*").From("driver_helpers").Where(sb.Equal("police_verification", "No"))
+	sb.Select(driverHelperColumns...).From("driver_helpers").Where(sb.Equal("police_verification", "No"))
+	excludeDeleted(ctx, sb)
 
 	query, args := sb.Build()
-	err := s.db.Select(&dhs, query, args...)
-	return dhs, err
+	if err := s.db.SelectContext(ctx, &dhs, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch driver/helpers: %w", translateError(err))
+	}
+	return dhs, s.openAll(dhs)
 }
 
-func (s *DBDriverHelperStore) CreateDriverHelper(dh *model.DriverHelper) error {
-	if dh.ID == uuid.Nil {
-		dh.ID = uuid.New()
+func (s *DBDriverHelperStore) VerificationsDueForRenewal(ctx context.Context, verifiedBefore time.Time) ([]model.DriverHelper, error) {
+	var dhs []model.DriverHelper
+	sb := sqlbuilder.NewSelectBuilder()
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	sb.Select(driverHelperColumns...).From("driver_helpers").Where(
+		sb.Equal("police_verification", "Yes"),
+		sb.LessThan("police_verification_date", verifiedBefore),
+	).OrderBy("police_verification_date")
+	excludeDeleted(ctx, sb)
+
+	query, args := sb.Build()
+	if err := s.db.SelectContext(ctx, &dhs, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch driver/helpers due for verification renewal: %w", translateError(err))
 	}
-	if dh.UserType != "Driver" && dh.UserType != "Helper" {
-		return fmt.Errorf("invalid user_type: %s; must be 'Driver' or 'Helper'", dh.UserType)
+	return dhs, s.openAll(dhs)
+}
+
+func (s *DBDriverHelperStore) LicensesExpiringBetween(ctx context.Context, from, to time.Time) ([]model.DriverHelper, error) {
+	var dhs []model.DriverHelper
+	sb := sqlbuilder.NewSelectBuilder()
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	sb.Select(driverHelperColumns...).From("driver_helpers").Where(
+		sb.Equal("user_type", "Driver"),
+		sb.GreaterEqualThan("license_expiry_date", from),
+		sb.LessThan("license_expiry_date", to),
+	).OrderBy("license_expiry_date")
+	excludeDeleted(ctx, sb)
+
+	query, args := sb.Build()
+	if err := s.db.SelectContext(ctx, &dhs, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch drivers with expiring licenses: %w", translateError(err))
 	}
+	return dhs, s.openAll(dhs)
+}
+
+func (s *DBDriverHelperStore) ExpiredLicenses(ctx context.Context, asOf time.Time) ([]model.DriverHelper, error) {
+	var dhs []model.DriverHelper
+	sb := sqlbuilder.NewSelectBuilder()
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	sb.Select(driverHelperColumns...).From("driver_helpers").Where(
+		sb.Equal("user_type", "Driver"),
+		sb.LessThan("license_expiry_date", asOf),
+	).OrderBy("license_expiry_date")
+	excludeDeleted(ctx, sb)
 
-	validBloodGroups := map[string]bool{
-		"A+": true, "A-": true, "B+": true, "B-": true, "AB+": true, "AB-": true, "O+": true, "O-": true,
+	query, args := sb.Build()
+	if err := s.db.SelectContext(ctx, &dhs, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch drivers with expired licenses: %w", translateError(err))
 	}
+	return dhs, s.openAll(dhs)
+}
 
-	if !validBloodGroups[dh.BloodGroup] {
-		return fmt.Errorf("invalid blood_group: %s; must be one of: A+, A-, B+, B-, AB+, AB-, O+, O-", dh.BloodGroup)
+func (s *DBDriverHelperStore) SubmitPoliceVerification(ctx context.Context, id uuid.UUID, expectedVersion int, date time.Time, documentPath string) (model.DriverHelper, error) {
+	dh, err := s.DriverHelperByID(ctx, id)
+	if err != nil {
+		return dh, err
+	}
+	if err := dh.ApplyPoliceVerification(date, documentPath); err != nil {
+		return dh, err
 	}
-	if len(dh.MobileNumber) != 10 {
-		return fmt.Errorf("invalid mobile_number: %s; must be a 10-digit number", dh.MobileNumber)
+	return s.savePoliceVerification(ctx, dh, expectedVersion)
+}
+
+func (s *DBDriverHelperStore) RevokePoliceVerification(ctx context.Context, id uuid.UUID, expectedVersion int, reason string) (model.DriverHelper, error) {
+	dh, err := s.DriverHelperByID(ctx, id)
+	if err != nil {
+		return dh, err
 	}
-	if len(dh.AadharNumber) != 12 {
-		return fmt.Errorf("invalid aadhar_number: %s; must be a 12-digit number", dh.AadharNumber)
+	if err := dh.RevokePoliceVerification(reason, time.Now()); err != nil {
+		return dh, err
 	}
-	if dh.PoliceVerification == "Yes" {
-		if dh.PoliceVerificationDate == nil {
-			return fmt.Errorf("police_verification_date is required when police_verification is 'Yes'")
-		}
-		if dh.PoliceVerificationDocumentPath == "" {
-			return fmt.Errorf("police_verification_document_path is required when police_verification is 'Yes'")
+	return s.savePoliceVerification(ctx, dh, expectedVersion)
+}
+
+// savePoliceVerification writes the police verification columns of dh,
+// conditional on expectedVersion when set.
+func (s *DBDriverHelperStore) savePoliceVerification(ctx context.Context, dh model.DriverHelper, expectedVersion int) (model.DriverHelper, error) {
+	var updated model.DriverHelper
+	sb := sqlbuilder.NewUpdateBuilder()
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	sb.Update("driver_helpers").Set(
+		sb.Assign("police_verification", dh.PoliceVerification),
+		sb.Assign("police_verification_date", dh.PoliceVerificationDate),
+		sb.Assign("police_verification_document_path", dh.PoliceVerificationDocumentPath),
+		sb.Assign("police_verification_revoked_at", dh.PoliceVerificationRevokedAt),
+		sb.Assign("police_verification_revocation_reason", dh.PoliceVerificationRevocationReason),
+		sb.Assign("updated_at", time.Now()),
+		sb.Incr("version"),
+	).Where(sb.Equal("id", dh.ID), sb.IsNull("deleted_at"))
+	if expectedVersion != 0 {
+		sb.Where(sb.Equal("version", expectedVersion))
+	}
+	sb.SQL(returningDriverHelper)
+
+	query, args := sb.Build()
+	if err := s.db.GetContext(ctx, &updated, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return updated, missingRowError(ctx, s.db, "driver_helpers", "driver/helper", dh.ID, expectedVersion)
 		}
+		return updated, fmt.Errorf("failed to update police verification: %w", translateError(err))
+	}
+	return updated, openDriverHelpers(s.keyring, &updated)
+}
+
+func (s *DBDriverHelperStore) CreateDriverHelper(ctx context.Context, dh *model.DriverHelper) error {
+	if dh.ID == uuid.Nil {
+		dh.ID = uuid.New()
+	}
+	if err := dh.Validate(); err != nil {
+		return err
 	}
 
+	columns, values, err := storedDriverHelperValues(s.keyring, dh, append([]string{"id"}, driverHelperWriteColumns...))
+	if err != nil {
+		return err
+	}
 	sb := sqlbuilder.NewInsertBuilder()
 	sb.SetFlavor(sqlbuilder.PostgreSQL)
-	sb.InsertInto("driver_helpers").
-		Cols("id", "user_type", "first_name", "last_name", "mobile_number", "aadhar_number",
-			"license_number", "license_expiry_date", "license_document_path", "police_verification",
-			"police_verification_date", "police_verification_document_path", "additional_documents_path",
-			"blood_group", "emergency_contact_name", "emergency_contact_number", "emergency_contact_relation").
-		Values(dh.ID, dh.UserType, dh.FirstName, dh.LastName, dh.MobileNumber, dh.AadharNumber,
-			dh.LicenseNumber, dh.LicenseExpiryDate, dh.LicenseDocumentPath, dh.PoliceVerification,
-			dh.PoliceVerificationDate, dh.PoliceVerificationDocumentPath, dh.AdditionalDocumentsPath,
-			dh.BloodGroup, dh.EmergencyContactName, dh.EmergencyContactNumber, dh.EmergencyContactRelation)
+	sb.InsertInto("driver_helpers").Cols(columns...).Values(values...).SQL(returningDriverHelper)
 
 	query, args := sb.Build()
 
-	_, err := s.db.Exec(query, args...)
+	err = s.db.GetContext(ctx, dh, query, args...)
 	if err != nil {
-		return fmt.Errorf("failed to insert driver/helper: %w", err)
+		return fmt.Errorf("failed to insert driver/helper: %w", translateError(err))
 	}
 
+	return openDriverHelpers(s.keyring, dh)
+}
+
+func (s *DBDriverHelperStore) UpdateDriverHelper(ctx context.Context, dh *model.DriverHelper) error {
+	if err := dh.Validate(); err != nil {
+		return err
+	}
+
+	return s.update(ctx, dh, driverHelperWriteColumns)
+}
+
+func (s *DBDriverHelperStore) PatchDriverHelper(ctx context.Context, dh *model.DriverHelper, columns []string) error {
+	if err := model.ValidatePatchColumns(dh, columns); err != nil {
+		return err
+	}
+	if err := dh.ValidateColumns(columns); err != nil {
+		return err
+	}
+
+	return s.update(ctx, dh, columns)
+}
+
+// update writes the given columns of dh, conditional on its version when set,
+// and reloads dh from the stored row.
+func (s *DBDriverHelperStore) update(ctx context.Context, dh *model.DriverHelper, columns []string) error {
+	columns, values, err := storedDriverHelperValues(s.keyring, dh, columns)
+	if err != nil {
+		return err
+	}
+
+	sb := sqlbuilder.NewUpdateBuilder()
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	sb.Update("driver_helpers")
+	for i, column := range columns {
+		sb.SetMore(sb.Assign(column, values[i]))
+	}
+	sb.SetMore(sb.Assign("updated_at", time.Now()), sb.Incr("version")).
+		Where(sb.Equal("id", dh.ID), sb.IsNull("deleted_at"))
+	if dh.Version != 0 {
+		sb.Where(sb.Equal("version", dh.Version))
+	}
+	sb.SQL(returningDriverHelper)
+
+	query, args := sb.Build()
+	version := dh.Version
+	if err := s.db.GetContext(ctx, dh, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return missingRowError(ctx, s.db, "driver_helpers", "driver/helper", dh.ID, version)
+		}
+		return fmt.Errorf("failed to update driver/helper: %w", translateError(err))
+	}
+	return openDriverHelpers(s.keyring, dh)
+}
+
+// openAll decrypts the sealed fields of dhs in place.
+func (s *DBDriverHelperStore) openAll(dhs []model.DriverHelper) error {
+	for i := range dhs {
+		if err := openDriverHelpers(s.keyring, &dhs[i]); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (s *DBDriverHelperStore) UpdateDriverHelper(dh *model.DriverHelper) error {
+func (s *DBDriverHelperStore) DeleteDriverHelper(ctx context.Context, id uuid.UUID, expectedVersion int, reason string) error {
 	sb := sqlbuilder.NewUpdateBuilder()
 	sb.SetFlavor(sqlbuilder.PostgreSQL)
 	sb.Update("driver_helpers").Set(
-		sb.Assign("user_type", dh.UserType),
-		sb.Assign("first_name", dh.FirstName),
-		sb.Assign("last_name", dh.LastName),
-		sb.Assign("mobile_number", dh.MobileNumber),
-		sb.Assign("aadhar_number", dh.AadharNumber),
-		sb.Assign("license_number", dh.LicenseNumber),
-		sb.Assign("license_expiry_date", dh.LicenseExpiryDate),
-		sb.Assign("license_document_path", dh.LicenseDocumentPath),
-		sb.Assign("police_verification", dh.PoliceVerification),
-		sb.Assign("police_verification_date", dh.PoliceVerificationDate),
-		sb.Assign("police_verification_document_path", dh.PoliceVerificationDocumentPath),
-		sb.Assign("additional_documents_path", dh.AdditionalDocumentsPath),
-		sb.Assign("blood_group", dh.BloodGroup),
-		sb.Assign("emergency_contact_name", dh.EmergencyContactName),
-		sb.Assign("emergency_contact_number", dh.EmergencyContactNumber),
-		sb.Assign("emergency_contact_relation", dh.EmergencyContactRelation),
+		sb.Assign("deleted_at", time.Now()),
+		sb.Assign("deleted_by", model.Actor(ctx)),
+		sb.Assign("delete_reason", reason),
 		sb.Assign("updated_at", time.Now()),
-	).Where(sb.Equal("id", dh.ID))
+		sb.Incr("version"),
+	).Where(sb.Equal("id", id), sb.IsNull("deleted_at"))
+	if expectedVersion != 0 {
+		sb.Where(sb.Equal("version", expectedVersion))
+	}
 
 	query, args := sb.Build()
-	_, err := s.db.Exec(query, args...)
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete driver/helper: %w", translateError(err))
+	}
+	err = requireRowAffected(res, model.ErrNotFound)
+	if errors.Is(err, model.ErrNotFound) {
+		return missingRowError(ctx, s.db, "driver_helpers", "driver/helper", id, expectedVersion)
+	}
 	return err
 }
 
-func (s *DBDriverHelperStore) DeleteDriverHelper(id uuid.UUID) error {
+func (s *DBDriverHelperStore) RestoreDriverHelper(ctx context.Context, id uuid.UUID) (model.DriverHelper, error) {
+	var dh model.DriverHelper
+	sb := sqlbuilder.NewUpdateBuilder()
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	sb.Update("driver_helpers").Set(
+		sb.Assign("deleted_at", nil),
+		sb.Assign("deleted_by", ""),
+		sb.Assign("delete_reason", ""),
+		sb.Assign("updated_at", time.Now()),
+		sb.Incr("version"),
+	).Where(sb.Equal("id", id), sb.IsNotNull("deleted_at")).SQL(returningDriverHelper)
+
+	query, args := sb.Build()
+	if err := s.db.GetContext(ctx, &dh, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dh, restoreMissError(ctx, s.db, "driver_helpers", "driver/helper", id)
+		}
+		return dh, fmt.Errorf("failed to restore driver/helper: %w", translateError(err))
+	}
+	return dh, openDriverHelpers(s.keyring, &dh)
+}
+
+func (s *DBDriverHelperStore) PurgeDeletedDriverHelpers(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error) {
 	sb := sqlbuilder.NewDeleteBuilder()
 	sb.SetFlavor(sqlbuilder.PostgreSQL)
-	sb.DeleteFrom("driver_helpers").Where(sb.Equal("id", id))
+	sb.DeleteFrom("driver_helpers").Where(sb.LessThan("deleted_at", deletedBefore)).SQL("RETURNING id")
 
 	query, args := sb.Build()
-	_, err := s.db.Exec(query, args...)
-	return err
+	var ids []uuid.UUID
+	if err := s.db.SelectContext(ctx, &ids, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to purge deleted driver/helpers: %w", translateError(err))
+	}
+	return ids, nil
 }
 
-func (s *DBDriverHelperStore) DriverHelperByMobileNumber(mobile string) (model.DriverHelper, error) {
+func (s *DBDriverHelperStore) DriverHelperByMobileNumber(ctx context.Context, mobile string) (model.DriverHelper, error) {
 	var dh model.DriverHelper
 	sb := sqlbuilder.NewSelectBuilder()
 	sb.SetFlavor(sqlbuilder.PostgreSQL)
-	sb.Select("*").From("driver_helpers").Where(sb.Equal("mobile_number", mobile))
+	sb.Select(driverHelperColumns...).From("driver_helpers").Where(sb.Equal("mobile_number", mobile))
+	excludeDeleted(ctx, sb)
 
 	query, args := sb.Build()
-	err := s.db.Get(&dh, query, args...)
-	return dh, err
+	if err := s.db.GetContext(ctx, &dh, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dh, fmt.Errorf("driver/helper with mobile number %s %w", mobile, model.ErrNotFound)
+		}
+		return dh, fmt.Errorf("failed to fetch driver/helper: %w", translateError(err))
+	}
+	return dh, openDriverHelpers(s.keyring, &dh)
 }
@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+type DBReminderStore struct {
+	db dbtx
+}
+
+var _ model.ReminderStore = (*DBReminderStore)(nil)
+
+func NewDBReminderStore(db *sqlx.DB) *DBReminderStore {
+	return &DBReminderStore{db: db}
+}
+
+func (s *DBReminderStore) ClaimReminder(ctx context.Context, r model.Reminder) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO reminders_sent (entity_type, entity_id, document, due_date, days_before, channel, sent_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT DO NOTHING`,
+		r.EntityType, r.EntityID, r.Document, r.DueDate, r.DaysBefore, r.Channel, r.SentAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to record reminder: %w", translateError(err))
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, translateError(err)
+	}
+	return n == 1, nil
+}
+
+func (s *DBReminderStore) ReleaseReminder(ctx context.Context, r model.Reminder) error {
+	_, err := s.db.ExecContext(ctx, `
+		DELETE FROM reminders_sent
+		WHERE entity_type = $1 AND entity_id = $2 AND document = $3 AND due_date = $4 AND days_before = $5 AND channel = $6`,
+		r.EntityType, r.EntityID, r.Document, r.DueDate, r.DaysBefore, r.Channel)
+	if err != nil {
+		return fmt.Errorf("failed to release reminder: %w", translateError(err))
+	}
+	return nil
+}
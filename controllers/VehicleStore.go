@@ -1,76 +1,65 @@
 package controllers
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/huandu/go-sqlbuilder"
 	"github.com/jmoiron/sqlx"
-	"github.com/lib/pq"
 	_ "github.com/lib/pq"
 
 	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+	"github.com/arjunsaxaena/driver_vehicle_profile/validation"
 )
 
 type DBVehicleStore struct {
-	db *sqlx.DB
+	db dbtx
 }
 
+var _ model.VehicleStore = (*DBVehicleStore)(nil)
+
 func NewDBVehicleStore(db *sqlx.DB) *DBVehicleStore {
 	return &DBVehicleStore{db: db}
 }
 
-func (s *DBVehicleStore) CreateVehicle(v *model.Vehicle) error {
+func (s *DBVehicleStore) CreateVehicle(ctx context.Context, v *model.Vehicle) error {
 	if v.ID == uuid.Nil {
 		v.ID = uuid.New()
 	}
 
-	if v.DriverHelperID != uuid.Nil {
-		var driverHelperExists bool
-		err := s.db.Get(&driverHelperExists, "SELECT EXISTS (SELECT 1 FROM driver_helpers WHERE id = $1)", v.DriverHelperID)
-		if err != nil {
-			return fmt.Errorf("failed to check if driver helper exists: %w", err)
-		}
-		if !driverHelperExists {
-			return fmt.Errorf("driver helper with ID %s does not exist", v.DriverHelperID)
-		}
+	if err := s.checkDriverHelperAssignable(ctx, v.DriverHelperID); err != nil {
+		return err
 	}
 
-	if v.SeatsAvailable < 0 || v.SeatsAvailable > v.TotalStudentsCapacity {
-		return fmt.Errorf("invalid seats_available: %d; must be between 0 and total_students_capacity", v.SeatsAvailable)
+	if err := v.Validate(); err != nil {
+		return err
 	}
 
 	sb := sqlbuilder.NewInsertBuilder()
 	sb.SetFlavor(sqlbuilder.PostgreSQL)
 	sb.InsertInto("vehicles").
-		Cols("id", "vehicle_number", "route_number", "total_students_capacity", "seats_available",
+		Cols("id", "vehicle_number", "vehicle_number_canonical", "route_number", "total_students_capacity", "seats_available",
 			"driver_helper_id", "insurance_number", "insurance_expiry_date", "pollution_certificate_number",
 			"pollution_certificate_expiry_date", "fitness_certificate_number", "fitness_certificate_expiry_date",
 			"vehicle_document_path")
 
-	if v.DriverHelperID != uuid.Nil {
-		sb.Values(v.ID, v.VehicleNumber, v.RouteNumber, v.TotalStudentsCapacity, v.SeatsAvailable,
-			v.DriverHelperID, v.InsuranceNumber, v.InsuranceExpiryDate, v.PollutionCertificateNumber,
-			v.PollutionCertificateExpiryDate, v.FitnessCertificateNumber, v.FitnessCertificateExpiryDate,
-			v.VehicleDocumentPath)
-	} else {
-		sb.Values(v.ID, v.VehicleNumber, v.RouteNumber, v.TotalStudentsCapacity, v.SeatsAvailable,
-			nil, v.InsuranceNumber, v.InsuranceExpiryDate, v.PollutionCertificateNumber,
-			v.PollutionCertificateExpiryDate, v.FitnessCertificateNumber, v.FitnessCertificateExpiryDate,
-			v.VehicleDocumentPath)
-	}
+	sb.Values(v.ID, v.VehicleNumber, v.VehicleNumberCanonical, v.RouteNumber, v.TotalStudentsCapacity, v.SeatsAvailable,
+		nullableID(v.DriverHelperID), v.InsuranceNumber, v.InsuranceExpiryDate, v.PollutionCertificateNumber,
+		v.PollutionCertificateExpiryDate, v.FitnessCertificateNumber, v.FitnessCertificateExpiryDate,
+		v.VehicleDocumentPath).
+		SQL("RETURNING *")
 
 	query, args := sb.Build()
-	_, err := s.db.Exec(query, args...)
+	err := s.db.GetContext(ctx, v, query, args...)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			switch pqErr.Code.Name() {
-			case "unique_violation":
-				return fmt.Errorf("vehicle with number %s already exists", v.VehicleNumber)
-			default:
-				return fmt.Errorf("failed to insert vehicle: %w", err)
-			}
+		err = translateError(err)
+		if errors.Is(err, model.ErrConflict) {
+			return fmt.Errorf("vehicle with number %s already exists: %w", v.VehicleNumber, model.ErrConflict)
 		}
 		return fmt.Errorf("failed to insert vehicle: %w", err)
 	}
@@ -78,24 +67,34 @@ func (s *DBVehicleStore) CreateVehicle(v *model.Vehicle) error {
 	return nil
 }
 
-func (s *DBVehicleStore) UpdateVehicle(v *model.Vehicle) error {
-	var driverHelperExists bool
-	err := s.db.Get(&driverHelperExists, "SELECT EXISTS (SELECT 1 FROM driver_helpers WHERE id = $1)", v.DriverHelperID)
-	if err != nil {
-		return fmt.Errorf("failed to check if driver helper exists: %w", err)
+func (s *DBVehicleStore) UpdateVehicle(ctx context.Context, v *model.Vehicle) error {
+	if err := v.Validate(); err != nil {
+		return err
 	}
-	if !driverHelperExists {
-		return fmt.Errorf("driver helper with ID %s does not exist", v.DriverHelperID)
+
+	// Only a newly assigned driver/helper must be assignable; keeping the current
+	// one must not block edits to the rest of the vehicle, e.g. once their license lapses.
+	var current uuid.UUID
+	err := s.db.QueryRowxContext(ctx, "SELECT driver_helper_id FROM vehicles WHERE id = $1 AND deleted_at IS NULL", v.ID).
+		Scan(&current)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get vehicle: %w", translateError(err))
+	}
+	if err == nil && v.DriverHelperID != current {
+		if err := s.checkDriverHelperAssignable(ctx, v.DriverHelperID); err != nil {
+			return err
+		}
 	}
 
 	sb := sqlbuilder.NewUpdateBuilder()
 	sb.SetFlavor(sqlbuilder.PostgreSQL)
 	sb.Update("vehicles").Set(
 		sb.Assign("vehicle_number", v.VehicleNumber),
+		sb.Assign("vehicle_number_canonical", v.VehicleNumberCanonical),
 		sb.Assign("route_number", v.RouteNumber),
 		sb.Assign("total_students_capacity", v.TotalStudentsCapacity),
 		sb.Assign("seats_available", v.SeatsAvailable),
-		sb.Assign("driver_helper_id", v.DriverHelperID),
+		sb.Assign("driver_helper_id", nullableID(v.DriverHelperID)),
 		sb.Assign("insurance_number", v.InsuranceNumber),
 		sb.Assign("insurance_expiry_date", v.InsuranceExpiryDate),
 		sb.Assign("pollution_certificate_number", v.PollutionCertificateNumber),
@@ -104,91 +103,308 @@ func (s *DBVehicleStore) UpdateVehicle(v *model.Vehicle) error {
 		sb.Assign("fitness_certificate_expiry_date", v.FitnessCertificateExpiryDate),
 		sb.Assign("vehicle_document_path", v.VehicleDocumentPath),
 		sb.Assign("updated_at", time.Now()),
-	).Where(sb.Equal("id", v.ID))
+		sb.Incr("version"),
+	).Where(sb.Equal("id", v.ID), sb.IsNull("deleted_at"))
+	if v.Version != 0 {
+		sb.Where(sb.Equal("version", v.Version))
+	}
+	sb.SQL("RETURNING *")
 
 	query, args := sb.Build()
-	_, err = s.db.Exec(query, args...)
-	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23514" {
-			return fmt.Errorf("failed to update vehicle: constraint violation. Please check the input values (e.g., seats_available > total_students_capacity)")
+	version := v.Version
+	if err := s.db.GetContext(ctx, v, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return missingRowError(ctx, s.db, "vehicles", "vehicle", v.ID, version)
+		}
+		err = translateError(err)
+		if errors.Is(err, model.ErrConflict) {
+			return fmt.Errorf("vehicle with number %s already exists: %w", v.VehicleNumber, model.ErrConflict)
 		}
 		return fmt.Errorf("failed to update vehicle: %w", err)
 	}
+	return nil
+}
+
+func (s *DBVehicleStore) PatchVehicle(ctx context.Context, v *model.Vehicle, columns []string) error {
+	if err := model.ValidatePatchColumns(v, columns); err != nil {
+		return err
+	}
+	if err := v.ValidateColumns(columns); err != nil {
+		return err
+	}
+	if slices.Contains(columns, "vehicle_number") {
+		columns = append(slices.Clone(columns), "vehicle_number_canonical")
+	}
+	if slices.Contains(columns, "driver_helper_id") {
+		if err := s.checkDriverHelperAssignable(ctx, v.DriverHelperID); err != nil {
+			return err
+		}
+	}
 
+	sb := sqlbuilder.NewUpdateBuilder()
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	sb.Update("vehicles")
+	for _, column := range columns {
+		value := model.ColumnValue(v, column)
+		if column == "driver_helper_id" {
+			value = nullableID(v.DriverHelperID)
+		}
+		sb.SetMore(sb.Assign(column, value))
+	}
+	sb.SetMore(sb.Assign("updated_at", time.Now()), sb.Incr("version")).
+		Where(sb.Equal("id", v.ID), sb.IsNull("deleted_at"))
+	if v.Version != 0 {
+		sb.Where(sb.Equal("version", v.Version))
+	}
+	sb.SQL("RETURNING *")
+
+	query, args := sb.Build()
+	version := v.Version
+	if err := s.db.GetContext(ctx, v, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return missingRowError(ctx, s.db, "vehicles", "vehicle", v.ID, version)
+		}
+		err = translateError(err)
+		if errors.Is(err, model.ErrConflict) {
+			return fmt.Errorf("vehicle with number %s already exists: %w", v.VehicleNumber, model.ErrConflict)
+		}
+		return fmt.Errorf("failed to update vehicle: %w", err)
+	}
 	return nil
 }
 
-func (s *DBVehicleStore) DeleteVehicle(id uuid.UUID) error {
-	sb := sqlbuilder.NewDeleteBuilder()
+// checkDriverHelperAssignable reports a foreign key error for an unknown or deleted driver/helper,
+// and a validation error for a driver whose license has expired; uuid.Nil means the vehicle
+// has no driver/helper assigned.
+func (s *DBVehicleStore) checkDriverHelperAssignable(ctx context.Context, id uuid.UUID) error {
+	if id == uuid.Nil {
+		return nil
+	}
+
+	dh := model.DriverHelper{ID: id}
+	err := s.db.QueryRowxContext(ctx, "SELECT user_type, license_expiry_date FROM driver_helpers WHERE id = $1 AND deleted_at IS NULL", id).
+		Scan(&dh.UserType, &dh.LicenseExpiryDate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("driver helper with ID %s does not exist: %w", id, model.ErrForeignKey)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check if driver helper exists: %w", translateError(err))
+	}
+	return dh.CheckAssignable(time.Now())
+}
+
+// nullableID stores uuid.Nil as NULL.
+func nullableID(id uuid.UUID) any {
+	if id == uuid.Nil {
+		return nil
+	}
+	return id
+}
+
+func (s *DBVehicleStore) DeleteVehicle(ctx context.Context, id uuid.UUID, expectedVersion int, reason string) error {
+	sb := sqlbuilder.NewUpdateBuilder()
 	sb.SetFlavor(sqlbuilder.PostgreSQL)
-	sb.DeleteFrom("vehicles").Where(sb.Equal("id", id))
+	sb.Update("vehicles").Set(
+		sb.Assign("deleted_at", time.Now()),
+		sb.Assign("deleted_by", model.Actor(ctx)),
+		sb.Assign("delete_reason", reason),
+		sb.Assign("updated_at", time.Now()),
+		sb.Incr("version"),
+	).Where(sb.Equal("id", id), sb.IsNull("deleted_at"))
+	if expectedVersion != 0 {
+		sb.Where(sb.Equal("version", expectedVersion))
+	}
 
 	query, args := sb.Build()
-	_, err := s.db.Exec(query, args...)
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete vehicle: %w", translateError(err))
+	}
+	err = requireRowAffected(res, model.ErrNotFound)
+	if errors.Is(err, model.ErrNotFound) {
+		return missingRowError(ctx, s.db, "vehicles", "vehicle", id, expectedVersion)
+	}
 	return err
 }
 
-func (s *DBVehicleStore) Vehicles() ([]model.Vehicle, error) {
-	var vehicles []model.Vehicle
+func (s *DBVehicleStore) RestoreVehicle(ctx context.Context, id uuid.UUID) (model.Vehicle, error) {
+	var v model.Vehicle
+	sb := sqlbuilder.NewUpdateBuilder()
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	sb.Update("vehicles").Set(
+		sb.Assign("deleted_at", nil),
+		sb.Assign("deleted_by", ""),
+		sb.Assign("delete_reason", ""),
+		sb.Assign("updated_at", time.Now()),
+		sb.Incr("version"),
+	).Where(sb.Equal("id", id), sb.IsNotNull("deleted_at")).SQL("RETURNING *")
+
+	query, args := sb.Build()
+	if err := s.db.GetContext(ctx, &v, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return v, restoreMissError(ctx, s.db, "vehicles", "vehicle", id)
+		}
+		err = translateError(err)
+		if errors.Is(err, model.ErrConflict) {
+			return v, fmt.Errorf("vehicle number of vehicle %s has been reused by another vehicle: %w", id, model.ErrConflict)
+		}
+		return v, fmt.Errorf("failed to restore vehicle: %w", err)
+	}
+	return v, nil
+}
+
+func (s *DBVehicleStore) PurgeDeletedVehicles(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error) {
+	sb := sqlbuilder.NewDeleteBuilder()
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	sb.DeleteFrom("vehicles").Where(sb.LessThan("deleted_at", deletedBefore)).SQL("RETURNING id")
+
+	query, args := sb.Build()
+	var ids []uuid.UUID
+	if err := s.db.SelectContext(ctx, &ids, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to purge deleted vehicles: %w", translateError(err))
+	}
+	return ids, nil
+}
+
+func (s *DBVehicleStore) Vehicles(ctx context.Context, filter model.VehicleFilter) (model.Page[model.Vehicle], error) {
+	var page model.Page[model.Vehicle]
+	if err := filter.Normalize(); err != nil {
+		return page, err
+	}
+
+	cb := sqlbuilder.NewSelectBuilder()
+	cb.SetFlavor(sqlbuilder.PostgreSQL)
+	cb.Select("COUNT(*)").From("vehicles")
+	applyVehicleFilter(cb, filter)
+	excludeDeleted(ctx, cb)
+
+	query, args := cb.Build()
+	if err := s.db.GetContext(ctx, &page.Total, query, args...); err != nil {
+		return page, fmt.Errorf("failed to count vehicles: %w", translateError(err))
+	}
+
 	sb := sqlbuilder.NewSelectBuilder()
 	sb.SetFlavor(sqlbuilder.PostgreSQL)
 	sb.Select("*").From("vehicles")
+	applyVehicleFilter(sb, filter)
+	excludeDeleted(ctx, sb)
+	if err := applyKeyset(sb, filter.ListOptions, model.Vehicle{}.SortKey(filter.Sort)); err != nil {
+		return page, err
+	}
 
-	query, args := sb.Build()
-	err := s.db.Select(&vehicles, query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch vehicles: %w", err)
+	var vehicles []model.Vehicle
+	query, args = sb.Build()
+	if err := s.db.SelectContext(ctx, &vehicles, query, args...); err != nil {
+		return page, fmt.Errorf("failed to fetch vehicles: %w", translateError(err))
 	}
 
-	return vehicles, nil
+	if len(vehicles) > filter.Limit {
+		vehicles = vehicles[:filter.Limit]
+		last := vehicles[len(vehicles)-1]
+		page.NextCursor = model.EncodeCursor(last.SortKey(filter.Sort), last.ID)
+	}
+	page.Items = vehicles
+	return page, nil
 }
 
-func (s *DBVehicleStore) VehicleByID(id uuid.UUID) (model.Vehicle, error) {
+func applyVehicleFilter(sb *sqlbuilder.SelectBuilder, filter model.VehicleFilter) {
+	if filter.RouteNumber != "" {
+		sb.Where(sb.Equal("route_number", filter.RouteNumber))
+	}
+	if filter.MinSeatsAvailable != nil {
+		sb.Where(sb.GreaterEqualThan("seats_available", *filter.MinSeatsAvailable))
+	}
+	if filter.MaxSeatsAvailable != nil {
+		sb.Where(sb.LessEqualThan("seats_available", *filter.MaxSeatsAvailable))
+	}
+}
+
+func (s *DBVehicleStore) VehicleByID(ctx context.Context, id uuid.UUID) (model.Vehicle, error) {
 	var v model.Vehicle
 	sb := sqlbuilder.NewSelectBuilder()
 	sb.SetFlavor(sqlbuilder.PostgreSQL)
 	sb.Select("*").From("vehicles").Where(sb.Equal("id", id))
+	excludeDeleted(ctx, sb)
 
 	query, args := sb.Build()
-	err := s.db.Get(&v, query, args...)
-	return v, err
+	if err := s.db.GetContext(ctx, &v, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return v, model.NotFoundError("vehicle", id)
+		}
+		return v, fmt.Errorf("failed to fetch vehicle: %w", translateError(err))
+	}
+	return v, nil
 }
 
-func (s *DBVehicleStore) VehiclesByDriverHelperID(driverHelperID uuid.UUID) ([]model.Vehicle, error) {
+func (s *DBVehicleStore) VehiclesByDriverHelperID(ctx context.Context, driverHelperID uuid.UUID) ([]model.Vehicle, error) {
 	var vehicles []model.Vehicle
 	sb := sqlbuilder.NewSelectBuilder()
 	sb.SetFlavor(sqlbuilder.PostgreSQL)
 	sb.Select("*").From("vehicles").Where(sb.Equal("driver_helper_id", driverHelperID))
+	excludeDeleted(ctx, sb)
 
 	query, args := sb.Build()
-	err := s.db.Select(&vehicles, query, args...)
-	return vehicles, err
+	if err := s.db.SelectContext(ctx, &vehicles, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch vehicles: %w", translateError(err))
+	}
+	return vehicles, nil
 }
 
-func (s *DBVehicleStore) VehiclesByRouteNumber(routeNumber string) ([]model.Vehicle, error) {
+func (s *DBVehicleStore) VehiclesByRouteNumber(ctx context.Context, routeNumber string) ([]model.Vehicle, error) {
 	var vehicles []model.Vehicle
 	sb := sqlbuilder.NewSelectBuilder()
 	sb.SetFlavor(sqlbuilder.PostgreSQL)
 	sb.Select("*").From("vehicles").Where(sb.Equal("route_number", routeNumber))
+	excludeDeleted(ctx, sb)
+
+	query, args := sb.Build()
+	if err := s.db.SelectContext(ctx, &vehicles, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch vehicles: %w", translateError(err))
+	}
+	return vehicles, nil
+}
+
+func (s *DBVehicleStore) VehicleByNumber(ctx context.Context, number string) (model.Vehicle, error) {
+	var v model.Vehicle
+	canonical, err := model.CanonicalVehicleNumber(number)
+	if err != nil {
+		return v, err
+	}
+
+	sb := sqlbuilder.NewSelectBuilder()
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	sb.Select("*").From("vehicles").Where(sb.Equal("vehicle_number_canonical", canonical))
+	excludeDeleted(ctx, sb)
+	// Deleted vehicles may share the number; prefer the current one, then the most recently deleted.
+	sb.OrderBy("deleted_at IS NOT NULL", "deleted_at DESC").Limit(1)
 
 	query, args := sb.Build()
-	err := s.db.Select(&vehicles, query, args...)
-	return vehicles, err
+	if err := s.db.GetContext(ctx, &v, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return v, fmt.Errorf("vehicle with number %s %w", number, model.ErrNotFound)
+		}
+		return v, fmt.Errorf("failed to fetch vehicle: %w", translateError(err))
+	}
+	return v, nil
 }
 
-func (s *DBVehicleStore) ExpiredCertificatesVehicles() ([]model.Vehicle, error) {
+func (s *DBVehicleStore) ExpiredCertificatesVehicles(ctx context.Context) ([]model.Vehicle, error) {
 	var vehicles []model.Vehicle
+	today := validation.StartOfDay(time.Now())
 	sb := sqlbuilder.NewSelectBuilder()
 	sb.SetFlavor(sqlbuilder.PostgreSQL)
 	sb.Select("*").From("vehicles").Where(
-		sb.LessThan("insurance_expiry_date", time.Now()),
 		sb.Or(
-			sb.LessThan("pollution_certificate_expiry_date", time.Now()),
-			sb.LessThan("fitness_certificate_expiry_date", time.Now()),
+			sb.LessThan("insurance_expiry_date", today),
+			sb.LessThan("pollution_certificate_expiry_date", today),
+			sb.LessThan("fitness_certificate_expiry_date", today),
 		),
 	)
+	excludeDeleted(ctx, sb)
 
 	query, args := sb.Build()
-	err := s.db.Select(&vehicles, query, args...)
-	return vehicles, err
+	if err := s.db.SelectContext(ctx, &vehicles, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch vehicles: %w", translateError(err))
+	}
+	return vehicles, nil
 }
@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/fieldcrypt"
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+// DBWebhookStore encrypts subscription secrets, which must be read back to sign payloads.
+type DBWebhookStore struct {
+	db      dbtx
+	keyring *fieldcrypt.Keyring
+}
+
+var _ model.WebhookStore = (*DBWebhookStore)(nil)
+
+func NewDBWebhookStore(db *sqlx.DB, keyring *fieldcrypt.Keyring) *DBWebhookStore {
+	return &DBWebhookStore{db: db, keyring: keyring}
+}
+
+func (s *DBWebhookStore) CreateWebhook(ctx context.Context, sub *model.WebhookSubscription) error {
+	secret, err := s.keyring.Seal(sub.Secret, sealContext("webhook_subscriptions", "secret", sub.ID))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt webhook secret: %w", err)
+	}
+
+	sb := sqlbuilder.NewInsertBuilder()
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	sb.InsertInto("webhook_subscriptions").
+		Cols("id", "url", "secret", "events", "created_by", "created_at", "updated_at").
+		Values(sub.ID, sub.URL, secret, sub.Events, sub.CreatedBy, sub.CreatedAt, sub.UpdatedAt)
+
+	query, args := sb.Build()
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to insert webhook: %w", translateError(err))
+	}
+	return nil
+}
+
+func (s *DBWebhookStore) Webhooks(ctx context.Context) ([]model.WebhookSubscription, error) {
+	sb := sqlbuilder.NewSelectBuilder()
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	sb.Select("*").From("webhook_subscriptions").OrderBy("created_at", "id")
+
+	var subs []model.WebhookSubscription
+	query, args := sb.Build()
+	if err := s.db.SelectContext(ctx, &subs, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch webhooks: %w", translateError(err))
+	}
+	for i := range subs {
+		if err := s.openSecret(&subs[i]); err != nil {
+			return nil, err
+		}
+	}
+	return subs, nil
+}
+
+func (s *DBWebhookStore) WebhookByID(ctx context.Context, id uuid.UUID) (model.WebhookSubscription, error) {
+	sb := sqlbuilder.NewSelectBuilder()
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	sb.Select("*").From("webhook_subscriptions").Where(sb.Equal("id", id))
+
+	var sub model.WebhookSubscription
+	query, args := sb.Build()
+	if err := s.db.GetContext(ctx, &sub, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sub, model.NotFoundError("webhook", id)
+		}
+		return sub, fmt.Errorf("failed to fetch webhook: %w", translateError(err))
+	}
+	return sub, s.openSecret(&sub)
+}
+
+func (s *DBWebhookStore) openSecret(sub *model.WebhookSubscription) error {
+	secret, err := s.keyring.Open(sub.Secret, sealContext("webhook_subscriptions", "secret", sub.ID))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt secret of webhook %s: %w", sub.ID, err)
+	}
+	sub.Secret = secret
+	return nil
+}
+
+func (s *DBWebhookStore) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM webhook_subscriptions WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", translateError(err))
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return translateError(err)
+	} else if n == 0 {
+		return model.NotFoundError("webhook", id)
+	}
+	return nil
+}
+
+func (s *DBWebhookStore) EnqueueDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	sb := sqlbuilder.NewInsertBuilder()
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	sb.InsertInto("webhook_deliveries").
+		Cols("id", "subscription_id", "event_id", "event_type", "payload", "status", "attempts", "next_attempt_at", "created_at", "updated_at")
+	for _, d := range deliveries {
+		sb.Values(d.ID, d.SubscriptionID, d.EventID, d.EventType, d.Payload, d.Status, d.Attempts, d.NextAttemptAt, d.CreatedAt, d.UpdatedAt)
+	}
+
+	query, args := sb.Build()
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to queue webhook deliveries: %w", translateError(err))
+	}
+	return nil
+}
+
+func (s *DBWebhookStore) ClaimDueDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	err := s.db.SelectContext(ctx, &deliveries, `
+		UPDATE webhook_deliveries SET next_attempt_at = $1, updated_at = $2
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $2
+			ORDER BY next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, leaseUntil, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", translateError(err))
+	}
+	return deliveries, nil
+}
+
+func (s *DBWebhookStore) RecordAttempt(ctx context.Context, d model.WebhookDelivery, attempt model.WebhookAttempt) error {
+	// One statement, so the attempt and the delivery state it led to are written together.
+	_, err := s.db.ExecContext(ctx, `
+		WITH attempt AS (
+			INSERT INTO webhook_attempts (id, delivery_id, attempt, status_code, error, duration_ms, attempted_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		)
+		UPDATE webhook_deliveries
+		SET status = $8, attempts = $9, next_attempt_at = $10, last_error = $11, delivered_at = $12, updated_at = $13
+		WHERE id = $2`,
+		attempt.ID, d.ID, attempt.Attempt, attempt.StatusCode, attempt.Error, attempt.DurationMS, attempt.AttemptedAt,
+		d.Status, d.Attempts, d.NextAttemptAt, d.LastError, d.DeliveredAt, d.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", translateError(err))
+	}
+	return nil
+}
+
+func (s *DBWebhookStore) WebhookDeliveries(ctx context.Context, filter model.WebhookDeliveryFilter) ([]model.WebhookDelivery, error) {
+	sb := sqlbuilder.NewSelectBuilder()
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	sb.Select("*").From("webhook_deliveries")
+	if filter.SubscriptionID != uuid.Nil {
+		sb.Where(sb.Equal("subscription_id", filter.SubscriptionID))
+	}
+	if filter.Status != "" {
+		sb.Where(sb.Equal("status", filter.Status))
+	}
+	sb.OrderBy("created_at DESC", "id DESC")
+	if filter.Limit > 0 {
+		sb.Limit(filter.Limit)
+	}
+
+	var deliveries []model.WebhookDelivery
+	query, args := sb.Build()
+	if err := s.db.SelectContext(ctx, &deliveries, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch webhook deliveries: %w", translateError(err))
+	}
+	return deliveries, nil
+}
+
+func (s *DBWebhookStore) WebhookDeliveryByID(ctx context.Context, id uuid.UUID) (model.WebhookDelivery, error) {
+	var d model.WebhookDelivery
+	if err := s.db.GetContext(ctx, &d, "SELECT * FROM webhook_deliveries WHERE id = $1", id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return d, model.NotFoundError("webhook delivery", id)
+		}
+		return d, fmt.Errorf("failed to fetch webhook delivery: %w", translateError(err))
+	}
+	return d, nil
+}
+
+func (s *DBWebhookStore) WebhookAttempts(ctx context.Context, deliveryID uuid.UUID) ([]model.WebhookAttempt, error) {
+	var attempts []model.WebhookAttempt
+	err := s.db.SelectContext(ctx, &attempts,
+		"SELECT * FROM webhook_attempts WHERE delivery_id = $1 ORDER BY attempted_at, id", deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch webhook attempts: %w", translateError(err))
+	}
+	return attempts, nil
+}
+
+func (s *DBWebhookStore) RequeueDelivery(ctx context.Context, id uuid.UUID, now time.Time) (model.WebhookDelivery, error) {
+	var d model.WebhookDelivery
+	err := s.db.GetContext(ctx, &d, `
+		UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = $2, updated_at = $2
+		WHERE id = $1 AND status = 'dead'
+		RETURNING *`, id, now)
+	if err == nil {
+		return d, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return d, fmt.Errorf("failed to requeue webhook delivery: %w", translateError(err))
+	}
+
+	if d, err = s.WebhookDeliveryByID(ctx, id); err != nil {
+		return d, err
+	}
+	return d, fmt.Errorf("webhook delivery with ID %s is %s, not dead: %w", id, d.Status, model.ErrConflict)
+}
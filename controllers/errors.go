@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+// translateError maps database/sql and lib/pq failures onto the model error
+// taxonomy so callers never have to inspect driver-specific error values.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return translatePQError(pqErr)
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("%w: %w", model.ErrNotFound, err)
+	case errors.Is(err, driver.ErrBadConn),
+		errors.Is(err, sql.ErrConnDone),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, context.Canceled),
+		errors.As(err, &netErr):
+		return fmt.Errorf("%w: %w", model.ErrUnavailable, err)
+	}
+	return err
+}
+
+func translatePQError(pqErr *pq.Error) error {
+	switch pqErr.Code.Class() {
+	case "08", "53", "57":
+		return fmt.Errorf("%w: %w", model.ErrUnavailable, pqErr)
+	case "22":
+		return model.NewFieldError(pqErrorField(pqErr), "%s", pqErr.Message)
+	}
+
+	switch pqErr.Code.Name() {
+	case "unique_violation":
+		return fmt.Errorf("%w: %w", model.ErrConflict, pqErr)
+	case "foreign_key_violation":
+		return fmt.Errorf("%w: %w", model.ErrForeignKey, pqErr)
+	case "check_violation", "not_null_violation":
+		return model.NewFieldError(pqErrorField(pqErr), "%s", pqErr.Message)
+	}
+	return pqErr
+}
+
+// pqErrorField names the offending column, falling back to the constraint
+// name with its table prefix and _check suffix removed (vehicles_seats_available_check -> seats_available).
+func pqErrorField(pqErr *pq.Error) string {
+	if pqErr.Column != "" {
+		return pqErr.Column
+	}
+	field := strings.TrimPrefix(pqErr.Constraint, pqErr.Table+"_")
+	return strings.TrimSuffix(field, "_check")
+}
+
+// requireRowAffected returns notFound when an UPDATE or DELETE matched no row.
+func requireRowAffected(res sql.Result, notFound error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return translateError(err)
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+// sortExpressions stand in for sort columns that may be NULL, using the zero
+// value the models' SortKey reports for NULL, so keyset comparisons never meet one.
+var sortExpressions = map[string]string{
+	"license_expiry_date": "COALESCE(license_expiry_date, '0001-01-01')",
+}
+
+// applyKeyset orders sb by the requested sort column (id breaks ties), resumes
+// after the cursor row and fetches one extra row to detect a following page.
+// opts must already be normalized, which restricts opts.Sort to known columns.
+func applyKeyset(sb *sqlbuilder.SelectBuilder, opts model.ListOptions, zeroKey any) error {
+	op, dir := ">", "ASC"
+	if opts.Descending() {
+		op, dir = "<", "DESC"
+	}
+
+	column := opts.Sort
+	if expr, ok := sortExpressions[column]; ok {
+		column = expr
+	}
+
+	if opts.Cursor != "" {
+		value, id, err := model.DecodeCursor(opts.Cursor, zeroKey)
+		if err != nil {
+			return model.NewFieldError("cursor", "invalid cursor for sort %s", opts.Sort)
+		}
+		sb.Where(fmt.Sprintf("(%s, id) %s (%s, %s)", column, op, sb.Var(value), sb.Var(id)))
+	}
+
+	sb.OrderBy(column+" "+dir, "id "+dir).Limit(opts.Limit + 1)
+	return nil
+}
+
+// missingRowError explains why a conditional write on table matched no row:
+// the row is gone (or soft-deleted), or it exists at a newer version than expected.
+func missingRowError(ctx context.Context, db sqlx.QueryerContext, table, entity string, id uuid.UUID, version int) error {
+	var exists bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE id = $1 AND deleted_at IS NULL)", table)
+	if err := sqlx.GetContext(ctx, db, &exists, query, id); err != nil {
+		return fmt.Errorf("failed to check if %s exists: %w", entity, translateError(err))
+	}
+	if exists && version != 0 {
+		return model.PreconditionFailedError(entity, id, version)
+	}
+	return model.NotFoundError(entity, id)
+}
+
+// excludeDeleted hides soft-deleted rows unless ctx asks for them with model.WithDeleted.
+func excludeDeleted(ctx context.Context, sb *sqlbuilder.SelectBuilder) {
+	if !model.IncludesDeleted(ctx) {
+		sb.Where(sb.IsNull("deleted_at"))
+	}
+}
+
+// restoreMissError explains why a restore on table matched no deleted row.
+func restoreMissError(ctx context.Context, db sqlx.QueryerContext, table, entity string, id uuid.UUID) error {
+	var exists bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE id = $1)", table)
+	if err := sqlx.GetContext(ctx, db, &exists, query, id); err != nil {
+		return fmt.Errorf("failed to check if %s exists: %w", entity, translateError(err))
+	}
+	if exists {
+		return model.NotDeletedError(entity, id)
+	}
+	return model.NotFoundError(entity, id)
+}
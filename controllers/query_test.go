@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/huandu/go-sqlbuilder"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+func TestApplyKeyset(t *testing.T) {
+	id := uuid.New()
+	expiry := time.Date(2027, 3, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		opts     model.ListOptions
+		zeroKey  any
+		wantSQL  string
+		wantArgs []any
+	}{
+		{name: "first page", opts: model.ListOptions{Limit: 10, Sort: "first_name", Order: "asc"}, zeroKey: "",
+			wantSQL: "SELECT id FROM driver_helpers ORDER BY first_name ASC, id ASC LIMIT 11"},
+		{name: "descending", opts: model.ListOptions{Limit: 10, Sort: "created_at", Order: "desc"}, zeroKey: time.Time{},
+			wantSQL: "SELECT id FROM driver_helpers ORDER BY created_at DESC, id DESC LIMIT 11"},
+		{name: "after the cursor", opts: model.ListOptions{Cursor: model.EncodeCursor("Ravi", id), Limit: 2, Sort: "first_name", Order: "asc"},
+			zeroKey:  "",
+			wantSQL:  "SELECT id FROM driver_helpers WHERE (first_name, id) > ($1, $2) ORDER BY first_name ASC, id ASC LIMIT 3",
+			wantArgs: []any{"Ravi", id}},
+		{name: "before the cursor when descending", opts: model.ListOptions{Cursor: model.EncodeCursor(40, id), Limit: 5, Sort: "seats_available", Order: "desc"},
+			zeroKey:  0,
+			wantSQL:  "SELECT id FROM driver_helpers WHERE (seats_available, id) < ($1, $2) ORDER BY seats_available DESC, id DESC LIMIT 6",
+			wantArgs: []any{40, id}},
+		{name: "nullable column", opts: model.ListOptions{Cursor: model.EncodeCursor(expiry, id), Limit: 1, Sort: "license_expiry_date", Order: "asc"},
+			zeroKey: time.Time{},
+			wantSQL: "SELECT id FROM driver_helpers WHERE (COALESCE(license_expiry_date, '0001-01-01'), id) > ($1, $2) " +
+				"ORDER BY COALESCE(license_expiry_date, '0001-01-01') ASC, id ASC LIMIT 2",
+			wantArgs: []any{expiry, id}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sb := sqlbuilder.Select("id").From("driver_helpers")
+			sb.SetFlavor(sqlbuilder.PostgreSQL)
+			if err := applyKeyset(sb, tt.opts, tt.zeroKey); err != nil {
+				t.Fatalf("applyKeyset: %v", err)
+			}
+			query, args := sb.Build()
+			if query != tt.wantSQL {
+				t.Errorf("query = %q, want %q", query, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestApplyKeysetRejectsCursorForAnotherSort(t *testing.T) {
+	sb := sqlbuilder.Select("id").From("vehicles")
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	opts := model.ListOptions{Cursor: model.EncodeCursor("DL1PC0001", uuid.New()), Limit: 10, Sort: "seats_available", Order: "asc"}
+	if err := applyKeyset(sb, opts, 0); !errors.Is(err, model.ErrValidation) {
+		t.Errorf("applyKeyset = %v, want %v", err, model.ErrValidation)
+	}
+}
@@ -0,0 +1,271 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/fieldcrypt"
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+// ReencryptStats counts the rows Reencrypt rewrote or had to leave alone.
+type ReencryptStats struct {
+	DriverHelpers        int `json:"driver_helpers"`
+	AuditEntries         int `json:"audit_entries"`
+	WebhookSubscriptions int `json:"webhook_subscriptions"`
+	// Skipped rows changed while they were being rewritten; the change itself
+	// was written under the primary key, so they need no second pass.
+	Skipped int `json:"skipped"`
+	// Conflicts are rows whose blind index clashes with another row's, i.e.
+	// duplicate Aadhaar or license numbers written before they were indexed.
+	Conflicts int `json:"conflicts"`
+}
+
+type sealedRow struct {
+	ID                     uuid.UUID `db:"id"`
+	Version                int       `db:"version"`
+	AadharNumber           string    `db:"aadhar_number"`
+	LicenseNumber          string    `db:"license_number"`
+	EmergencyContactNumber string    `db:"emergency_contact_number"`
+	AadharNumberBidx       []byte    `db:"aadhar_number_bidx"`
+	LicenseNumberBidx      []byte    `db:"license_number_bidx"`
+}
+
+// Reencrypt brings every stored driver/helper, including soft-deleted ones,
+// their audit history and the webhook subscription secrets in line with
+// keyring: values in plaintext or under a retired key are sealed under the
+// primary key, and blind indexes are rebuilt. Rows are rewritten batchSize at a time, so it can run while the
+// service is serving requests. Without a keyring it only rebuilds the indexes.
+func Reencrypt(ctx context.Context, db *sqlx.DB, keyring *fieldcrypt.Keyring, batchSize int) (ReencryptStats, error) {
+	var stats ReencryptStats
+	if err := reencryptDriverHelpers(ctx, db, keyring, batchSize, &stats); err != nil {
+		return stats, err
+	}
+	if err := reencryptAuditLog(ctx, db, keyring, batchSize, &stats); err != nil {
+		return stats, err
+	}
+	if err := reencryptWebhookSubscriptions(ctx, db, keyring, batchSize, &stats); err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
+
+func reencryptDriverHelpers(ctx context.Context, db *sqlx.DB, keyring *fieldcrypt.Keyring, batchSize int, stats *ReencryptStats) error {
+	after := uuid.Nil
+	for {
+		var rows []sealedRow
+		err := db.SelectContext(ctx, &rows, `
+			SELECT id, version, COALESCE(aadhar_number, '') AS aadhar_number,
+				COALESCE(license_number, '') AS license_number,
+				COALESCE(emergency_contact_number, '') AS emergency_contact_number,
+				aadhar_number_bidx, license_number_bidx
+			FROM driver_helpers WHERE id > $1 ORDER BY id LIMIT $2`, after, batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to fetch driver/helpers: %w", translateError(err))
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		after = rows[len(rows)-1].ID
+
+		for _, row := range rows {
+			if err := reencryptDriverHelper(ctx, db, keyring, row, stats); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func reencryptDriverHelper(ctx context.Context, db *sqlx.DB, keyring *fieldcrypt.Keyring, row sealedRow, stats *ReencryptStats) error {
+	columns, values, err := resealDriverHelper(keyring, row)
+	if err != nil || columns == nil {
+		return err
+	}
+	sb := sqlbuilder.NewUpdateBuilder()
+	sb.SetFlavor(sqlbuilder.PostgreSQL)
+	sb.Update("driver_helpers")
+	for i, column := range columns {
+		sb.SetMore(sb.Assign(column, values[i]))
+	}
+	sb.Where(sb.Equal("id", row.ID), sb.Equal("version", row.Version))
+
+	query, args := sb.Build()
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		err = translateError(err)
+		if errors.Is(err, model.ErrConflict) {
+			slog.Warn("driver/helper duplicates another's Aadhaar or license number; left unindexed", "id", row.ID)
+			stats.Conflicts++
+			return nil
+		}
+		return fmt.Errorf("failed to re-encrypt driver/helper %s: %w", row.ID, err)
+	}
+	if err := requireRowAffected(res, model.ErrNotFound); err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			stats.Skipped++
+			return nil
+		}
+		return err
+	}
+	stats.DriverHelpers++
+	return nil
+}
+
+// CheckBlindIndexes fails while any driver/helper has an Aadhaar or license
+// number without a blind index. The unique indexes added by migration 7 cover
+// the blind indexes only, so such rows could be duplicated unnoticed; running
+// Reencrypt indexes them, apart from the Conflicts it reports.
+func CheckBlindIndexes(ctx context.Context, db sqlx.QueryerContext) error {
+	var unindexed int
+	err := sqlx.GetContext(ctx, db, &unindexed, `
+		SELECT COUNT(*) FROM driver_helpers
+		WHERE (COALESCE(aadhar_number, '') <> '' AND aadhar_number_bidx IS NULL)
+			OR (COALESCE(license_number, '') <> '' AND license_number_bidx IS NULL)`)
+	if err != nil {
+		return fmt.Errorf("failed to check blind indexes: %w", translateError(err))
+	}
+	if unindexed > 0 {
+		return fmt.Errorf("%d driver/helpers have an Aadhaar or license number without a blind index, so their uniqueness is not enforced; "+
+			"run `reencrypt` and resolve any conflicts it reports", unindexed)
+	}
+	return nil
+}
+
+// resealDriverHelper returns the sealed columns of row, with their blind
+// indexes, as they should be stored under keyring, or no columns when row
+// is stored that way already.
+func resealDriverHelper(keyring *fieldcrypt.Keyring, row sealedRow) ([]string, []any, error) {
+	dh := model.DriverHelper{
+		ID:                     row.ID,
+		AadharNumber:           row.AadharNumber,
+		LicenseNumber:          row.LicenseNumber,
+		EmergencyContactNumber: row.EmergencyContactNumber,
+	}
+	stale := keyring.NeedsReseal(dh.AadharNumber) || keyring.NeedsReseal(dh.LicenseNumber) ||
+		keyring.NeedsReseal(dh.EmergencyContactNumber)
+	if err := openDriverHelpers(keyring, &dh); err != nil {
+		return nil, nil, err
+	}
+	stale = stale ||
+		!bytes.Equal(row.AadharNumberBidx, keyring.BlindIndex("aadhar_number", dh.AadharNumber)) ||
+		!bytes.Equal(row.LicenseNumberBidx, keyring.BlindIndex("license_number", dh.LicenseNumber))
+	if !stale {
+		return nil, nil, nil
+	}
+	return storedDriverHelperValues(keyring, &dh, sealedColumns)
+}
+
+func reencryptAuditLog(ctx context.Context, db *sqlx.DB, keyring *fieldcrypt.Keyring, batchSize int, stats *ReencryptStats) error {
+	after := uuid.Nil
+	for {
+		var entries []model.AuditEntry
+		err := db.SelectContext(ctx, &entries,
+			"SELECT * FROM audit_log WHERE entity_type = $1 AND id > $2 ORDER BY id LIMIT $3",
+			model.EntityDriverHelper, after, batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to fetch audit entries: %w", translateError(err))
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		after = entries[len(entries)-1].ID
+
+		for _, entry := range entries {
+			if !changesNeedReseal(keyring, entry) {
+				continue
+			}
+			if err := openChanges(keyring, &entry); err != nil {
+				return err
+			}
+			changes, err := sealChanges(keyring, &entry)
+			if err != nil {
+				return err
+			}
+			if _, err := db.ExecContext(ctx, "UPDATE audit_log SET changes = $1 WHERE id = $2", changes, entry.ID); err != nil {
+				return fmt.Errorf("failed to re-encrypt audit entry %s: %w", entry.ID, translateError(err))
+			}
+			stats.AuditEntries++
+		}
+	}
+}
+
+func changesNeedReseal(keyring *fieldcrypt.Keyring, entry model.AuditEntry) bool {
+	for _, change := range entry.Changes {
+		if !sealedChange(entry.EntityType, change) {
+			continue
+		}
+		for _, value := range []any{change.Before, change.After} {
+			if s, ok := value.(string); ok && keyring.NeedsReseal(s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type sealedSecret struct {
+	ID     uuid.UUID `db:"id"`
+	Secret string    `db:"secret"`
+}
+
+func reencryptWebhookSubscriptions(ctx context.Context, db *sqlx.DB, keyring *fieldcrypt.Keyring, batchSize int, stats *ReencryptStats) error {
+	after := uuid.Nil
+	for {
+		var rows []sealedSecret
+		err := db.SelectContext(ctx, &rows,
+			"SELECT id, secret FROM webhook_subscriptions WHERE id > $1 ORDER BY id LIMIT $2", after, batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to fetch webhooks: %w", translateError(err))
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		after = rows[len(rows)-1].ID
+
+		for _, row := range rows {
+			secret, err := resealWebhookSecret(keyring, row)
+			if err != nil {
+				return err
+			}
+			if secret == "" {
+				continue
+			}
+			// Subscriptions have no version; matching the old value leaves a
+			// secret replaced meanwhile alone, as it was sealed under the primary.
+			res, err := db.ExecContext(ctx, "UPDATE webhook_subscriptions SET secret = $1 WHERE id = $2 AND secret = $3",
+				secret, row.ID, row.Secret)
+			if err != nil {
+				return fmt.Errorf("failed to re-encrypt secret of webhook %s: %w", row.ID, translateError(err))
+			}
+			if err := requireRowAffected(res, model.ErrNotFound); err != nil {
+				if errors.Is(err, model.ErrNotFound) {
+					stats.Skipped++
+					continue
+				}
+				return err
+			}
+			stats.WebhookSubscriptions++
+		}
+	}
+}
+
+// resealWebhookSecret returns the secret of row sealed under the primary key
+// of keyring, or "" when it is stored that way already.
+func resealWebhookSecret(keyring *fieldcrypt.Keyring, row sealedSecret) (string, error) {
+	if !keyring.NeedsReseal(row.Secret) {
+		return "", nil
+	}
+	sc := sealContext("webhook_subscriptions", "secret", row.ID)
+	secret, err := keyring.Open(row.Secret, sc)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret of webhook %s: %w", row.ID, err)
+	}
+	return keyring.Seal(secret, sc)
+}
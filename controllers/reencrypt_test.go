@@ -0,0 +1,262 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/fieldcrypt"
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+// newTestKeyring derives each key from its ID, so a key is the same in every keyring that holds it.
+func newTestKeyring(t *testing.T, primary string, ids ...string) *fieldcrypt.Keyring {
+	t.Helper()
+	keys := make([]fieldcrypt.Key, len(ids))
+	for i, id := range ids {
+		keys[i] = fieldcrypt.Key{ID: id, Secret: bytes.Repeat([]byte(id), 32)[:32]}
+	}
+	k, err := fieldcrypt.NewKeyring(keys, primary, bytes.Repeat([]byte{0xff}, 32))
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	return k
+}
+
+// storedRow is dh as storedDriverHelperValues would write it under keyring.
+func storedRow(t *testing.T, keyring *fieldcrypt.Keyring, dh model.DriverHelper) sealedRow {
+	t.Helper()
+	columns, values, err := storedDriverHelperValues(keyring, &dh, sealedColumns)
+	if err != nil {
+		t.Fatalf("storedDriverHelperValues: %v", err)
+	}
+	row := sealedRow{ID: dh.ID, Version: 1}
+	applyStored(t, &row, columns, values)
+	return row
+}
+
+func applyStored(t *testing.T, row *sealedRow, columns []string, values []any) {
+	t.Helper()
+	for i, column := range columns {
+		switch column {
+		case "aadhar_number":
+			row.AadharNumber = values[i].(string)
+		case "license_number":
+			row.LicenseNumber = values[i].(string)
+		case "emergency_contact_number":
+			row.EmergencyContactNumber = values[i].(string)
+		case "aadhar_number_bidx":
+			row.AadharNumberBidx = values[i].([]byte)
+		case "license_number_bidx":
+			row.LicenseNumberBidx = values[i].([]byte)
+		default:
+			t.Fatalf("unexpected column %s", column)
+		}
+	}
+}
+
+func TestResealDriverHelper(t *testing.T) {
+	dh := model.DriverHelper{ID: uuid.New(), AadharNumber: "234567890124", LicenseNumber: "MH1220110012345",
+		EmergencyContactNumber: "9876543210"}
+	old := newTestKeyring(t, "k1", "k1")
+	rotated := newTestKeyring(t, "k2", "k1", "k2")
+
+	tests := []struct {
+		name      string
+		row       sealedRow
+		keyring   *fieldcrypt.Keyring
+		wantStale bool
+	}{
+		{name: "current", row: storedRow(t, old, dh), keyring: old},
+		{name: "retired primary", row: storedRow(t, old, dh), keyring: rotated, wantStale: true},
+		{name: "plaintext", row: storedRow(t, nil, dh), keyring: old, wantStale: true},
+		{name: "unindexed", row: func() sealedRow {
+			row := storedRow(t, old, dh)
+			row.AadharNumberBidx, row.LicenseNumberBidx = nil, nil
+			return row
+		}(), keyring: old, wantStale: true},
+		{name: "plaintext without a keyring", row: storedRow(t, nil, dh), keyring: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, values, err := resealDriverHelper(tt.keyring, tt.row)
+			if err != nil {
+				t.Fatalf("resealDriverHelper: %v", err)
+			}
+			if stale := columns != nil; stale != tt.wantStale {
+				t.Fatalf("resealDriverHelper rewrote %v, want stale = %v", columns, tt.wantStale)
+			}
+			if !tt.wantStale {
+				return
+			}
+
+			row := tt.row
+			applyStored(t, &row, columns, values)
+			for _, value := range []string{row.AadharNumber, row.LicenseNumber, row.EmergencyContactNumber} {
+				if tt.keyring.NeedsReseal(value) {
+					t.Errorf("resealed value %q still needs resealing", value)
+				}
+			}
+			if columns, _, err := resealDriverHelper(tt.keyring, row); err != nil || columns != nil {
+				t.Errorf("second pass rewrote %v, %v; want nothing", columns, err)
+			}
+
+			got := model.DriverHelper{ID: dh.ID, AadharNumber: row.AadharNumber, LicenseNumber: row.LicenseNumber,
+				EmergencyContactNumber: row.EmergencyContactNumber}
+			if err := openDriverHelpers(tt.keyring, &got); err != nil {
+				t.Fatalf("openDriverHelpers: %v", err)
+			}
+			if got.AadharNumber != dh.AadharNumber || got.LicenseNumber != dh.LicenseNumber ||
+				got.EmergencyContactNumber != dh.EmergencyContactNumber {
+				t.Errorf("resealed row opens to %+v, want the original numbers", got)
+			}
+		})
+	}
+}
+
+func TestResealAuditChanges(t *testing.T) {
+	old := newTestKeyring(t, "k1", "k1")
+	rotated := newTestKeyring(t, "k2", "k1", "k2")
+	entry := model.AuditEntry{ID: uuid.New(), EntityType: model.EntityDriverHelper, Changes: model.Changes{
+		{Field: "aadhar_number", Before: "234567890124", After: "345678901238"},
+		{Field: "first_name", Before: "Ravi", After: "Ravindra"},
+	}}
+
+	sealed, err := sealChanges(old, &entry)
+	if err != nil {
+		t.Fatalf("sealChanges: %v", err)
+	}
+	if sealed[1] != entry.Changes[1] {
+		t.Errorf("sealChanges changed an unsealed field: %+v", sealed[1])
+	}
+	stored := entry
+	stored.Changes = sealed
+	if changesNeedReseal(old, stored) {
+		t.Error("changes under the primary need resealing")
+	}
+	if !changesNeedReseal(rotated, stored) {
+		t.Fatal("changes under the retired primary do not need resealing")
+	}
+
+	if err := openChanges(rotated, &stored); err != nil {
+		t.Fatalf("openChanges: %v", err)
+	}
+	resealed, err := sealChanges(rotated, &stored)
+	if err != nil {
+		t.Fatalf("sealChanges: %v", err)
+	}
+	stored.Changes = resealed
+	if changesNeedReseal(rotated, stored) {
+		t.Error("resealed changes still need resealing")
+	}
+	if after := stored.Changes[0].After.(string); !strings.HasPrefix(after, "enc:v1:k2:") {
+		t.Errorf("resealed change = %q, want it under k2", after)
+	}
+	if err := openChanges(rotated, &stored); err != nil {
+		t.Fatalf("openChanges: %v", err)
+	}
+	if stored.Changes[0] != entry.Changes[0] {
+		t.Errorf("resealed change opens to %+v, want %+v", stored.Changes[0], entry.Changes[0])
+	}
+}
+
+func TestResealWebhookSecret(t *testing.T) {
+	id := uuid.New()
+	old := newTestKeyring(t, "k1", "k1")
+	rotated := newTestKeyring(t, "k2", "k1", "k2")
+	sealed := func(keyring *fieldcrypt.Keyring) string {
+		t.Helper()
+		secret, err := keyring.Seal("whsec_0123456789", sealContext("webhook_subscriptions", "secret", id))
+		if err != nil {
+			t.Fatalf("Seal: %v", err)
+		}
+		return secret
+	}
+
+	tests := []struct {
+		name      string
+		secret    string
+		keyring   *fieldcrypt.Keyring
+		wantStale bool
+	}{
+		{name: "current", secret: sealed(old), keyring: old},
+		{name: "retired primary", secret: sealed(old), keyring: rotated, wantStale: true},
+		{name: "plaintext", secret: "whsec_0123456789", keyring: old, wantStale: true},
+		{name: "plaintext without a keyring", secret: "whsec_0123456789", keyring: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret, err := resealWebhookSecret(tt.keyring, sealedSecret{ID: id, Secret: tt.secret})
+			if err != nil {
+				t.Fatalf("resealWebhookSecret: %v", err)
+			}
+			if stale := secret != ""; stale != tt.wantStale {
+				t.Fatalf("resealWebhookSecret = %q, want stale = %v", secret, tt.wantStale)
+			}
+			if !tt.wantStale {
+				return
+			}
+			if tt.keyring.NeedsReseal(secret) {
+				t.Errorf("resealed secret %q still needs resealing", secret)
+			}
+			if again, err := resealWebhookSecret(tt.keyring, sealedSecret{ID: id, Secret: secret}); err != nil || again != "" {
+				t.Errorf("second pass = %q, %v; want nothing", again, err)
+			}
+
+			// The old key can now be dropped from the keyring.
+			current := newTestKeyring(t, "k2", "k2")
+			if tt.keyring == old {
+				current = old
+			}
+			opened, err := current.Open(secret, sealContext("webhook_subscriptions", "secret", id))
+			if err != nil || opened != "whsec_0123456789" {
+				t.Errorf("resealed secret opens to %q, %v; want the original secret", opened, err)
+			}
+			if _, err := current.Open(secret, sealContext("webhook_subscriptions", "secret", uuid.New())); err == nil {
+				t.Error("resealed secret opens for another subscription")
+			}
+		})
+	}
+}
+
+func TestCheckBlindIndexes(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	if _, err := db.Exec("TRUNCATE driver_helpers, vehicles, audit_log, webhook_deliveries CASCADE"); err != nil {
+		t.Fatalf("failed to empty the test database: %v", err)
+	}
+	if err := CheckBlindIndexes(ctx, db); err != nil {
+		t.Fatalf("CheckBlindIndexes on an empty table: %v", err)
+	}
+
+	keyring := newTestKeyring(t, "k1", "k1")
+	store := NewDBDriverHelperStore(db, keyring)
+	expiry := time.Now().AddDate(1, 0, 0)
+	dh := model.DriverHelper{UserType: "Driver", FirstName: "Ravi", BloodGroup: "O+", AadharNumber: "234567890124",
+		MobileNumber: "9876543210", LicenseNumber: "MH1220110012345", LicenseExpiryDate: &expiry, PoliceVerification: "No"}
+	if err := store.CreateDriverHelper(ctx, &dh); err != nil {
+		t.Fatalf("CreateDriverHelper: %v", err)
+	}
+	if err := CheckBlindIndexes(ctx, db); err != nil {
+		t.Fatalf("CheckBlindIndexes with every row indexed: %v", err)
+	}
+
+	// As left by migration 7, or by reencrypt when the row is a duplicate.
+	if _, err := db.Exec("UPDATE driver_helpers SET aadhar_number_bidx = NULL WHERE id = $1", dh.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckBlindIndexes(ctx, db); err == nil {
+		t.Fatal("CheckBlindIndexes passed with an unindexed Aadhaar number")
+	}
+
+	if _, err := Reencrypt(ctx, db, keyring, 10); err != nil {
+		t.Fatalf("Reencrypt: %v", err)
+	}
+	if err := CheckBlindIndexes(ctx, db); err != nil {
+		t.Errorf("CheckBlindIndexes after reencrypt: %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/fieldcrypt"
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+// sealedColumns of driver_helpers are encrypted at rest, here and in the
+// changes recorded for them in audit_log.
+var sealedColumns = []string{"aadhar_number", "license_number", "emergency_contact_number"}
+
+// blindIndexColumns maps the sealed columns that must stay unique to the
+// column holding their blind index.
+var blindIndexColumns = map[string]string{
+	"aadhar_number":  "aadhar_number_bidx",
+	"license_number": "license_number_bidx",
+}
+
+// driverHelperColumns are the driver_helpers columns model.DriverHelper maps;
+// reads name them instead of using * because the blind indexes are write-only.
+var (
+	driverHelperColumns   = modelColumns(model.DriverHelper{})
+	returningDriverHelper = "RETURNING " + strings.Join(driverHelperColumns, ", ")
+)
+
+func modelColumns(entity any) []string {
+	rt := reflect.TypeOf(entity)
+	columns := make([]string, 0, rt.NumField())
+	for i := 0; i < rt.NumField(); i++ {
+		if column := rt.Field(i).Tag.Get("db"); column != "" && column != "-" {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// sealedFields points at the fields of dh stored in sealed columns.
+func sealedFields(dh *model.DriverHelper) map[string]*string {
+	return map[string]*string{
+		"aadhar_number":            &dh.AadharNumber,
+		"license_number":           &dh.LicenseNumber,
+		"emergency_contact_number": &dh.EmergencyContactNumber,
+	}
+}
+
+// sealContext binds a sealed value to the table, column and row it is stored
+// in, so it cannot be copied into another one.
+func sealContext(table, column string, id uuid.UUID) string {
+	return table + "." + column + "/" + id.String()
+}
+
+// storedDriverHelperValues returns the values to write for the given columns
+// of dh: sealed columns are encrypted, and those with a blind index are
+// followed by their index column.
+func storedDriverHelperValues(keyring *fieldcrypt.Keyring, dh *model.DriverHelper, columns []string) ([]string, []any, error) {
+	fields := sealedFields(dh)
+	stored := make([]string, 0, len(columns)+len(blindIndexColumns))
+	values := make([]any, 0, len(columns)+len(blindIndexColumns))
+	for _, column := range columns {
+		field, sealed := fields[column]
+		if !sealed {
+			stored = append(stored, column)
+			values = append(values, model.ColumnValue(dh, column))
+			continue
+		}
+
+		value, err := keyring.Seal(*field, sealContext("driver_helpers", column, dh.ID))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encrypt %s: %w", column, err)
+		}
+		stored = append(stored, column)
+		values = append(values, value)
+		if index, ok := blindIndexColumns[column]; ok {
+			stored = append(stored, index)
+			values = append(values, keyring.BlindIndex(column, *field))
+		}
+	}
+	return stored, values, nil
+}
+
+// openDriverHelpers decrypts the sealed fields of dhs in place.
+func openDriverHelpers(keyring *fieldcrypt.Keyring, dhs ...*model.DriverHelper) error {
+	for _, dh := range dhs {
+		for column, field := range sealedFields(dh) {
+			plaintext, err := keyring.Open(*field, sealContext("driver_helpers", column, dh.ID))
+			if err != nil {
+				return fmt.Errorf("failed to decrypt %s of driver/helper %s: %w", column, dh.ID, err)
+			}
+			*field = plaintext
+		}
+	}
+	return nil
+}
+
+// sealedChange reports whether change records a sealed column of entityType.
+func sealedChange(entityType string, change model.FieldChange) bool {
+	if entityType != model.EntityDriverHelper {
+		return false
+	}
+	for _, column := range sealedColumns {
+		if change.Field == column {
+			return true
+		}
+	}
+	return false
+}
+
+// changeContext binds one side ("before" or "after") of a recorded change to its audit entry.
+func changeContext(entryID uuid.UUID, field, side string) string {
+	return sealContext("audit_log", field, entryID) + "/" + side
+}
+
+// sealChanges returns a copy of entry's changes with the values of sealed columns encrypted.
+func sealChanges(keyring *fieldcrypt.Keyring, entry *model.AuditEntry) (model.Changes, error) {
+	changes := make(model.Changes, len(entry.Changes))
+	for i, change := range entry.Changes {
+		if sealedChange(entry.EntityType, change) {
+			var err error
+			if change.Before, err = sealChangeValue(keyring, change.Before, changeContext(entry.ID, change.Field, "before")); err != nil {
+				return nil, err
+			}
+			if change.After, err = sealChangeValue(keyring, change.After, changeContext(entry.ID, change.Field, "after")); err != nil {
+				return nil, err
+			}
+		}
+		changes[i] = change
+	}
+	return changes, nil
+}
+
+func sealChangeValue(keyring *fieldcrypt.Keyring, value any, context string) (any, error) {
+	s, ok := value.(string)
+	if !ok {
+		return value, nil
+	}
+	sealed, err := keyring.Seal(s, context)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt audit change: %w", err)
+	}
+	return sealed, nil
+}
+
+// openChanges decrypts the sealed values in entry's changes in place.
+func openChanges(keyring *fieldcrypt.Keyring, entry *model.AuditEntry) error {
+	for i, change := range entry.Changes {
+		if !sealedChange(entry.EntityType, change) {
+			continue
+		}
+		var err error
+		if change.Before, err = openChangeValue(keyring, change.Before, changeContext(entry.ID, change.Field, "before")); err != nil {
+			return fmt.Errorf("failed to decrypt audit entry %s: %w", entry.ID, err)
+		}
+		if change.After, err = openChangeValue(keyring, change.After, changeContext(entry.ID, change.Field, "after")); err != nil {
+			return fmt.Errorf("failed to decrypt audit entry %s: %w", entry.ID, err)
+		}
+		entry.Changes[i] = change
+	}
+	return nil
+}
+
+func openChangeValue(keyring *fieldcrypt.Keyring, value any, context string) (any, error) {
+	s, ok := value.(string)
+	if !ok {
+		return value, nil
+	}
+	return keyring.Open(s, context)
+}
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/migrations"
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+	"github.com/arjunsaxaena/driver_vehicle_profile/storetest"
+)
+
+// openTestDB connects to the database named by storetest.DSNEnv, skipping the
+// test when it is unset, and migrates it to the latest embedded version.
+func openTestDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	dsn := os.Getenv(storetest.DSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", storetest.DSNEnv)
+	}
+	db, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to connect to the test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	runner, err := migrations.NewRunner(context.Background(), db.DB, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer runner.Close()
+	if err := runner.Up(); err != nil {
+		t.Fatalf("failed to migrate the test database: %v", err)
+	}
+	return db
+}
+
+func TestStores(t *testing.T) {
+	db := openTestDB(t)
+	storetest.Run(t, func(t *testing.T) model.Stores {
+		if _, err := db.Exec("TRUNCATE driver_helpers, vehicles, audit_log, webhook_deliveries CASCADE"); err != nil {
+			t.Fatalf("failed to empty the test database: %v", err)
+		}
+		return NewDBStores(db, nil)
+	})
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/fieldcrypt"
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+// dbtx is satisfied by both *sqlx.DB and *sqlx.Tx, so the stores run the same
+// queries whether or not they are part of a transaction.
+type dbtx interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+}
+
+var (
+	_ dbtx = (*sqlx.DB)(nil)
+	_ dbtx = (*sqlx.Tx)(nil)
+)
+
+// NewDBStores returns the stores over db, encrypting sealed columns with
+// keyring (which may be nil to store them in plaintext).
+func NewDBStores(db *sqlx.DB, keyring *fieldcrypt.Keyring) model.Stores {
+	return newDBStores(db, keyring)
+}
+
+func newDBStores(db dbtx, keyring *fieldcrypt.Keyring) model.Stores {
+	return model.Stores{
+		DriverHelpers: &DBDriverHelperStore{db: db, keyring: keyring},
+		Vehicles:      &DBVehicleStore{db: db},
+		Audit:         &DBAuditStore{db: db, keyring: keyring},
+		Webhooks:      &DBWebhookStore{db: db, keyring: keyring},
+	}
+}
+
+// DBUnitOfWork runs units of work in SERIALIZABLE transactions, retrying
+// those PostgreSQL aborts with a serialization failure or deadlock.
+type DBUnitOfWork struct {
+	db         *sqlx.DB
+	keyring    *fieldcrypt.Keyring
+	maxRetries int
+}
+
+var _ model.UnitOfWork = (*DBUnitOfWork)(nil)
+
+func NewDBUnitOfWork(db *sqlx.DB, keyring *fieldcrypt.Keyring, maxRetries int) *DBUnitOfWork {
+	return &DBUnitOfWork{db: db, keyring: keyring, maxRetries: maxRetries}
+}
+
+func (u *DBUnitOfWork) WithTx(ctx context.Context, fn func(tx model.Stores) error) error {
+	return withRetries(ctx, u.maxRetries, func() error { return u.run(ctx, fn) })
+}
+
+// withRetries calls run until it succeeds, fails with an error that is not
+// retryable, or has been retried maxRetries times, backing off in between.
+func withRetries(ctx context.Context, maxRetries int, run func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := run()
+		if err == nil || !isRetryable(err) || attempt >= maxRetries {
+			return err
+		}
+
+		backoff := time.Duration(attempt+1) * 10 * time.Millisecond
+		slog.Debug("retrying transaction", "attempt", attempt+1, "error", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", model.ErrUnavailable, ctx.Err())
+		case <-time.After(backoff):
+		}
+	}
+}
+
+func (u *DBUnitOfWork) run(ctx context.Context, fn func(tx model.Stores) error) error {
+	tx, err := u.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+
+	if err := fn(newDBStores(tx, u.keyring)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", translateError(err))
+	}
+	return nil
+}
+
+// isRetryable reports a transaction aborted by serialization_failure (40001) or deadlock_detected (40P01).
+func isRetryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
+}
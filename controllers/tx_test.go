@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "serialization failure", err: &pq.Error{Code: "40001"}, want: true},
+		{name: "deadlock", err: &pq.Error{Code: "40P01"}, want: true},
+		{name: "wrapped serialization failure", err: fmt.Errorf("failed to commit transaction: %w", &pq.Error{Code: "40001"}), want: true},
+		{name: "unique violation", err: &pq.Error{Code: "23505"}},
+		{name: "lock not available", err: &pq.Error{Code: "55P03"}},
+		{name: "query canceled", err: &pq.Error{Code: "57014"}},
+		{name: "not a PostgreSQL error", err: errors.New("40001")},
+		{name: "model error", err: model.ErrConflict},
+		{name: "nil", err: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.err); got != tt.want {
+				t.Errorf("isRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithRetries(t *testing.T) {
+	serialization := &pq.Error{Code: "40001"}
+	tests := []struct {
+		name       string
+		maxRetries int
+		errs       []error // returned by successive attempts; nil once they run out
+		wantCalls  int
+		wantErr    error
+	}{
+		{name: "succeeds at once", maxRetries: 3, wantCalls: 1},
+		{name: "succeeds on retry", maxRetries: 3, errs: []error{serialization, &pq.Error{Code: "40P01"}}, wantCalls: 3},
+		{name: "gives up at the limit", maxRetries: 2, errs: []error{serialization, serialization, serialization, serialization}, wantCalls: 3, wantErr: serialization},
+		{name: "no retries configured", maxRetries: 0, errs: []error{serialization}, wantCalls: 1, wantErr: serialization},
+		{name: "other errors are not retried", maxRetries: 3, errs: []error{model.ErrConflict}, wantCalls: 1, wantErr: model.ErrConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := withRetries(context.Background(), tt.maxRetries, func() error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("withRetries = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("run called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestWithRetriesStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := withRetries(ctx, 5, func() error {
+		calls++
+		cancel()
+		return &pq.Error{Code: "40001"}
+	})
+	if !errors.Is(err, model.ErrUnavailable) || !errors.Is(err, context.Canceled) {
+		t.Errorf("withRetries = %v, want %v wrapping %v", err, model.ErrUnavailable, context.Canceled)
+	}
+	if calls != 1 {
+		t.Errorf("run called %d times after cancellation, want 1", calls)
+	}
+}
@@ -0,0 +1,235 @@
+// Package fieldcrypt encrypts individual column values at rest.
+//
+// Each value is sealed with AES-256-GCM under its own random data key, and
+// the data key is in turn sealed under the keyring's primary key (envelope
+// encryption). Rotating the primary key therefore only needs the small data
+// keys rewrapped, and older keys stay in the keyring to open values sealed
+// before a rotation. Sealed values look like
+//
+//	enc:v1:<key id>:<base64 wrapped data key>:<base64 ciphertext>
+//
+// A nil *Keyring is valid and leaves values in plaintext.
+package fieldcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+const sealedPrefix = "enc:v1:"
+
+const keySize = 32
+
+// ErrNoKeys is returned when opening a sealed value without a keyring.
+var ErrNoKeys = errors.New("value is encrypted but no encryption keys are configured")
+
+type Keyring struct {
+	primary  string
+	keys     map[string]cipher.AEAD
+	indexKey []byte
+}
+
+// Key is a 32-byte AES key and the ID sealed values name it by.
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// NewKeyring builds a keyring that seals under the key whose ID is primary and
+// opens values sealed under any of keys; indexKey is the 32-byte HMAC key of
+// the blind indexes.
+func NewKeyring(keys []Key, primary string, indexKey []byte) (*Keyring, error) {
+	k := &Keyring{primary: primary, keys: make(map[string]cipher.AEAD)}
+	for _, key := range keys {
+		if key.ID == "" || strings.Contains(key.ID, ":") {
+			return nil, fmt.Errorf("invalid encryption key ID %q", key.ID)
+		}
+		if _, exists := k.keys[key.ID]; exists {
+			return nil, fmt.Errorf("encryption key %s is listed twice", key.ID)
+		}
+		if len(key.Secret) != keySize {
+			return nil, fmt.Errorf("encryption key %s must be %d bytes, not %d", key.ID, keySize, len(key.Secret))
+		}
+		var err error
+		if k.keys[key.ID], err = newAEAD(key.Secret); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := k.keys[k.primary]; !ok {
+		return nil, fmt.Errorf("encryption primary key %q is not in the keyring", k.primary)
+	}
+	if len(indexKey) != keySize {
+		return nil, fmt.Errorf("encryption index key must be %d bytes, not %d", keySize, len(indexKey))
+	}
+	k.indexKey = indexKey
+	return k, nil
+}
+
+// ParseKey decodes a key written as "id:base64".
+func ParseKey(entry string) (Key, error) {
+	id, encoded, ok := strings.Cut(entry, ":")
+	if !ok || id == "" {
+		return Key{}, fmt.Errorf("encryption key %q must be written as id:base64", entry)
+	}
+	secret, err := DecodeKey(encoded)
+	if err != nil {
+		return Key{}, fmt.Errorf("encryption key %s: %w", id, err)
+	}
+	return Key{ID: id, Secret: secret}, nil
+}
+
+// DecodeKey decodes a base64 32-byte key.
+func DecodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64: %w", err)
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("key must be %d bytes, not %d", keySize, len(key))
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// IsSealed reports whether value was produced by Seal.
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, sealedPrefix)
+}
+
+// Seal encrypts plaintext under the primary key. context is authenticated
+// along with it, so a sealed value only opens with the same context; callers
+// pass the table, column and row it is stored in. Empty values stay empty.
+func (k *Keyring) Seal(plaintext, context string) (string, error) {
+	if k == nil || plaintext == "" {
+		return plaintext, nil
+	}
+
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	wrapped, err := seal(k.keys[k.primary], dataKey, []byte(k.primary))
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(dataAEAD, []byte(plaintext), []byte(context))
+	if err != nil {
+		return "", err
+	}
+
+	return sealedPrefix + k.primary + ":" +
+		base64.RawStdEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Open decrypts a value sealed with the same context. Values that were never
+// sealed, such as rows written before encryption was enabled, are returned as is.
+func (k *Keyring) Open(value, context string) (string, error) {
+	if !IsSealed(value) {
+		return value, nil
+	}
+	if k == nil {
+		return "", ErrNoKeys
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, sealedPrefix), ":")
+	if len(parts) != 3 {
+		return "", errors.New("malformed encrypted value")
+	}
+	id := parts[0]
+	kek, ok := k.keys[id]
+	if !ok {
+		return "", fmt.Errorf("value is encrypted with unknown key %q", id)
+	}
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", errors.New("malformed encrypted value")
+	}
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errors.New("malformed encrypted value")
+	}
+
+	dataKey, err := open(kek, wrapped, []byte(id))
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(dataAEAD, ciphertext, []byte(context))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// NeedsReseal reports whether value should be sealed again: it is stored in
+// plaintext or under a key other than the primary.
+func (k *Keyring) NeedsReseal(value string) bool {
+	if k == nil || value == "" {
+		return false
+	}
+	if !IsSealed(value) {
+		return true
+	}
+	id, _, _ := strings.Cut(strings.TrimPrefix(value, sealedPrefix), ":")
+	return id != k.primary
+}
+
+// BlindIndex returns a deterministic digest of value for equality lookups and
+// unique indexes on sealed columns, or nil for an empty value. It is keyed
+// with the index key, and namespaced by column so equal values in different
+// columns do not match. Without a keyring the values are stored in plaintext
+// anyway, so the digest is left unkeyed.
+func (k *Keyring) BlindIndex(column, value string) []byte {
+	if value == "" {
+		return nil
+	}
+	var mac hash.Hash
+	if k == nil {
+		mac = sha256.New()
+	} else {
+		mac = hmac.New(sha256.New, k.indexKey)
+	}
+	mac.Write([]byte(column))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, sealed, additionalData []byte) ([]byte, error) {
+	n := aead.NonceSize()
+	if len(sealed) < n {
+		return nil, errors.New("ciphertext too short")
+	}
+	return aead.Open(nil, sealed[:n], sealed[n:], additionalData)
+}
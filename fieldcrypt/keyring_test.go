@@ -0,0 +1,188 @@
+package fieldcrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// testKey returns a 32-byte key filled with b.
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, keySize)
+}
+
+func newTestKeyring(t *testing.T, primary string, ids ...string) *Keyring {
+	t.Helper()
+	keys := make([]Key, len(ids))
+	for i, id := range ids {
+		keys[i] = Key{ID: id, Secret: testKey(byte(i + 1))}
+	}
+	k, err := NewKeyring(keys, primary, testKey(0xff))
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	return k
+}
+
+func TestSealOpen(t *testing.T) {
+	k := newTestKeyring(t, "k1", "k1")
+	const context = "driver_helpers.aadhar_number/1"
+
+	sealed, err := k.Seal("234567890124", context)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if !IsSealed(sealed) || strings.Contains(sealed, "234567890124") {
+		t.Fatalf("Seal = %q, want an enc:v1 value hiding the plaintext", sealed)
+	}
+	if again, _ := k.Seal("234567890124", context); again == sealed {
+		t.Error("sealing the same value twice gave the same ciphertext")
+	}
+	if got, err := k.Open(sealed, context); err != nil || got != "234567890124" {
+		t.Errorf("Open = %q, %v; want the plaintext", got, err)
+	}
+
+	if _, err := k.Open(sealed, "driver_helpers.aadhar_number/2"); err == nil {
+		t.Error("Open with another row's context succeeded")
+	}
+	if _, err := k.Open(sealed, "driver_helpers.license_number/1"); err == nil {
+		t.Error("Open with another column's context succeeded")
+	}
+
+	parts := strings.Split(sealed, ":")
+	parts[len(parts)-1] = base64.RawStdEncoding.EncodeToString([]byte("tampered ciphertext long enough"))
+	if _, err := k.Open(strings.Join(parts, ":"), context); err == nil {
+		t.Error("Open of a tampered value succeeded")
+	}
+	if _, err := k.Open("enc:v1:k1:only-two-parts", context); err == nil {
+		t.Error("Open of a malformed value succeeded")
+	}
+}
+
+func TestOpenUnknownKey(t *testing.T) {
+	old := newTestKeyring(t, "k1", "k1")
+	sealed, err := old.Seal("MH1220110012345", "ctx")
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	other := newTestKeyring(t, "k2", "k2")
+	if _, err := other.Open(sealed, "ctx"); err == nil || !strings.Contains(err.Error(), `unknown key "k1"`) {
+		t.Errorf("Open = %v, want an unknown key error", err)
+	}
+	var none *Keyring
+	if _, err := none.Open(sealed, "ctx"); err != ErrNoKeys {
+		t.Errorf("Open without a keyring = %v, want %v", err, ErrNoKeys)
+	}
+}
+
+func TestRotatedPrimary(t *testing.T) {
+	old := newTestKeyring(t, "k1", "k1")
+	sealed, err := old.Seal("9876543210", "ctx")
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if old.NeedsReseal(sealed) {
+		t.Error("NeedsReseal under the key that sealed it")
+	}
+
+	rotated := newTestKeyring(t, "k2", "k1", "k2")
+	if got, err := rotated.Open(sealed, "ctx"); err != nil || got != "9876543210" {
+		t.Errorf("Open after rotation = %q, %v; want the plaintext", got, err)
+	}
+	if !rotated.NeedsReseal(sealed) {
+		t.Error("a value under the retired primary does not need resealing")
+	}
+	resealed, err := rotated.Seal("9876543210", "ctx")
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if !strings.HasPrefix(resealed, sealedPrefix+"k2:") || rotated.NeedsReseal(resealed) {
+		t.Errorf("Seal after rotation = %q, want it under k2", resealed)
+	}
+}
+
+func TestPlaintextPassthrough(t *testing.T) {
+	k := newTestKeyring(t, "k1", "k1")
+	if got, err := k.Open("234567890124", "ctx"); err != nil || got != "234567890124" {
+		t.Errorf("Open of a plaintext value = %q, %v; want it unchanged", got, err)
+	}
+	if !k.NeedsReseal("234567890124") {
+		t.Error("a plaintext value does not need sealing")
+	}
+	if sealed, err := k.Seal("", "ctx"); err != nil || sealed != "" {
+		t.Errorf("Seal of an empty value = %q, %v; want it empty", sealed, err)
+	}
+	if k.NeedsReseal("") {
+		t.Error("an empty value needs sealing")
+	}
+
+	var none *Keyring
+	if sealed, err := none.Seal("234567890124", "ctx"); err != nil || sealed != "234567890124" {
+		t.Errorf("Seal without a keyring = %q, %v; want the plaintext", sealed, err)
+	}
+	if none.NeedsReseal("234567890124") {
+		t.Error("a plaintext value needs sealing without a keyring")
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	k := newTestKeyring(t, "k1", "k1")
+	rotated := newTestKeyring(t, "k2", "k1", "k2")
+
+	index := k.BlindIndex("aadhar_number", "234567890124")
+	if len(index) == 0 {
+		t.Fatal("BlindIndex is empty")
+	}
+	if !bytes.Equal(index, k.BlindIndex("aadhar_number", "234567890124")) {
+		t.Error("BlindIndex is not deterministic")
+	}
+	if !bytes.Equal(index, rotated.BlindIndex("aadhar_number", "234567890124")) {
+		t.Error("BlindIndex changed with the primary key; it only depends on the index key")
+	}
+	if bytes.Equal(index, k.BlindIndex("license_number", "234567890124")) {
+		t.Error("BlindIndex matches across columns")
+	}
+	if bytes.Equal(index, k.BlindIndex("aadhar_number", "345678901238")) {
+		t.Error("BlindIndex matches different values")
+	}
+	var none *Keyring
+	if bytes.Equal(index, none.BlindIndex("aadhar_number", "234567890124")) {
+		t.Error("BlindIndex is unkeyed with a keyring")
+	}
+	if k.BlindIndex("aadhar_number", "") != nil {
+		t.Error("BlindIndex of an empty value is not nil")
+	}
+}
+
+func TestNewKeyringRejectsBadKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []Key
+		primary  string
+		indexKey []byte
+	}{
+		{name: "primary missing", keys: []Key{{ID: "k1", Secret: testKey(1)}}, primary: "k2", indexKey: testKey(0xff)},
+		{name: "short key", keys: []Key{{ID: "k1", Secret: testKey(1)[:16]}}, primary: "k1", indexKey: testKey(0xff)},
+		{name: "duplicate ID", keys: []Key{{ID: "k1", Secret: testKey(1)}, {ID: "k1", Secret: testKey(2)}}, primary: "k1", indexKey: testKey(0xff)},
+		{name: "ID with a colon", keys: []Key{{ID: "k:1", Secret: testKey(1)}}, primary: "k:1", indexKey: testKey(0xff)},
+		{name: "short index key", keys: []Key{{ID: "k1", Secret: testKey(1)}}, primary: "k1", indexKey: testKey(0xff)[:8]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewKeyring(tt.keys, tt.primary, tt.indexKey); err == nil {
+				t.Error("NewKeyring accepted the keys")
+			}
+		})
+	}
+
+	if _, err := ParseKey("k1:" + base64.StdEncoding.EncodeToString(testKey(1))); err != nil {
+		t.Errorf("ParseKey: %v", err)
+	}
+	for _, entry := range []string{"k1", ":" + base64.StdEncoding.EncodeToString(testKey(1)), "k1:not base64", "k1:AAAA"} {
+		if _, err := ParseKey(entry); err == nil {
+			t.Errorf("ParseKey(%q) succeeded", entry)
+		}
+	}
+}
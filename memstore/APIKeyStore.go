@@ -0,0 +1,100 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+type APIKeyStore struct {
+	db *DB
+}
+
+var _ model.APIKeyStore = (*APIKeyStore)(nil)
+
+func NewAPIKeyStore(db *DB) *APIKeyStore {
+	return &APIKeyStore{db: db}
+}
+
+func (s *APIKeyStore) CreateAPIKey(ctx context.Context, key *model.APIKey) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, existing := range s.db.apiKeys {
+		if existing.ID == key.ID || existing.Prefix == key.Prefix {
+			return fmt.Errorf("API key %s already exists: %w", key.Prefix, model.ErrConflict)
+		}
+	}
+	key.KeyHash = slices.Clone(key.KeyHash)
+	key.Scopes = slices.Clone(key.Scopes)
+	s.db.apiKeys = append(s.db.apiKeys, *key)
+	return nil
+}
+
+func (s *APIKeyStore) APIKeys(ctx context.Context) ([]model.APIKey, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return slices.Clone(s.db.apiKeys), nil
+}
+
+func (s *APIKeyStore) APIKeyByPrefix(ctx context.Context, prefix string) (model.APIKey, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	for _, key := range s.db.apiKeys {
+		if key.Prefix == prefix {
+			return key, nil
+		}
+	}
+	return model.APIKey{}, fmt.Errorf("API key with prefix %s %w", prefix, model.ErrNotFound)
+}
+
+func (s *APIKeyStore) RotateAPIKey(ctx context.Context, id uuid.UUID, prefix string, keyHash []byte) (model.APIKey, error) {
+	return s.modifyUnrevoked(id, func(key *model.APIKey) {
+		key.Prefix = prefix
+		key.KeyHash = slices.Clone(keyHash)
+		key.LastUsedAt = nil
+		key.UpdatedAt = time.Now()
+	})
+}
+
+func (s *APIKeyStore) RevokeAPIKey(ctx context.Context, id uuid.UUID) (model.APIKey, error) {
+	return s.modifyUnrevoked(id, func(key *model.APIKey) {
+		now := time.Now()
+		key.RevokedAt = &now
+		key.UpdatedAt = now
+	})
+}
+
+func (s *APIKeyStore) modifyUnrevoked(id uuid.UUID, apply func(key *model.APIKey)) (model.APIKey, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	i := slices.IndexFunc(s.db.apiKeys, func(k model.APIKey) bool { return k.ID == id })
+	if i < 0 {
+		return model.APIKey{}, model.NotFoundError("API key", id)
+	}
+	key := s.db.apiKeys[i]
+	if key.RevokedAt != nil {
+		return model.APIKey{}, model.RevokedError("API key", id)
+	}
+	apply(&key)
+	s.db.apiKeys[i] = key
+	return key, nil
+}
+
+func (s *APIKeyStore) TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if i := slices.IndexFunc(s.db.apiKeys, func(k model.APIKey) bool { return k.ID == id }); i >= 0 {
+		s.db.apiKeys[i].LastUsedAt = &usedAt
+	}
+	return nil
+}
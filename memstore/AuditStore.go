@@ -0,0 +1,49 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+type AuditStore struct {
+	db *DB
+}
+
+var _ model.AuditStore = (*AuditStore)(nil)
+
+func NewAuditStore(db *DB) *AuditStore {
+	return &AuditStore{db: db}
+}
+
+func (s *AuditStore) RecordAudit(ctx context.Context, entry *model.AuditEntry) error {
+	if entry.ID == uuid.Nil {
+		entry.ID = uuid.New()
+	}
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.auditLog = append(s.db.auditLog, *entry)
+	return nil
+}
+
+func (s *AuditStore) AuditHistory(ctx context.Context, entityType string, entityID uuid.UUID, filter model.AuditFilter) (model.Page[model.AuditEntry], error) {
+	if err := filter.Normalize(); err != nil {
+		return model.Page[model.AuditEntry]{}, err
+	}
+
+	s.db.mu.RLock()
+	var entries []model.AuditEntry
+	for _, entry := range s.db.auditLog {
+		if entry.EntityType == entityType && entry.EntityID == entityID &&
+			(filter.Operation == "" || entry.Operation == filter.Operation) {
+			entries = append(entries, entry)
+		}
+	}
+	s.db.mu.RUnlock()
+
+	return paginate(entries, filter.ListOptions, model.AuditEntry.SortKey, func(e model.AuditEntry) uuid.UUID { return e.ID })
+}
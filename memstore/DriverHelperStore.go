@@ -0,0 +1,344 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+type DriverHelperStore struct {
+	db *DB
+}
+
+var _ model.DriverHelperStore = (*DriverHelperStore)(nil)
+
+func NewDriverHelperStore(db *DB) *DriverHelperStore {
+	return &DriverHelperStore{db: db}
+}
+
+func (s *DriverHelperStore) DriverHelperByID(ctx context.Context, id uuid.UUID) (model.DriverHelper, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	row, ok := s.db.driverHelpers[id]
+	if !ok || (row.dh.DeletedAt != nil && !model.IncludesDeleted(ctx)) {
+		return model.DriverHelper{}, model.NotFoundError("driver/helper", id)
+	}
+	return row.dh, nil
+}
+
+func (s *DriverHelperStore) DriverHelpers(ctx context.Context, filter model.DriverHelperFilter) (model.Page[model.DriverHelper], error) {
+	if err := filter.Normalize(); err != nil {
+		return model.Page[model.DriverHelper]{}, err
+	}
+
+	dhs := s.selectWhere(ctx, func(dh model.DriverHelper) bool {
+		switch {
+		case filter.UserType != "" && dh.UserType != filter.UserType,
+			filter.PoliceVerification != "" && dh.PoliceVerification != filter.PoliceVerification,
+			filter.BloodGroup != "" && dh.BloodGroup != filter.BloodGroup,
+			filter.LicenseExpiresAfter != nil && (dh.LicenseExpiryDate == nil || dh.LicenseExpiryDate.Before(*filter.LicenseExpiresAfter)),
+			filter.LicenseExpiresBefore != nil && (dh.LicenseExpiryDate == nil || dh.LicenseExpiryDate.After(*filter.LicenseExpiresBefore)):
+			return false
+		}
+		return true
+	})
+	return paginate(dhs, filter.ListOptions, model.DriverHelper.SortKey, func(dh model.DriverHelper) uuid.UUID { return dh.ID })
+}
+
+func (s *DriverHelperStore) Drivers(ctx context.Context) ([]model.DriverHelper, error) {
+	return s.selectWhere(ctx, func(dh model.DriverHelper) bool { return dh.UserType == "Driver" }), nil
+}
+
+func (s *DriverHelperStore) Helpers(ctx context.Context) ([]model.DriverHelper, error) {
+	return s.selectWhere(ctx, func(dh model.DriverHelper) bool { return dh.UserType == "Helper" }), nil
+}
+
+func (s *DriverHelperStore) VerifiedDriverHelpers(ctx context.Context) ([]model.DriverHelper, error) {
+	return s.selectWhere(ctx, func(dh model.DriverHelper) bool { return dh.PoliceVerification == "Yes" }), nil
+}
+
+func (s *DriverHelperStore) PendingVerificationDriverHelpers(ctx context.Context) ([]model.DriverHelper, error) {
+	return s.selectWhere(ctx, func(dh model.DriverHelper) bool { return dh.PoliceVerification == "No" }), nil
+}
+
+func (s *DriverHelperStore) VerificationsDueForRenewal(ctx context.Context, verifiedBefore time.Time) ([]model.DriverHelper, error) {
+	dhs := s.selectWhere(ctx, func(dh model.DriverHelper) bool {
+		return dh.PoliceVerification == "Yes" && dh.PoliceVerificationDate != nil && dh.PoliceVerificationDate.Before(verifiedBefore)
+	})
+	sort.SliceStable(dhs, func(i, j int) bool { return dhs[i].PoliceVerificationDate.Before(*dhs[j].PoliceVerificationDate) })
+	return dhs, nil
+}
+
+func (s *DriverHelperStore) LicensesExpiringBetween(ctx context.Context, from, to time.Time) ([]model.DriverHelper, error) {
+	dhs := s.selectWhere(ctx, func(dh model.DriverHelper) bool {
+		return dh.UserType == "Driver" && dh.LicenseExpiryDate != nil &&
+			!dh.LicenseExpiryDate.Before(from) && dh.LicenseExpiryDate.Before(to)
+	})
+	sort.SliceStable(dhs, func(i, j int) bool { return dhs[i].LicenseExpiryDate.Before(*dhs[j].LicenseExpiryDate) })
+	return dhs, nil
+}
+
+func (s *DriverHelperStore) ExpiredLicenses(ctx context.Context, asOf time.Time) ([]model.DriverHelper, error) {
+	dhs := s.selectWhere(ctx, func(dh model.DriverHelper) bool {
+		return dh.UserType == "Driver" && dh.LicenseExpiryDate != nil && dh.LicenseExpiryDate.Before(asOf)
+	})
+	sort.SliceStable(dhs, func(i, j int) bool { return dhs[i].LicenseExpiryDate.Before(*dhs[j].LicenseExpiryDate) })
+	return dhs, nil
+}
+
+func (s *DriverHelperStore) SubmitPoliceVerification(ctx context.Context, id uuid.UUID, expectedVersion int, date time.Time, documentPath string) (model.DriverHelper, error) {
+	return s.modify(id, expectedVersion, func(dh *model.DriverHelper) error {
+		return dh.ApplyPoliceVerification(date, documentPath)
+	})
+}
+
+func (s *DriverHelperStore) RevokePoliceVerification(ctx context.Context, id uuid.UUID, expectedVersion int, reason string) (model.DriverHelper, error) {
+	return s.modify(id, expectedVersion, func(dh *model.DriverHelper) error {
+		return dh.RevokePoliceVerification(reason, time.Now())
+	})
+}
+
+// modify applies fn to a copy of the stored row and saves it only if fn
+// succeeds, and only if the row is at expectedVersion when that is non-zero.
+func (s *DriverHelperStore) modify(id uuid.UUID, expectedVersion int, fn func(dh *model.DriverHelper) error) (model.DriverHelper, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	row, ok := s.db.driverHelpers[id]
+	if !ok || row.dh.DeletedAt != nil {
+		return model.DriverHelper{}, model.NotFoundError("driver/helper", id)
+	}
+	if err := checkVersion("driver/helper", id, row.dh.Version, expectedVersion); err != nil {
+		return row.dh, err
+	}
+
+	dh := row.dh
+	if err := fn(&dh); err != nil {
+		return row.dh, err
+	}
+	dh.UpdatedAt = time.Now()
+	dh.Version++
+	s.db.driverHelpers[id] = driverHelperRow{seq: row.seq, dh: dh}
+	return dh, nil
+}
+
+func (s *DriverHelperStore) CreateDriverHelper(ctx context.Context, dh *model.DriverHelper) error {
+	if dh.ID == uuid.Nil {
+		dh.ID = uuid.New()
+	}
+	if err := dh.Validate(); err != nil {
+		return err
+	}
+	if err := checkDriverHelperEnums(dh); err != nil {
+		return fmt.Errorf("failed to insert driver/helper: %w", err)
+	}
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, exists := s.db.driverHelpers[dh.ID]; exists {
+		return fmt.Errorf("failed to insert driver/helper: duplicate key value violates unique constraint \"driver_helpers_pkey\": %w", model.ErrConflict)
+	}
+	if err := s.checkUniqueIdentifiers(*dh); err != nil {
+		return fmt.Errorf("failed to insert driver/helper: %w", err)
+	}
+
+	row := *dh
+	row.PoliceVerificationRevokedAt = nil
+	row.PoliceVerificationRevocationReason = ""
+	row.DeletedAt, row.DeletedBy, row.DeleteReason = nil, "", ""
+	row.CreatedAt = time.Now()
+	row.UpdatedAt = row.CreatedAt
+	row.Version = 1
+	s.db.driverHelpers[dh.ID] = driverHelperRow{seq: s.db.nextSeq(), dh: row}
+	*dh = row
+	return nil
+}
+
+func (s *DriverHelperStore) UpdateDriverHelper(ctx context.Context, dh *model.DriverHelper) error {
+	if err := dh.Validate(); err != nil {
+		return err
+	}
+	if err := checkDriverHelperEnums(dh); err != nil {
+		return fmt.Errorf("failed to update driver/helper: %w", err)
+	}
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	existing, ok := s.db.driverHelpers[dh.ID]
+	if !ok || existing.dh.DeletedAt != nil {
+		return model.NotFoundError("driver/helper", dh.ID)
+	}
+	if err := checkVersion("driver/helper", dh.ID, existing.dh.Version, dh.Version); err != nil {
+		return err
+	}
+	if err := s.checkUniqueIdentifiers(*dh); err != nil {
+		return fmt.Errorf("failed to update driver/helper: %w", err)
+	}
+
+	row := *dh
+	row.PoliceVerificationRevokedAt = existing.dh.PoliceVerificationRevokedAt
+	row.PoliceVerificationRevocationReason = existing.dh.PoliceVerificationRevocationReason
+	row.DeletedAt, row.DeletedBy, row.DeleteReason = nil, "", ""
+	row.CreatedAt = existing.dh.CreatedAt
+	row.UpdatedAt = time.Now()
+	row.Version = existing.dh.Version + 1
+	s.db.driverHelpers[dh.ID] = driverHelperRow{seq: existing.seq, dh: row}
+	*dh = row
+	return nil
+}
+
+func (s *DriverHelperStore) PatchDriverHelper(ctx context.Context, dh *model.DriverHelper, columns []string) error {
+	if err := model.ValidatePatchColumns(dh, columns); err != nil {
+		return err
+	}
+	if err := dh.ValidateColumns(columns); err != nil {
+		return err
+	}
+
+	patched, err := s.modify(dh.ID, dh.Version, func(row *model.DriverHelper) error {
+		model.CopyColumns(row, dh, columns)
+		if err := checkDriverHelperEnums(row); err != nil {
+			return err
+		}
+		return s.checkUniqueIdentifiers(*row)
+	})
+	if err != nil {
+		return err
+	}
+	*dh = patched
+	return nil
+}
+
+// DeleteDriverHelper soft-deletes the row, leaving the vehicles assigned to the
+// driver/helper as they are; they are only unassigned when the row is purged.
+func (s *DriverHelperStore) DeleteDriverHelper(ctx context.Context, id uuid.UUID, expectedVersion int, reason string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	row, ok := s.db.driverHelpers[id]
+	if !ok || row.dh.DeletedAt != nil {
+		return model.NotFoundError("driver/helper", id)
+	}
+	if err := checkVersion("driver/helper", id, row.dh.Version, expectedVersion); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	row.dh.DeletedAt, row.dh.DeletedBy, row.dh.DeleteReason = &now, model.Actor(ctx), reason
+	row.dh.UpdatedAt = now
+	row.dh.Version++
+	s.db.driverHelpers[id] = row
+	return nil
+}
+
+func (s *DriverHelperStore) RestoreDriverHelper(ctx context.Context, id uuid.UUID) (model.DriverHelper, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	row, ok := s.db.driverHelpers[id]
+	if !ok {
+		return model.DriverHelper{}, model.NotFoundError("driver/helper", id)
+	}
+	if row.dh.DeletedAt == nil {
+		return row.dh, model.NotDeletedError("driver/helper", id)
+	}
+	if err := s.checkUniqueIdentifiers(row.dh); err != nil {
+		return row.dh, fmt.Errorf("failed to restore driver/helper: %w", err)
+	}
+
+	row.dh.DeletedAt, row.dh.DeletedBy, row.dh.DeleteReason = nil, "", ""
+	row.dh.UpdatedAt = time.Now()
+	row.dh.Version++
+	s.db.driverHelpers[id] = row
+	return row.dh, nil
+}
+
+// PurgeDeletedDriverHelpers unassigns the purged rows' vehicles, as ON DELETE SET NULL does in Postgres.
+func (s *DriverHelperStore) PurgeDeletedDriverHelpers(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	var ids []uuid.UUID
+	for id, row := range s.db.driverHelpers {
+		if row.dh.DeletedAt == nil || !row.dh.DeletedAt.Before(deletedBefore) {
+			continue
+		}
+		delete(s.db.driverHelpers, id)
+		ids = append(ids, id)
+		for vid, vrow := range s.db.vehicles {
+			if vrow.v.DriverHelperID == id {
+				vrow.v.DriverHelperID = uuid.Nil
+				s.db.vehicles[vid] = vrow
+			}
+		}
+	}
+	return ids, nil
+}
+
+func (s *DriverHelperStore) DriverHelperByMobileNumber(ctx context.Context, mobile string) (model.DriverHelper, error) {
+	dhs := s.selectWhere(ctx, func(dh model.DriverHelper) bool { return dh.MobileNumber == mobile })
+	if len(dhs) == 0 {
+		return model.DriverHelper{}, fmt.Errorf("driver/helper with mobile number %s %w", mobile, model.ErrNotFound)
+	}
+	return dhs[0], nil
+}
+
+func (s *DriverHelperStore) selectWhere(ctx context.Context, match func(model.DriverHelper) bool) []model.DriverHelper {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	includeDeleted := model.IncludesDeleted(ctx)
+	var rows []driverHelperRow
+	for _, row := range s.db.driverHelpers {
+		if (includeDeleted || row.dh.DeletedAt == nil) && match(row.dh) {
+			rows = append(rows, row)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].seq < rows[j].seq })
+
+	var dhs []model.DriverHelper
+	for _, row := range rows {
+		dhs = append(dhs, row.dh)
+	}
+	return dhs
+}
+
+// checkUniqueIdentifiers mirrors the unique indexes on the Aadhaar and license
+// numbers of driver/helpers that are not deleted. It must be called with s.db.mu held.
+func (s *DriverHelperStore) checkUniqueIdentifiers(dh model.DriverHelper) error {
+	for _, row := range s.db.driverHelpers {
+		other := row.dh
+		if other.ID == dh.ID || other.DeletedAt != nil {
+			continue
+		}
+		if dh.AadharNumber != "" && other.AadharNumber == dh.AadharNumber {
+			return fmt.Errorf("duplicate key value violates unique constraint \"driver_helpers_aadhar_number_bidx_key\": %w", model.ErrConflict)
+		}
+		if dh.LicenseNumber != "" && other.LicenseNumber == dh.LicenseNumber {
+			return fmt.Errorf("duplicate key value violates unique constraint \"driver_helpers_license_number_bidx_key\": %w", model.ErrConflict)
+		}
+	}
+	return nil
+}
+
+// checkDriverHelperEnums rejects the values the Postgres enum columns would refuse.
+func checkDriverHelperEnums(dh *model.DriverHelper) error {
+	if dh.UserType != "Driver" && dh.UserType != "Helper" {
+		return model.NewFieldError("user_type", "invalid input value for enum user_type_enum: %q", dh.UserType)
+	}
+	if dh.PoliceVerification != "Yes" && dh.PoliceVerification != "No" {
+		return model.NewFieldError("police_verification", "invalid input value for enum police_verification_enum: %q", dh.PoliceVerification)
+	}
+	if !model.ValidBloodGroup(dh.BloodGroup) {
+		return model.NewFieldError("blood_group", "invalid input value for enum blood_group_enum: %q", dh.BloodGroup)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package memstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+func newTestDriver(aadhaar, mobile, license string) model.DriverHelper {
+	expiry := time.Now().AddDate(1, 0, 0)
+	return model.DriverHelper{UserType: "Driver", FirstName: "Ravi", BloodGroup: "O+", AadharNumber: aadhaar,
+		MobileNumber: mobile, LicenseNumber: license, LicenseExpiryDate: &expiry, PoliceVerification: "No"}
+}
+
+// backdateDeletion moves the deletion of a driver/helper back by age, as if it had been deleted that long ago.
+func backdateDeletion(db *DB, id uuid.UUID, age time.Duration) {
+	row := db.driverHelpers[id]
+	deletedAt := row.dh.DeletedAt.Add(-age)
+	row.dh.DeletedAt = &deletedAt
+	db.driverHelpers[id] = row
+}
+
+func TestPoliceVerificationChecksVersion(t *testing.T) {
+	ctx := context.Background()
+	store := NewDriverHelperStore(NewDB())
+	expiry := time.Now().AddDate(1, 0, 0)
+	dh := model.DriverHelper{UserType: "Driver", FirstName: "Ravi", BloodGroup: "O+", AadharNumber: "234567890124",
+		MobileNumber: "9876543210", LicenseNumber: "MH1220110012345", LicenseExpiryDate: &expiry, PoliceVerification: "No"}
+	if err := store.CreateDriverHelper(ctx, &dh); err != nil {
+		t.Fatalf("CreateDriverHelper: %v", err)
+	}
+	stale := dh.Version
+
+	verified, err := store.SubmitPoliceVerification(ctx, dh.ID, stale, time.Now().AddDate(0, 0, -1), "docs/pv.pdf")
+	if err != nil {
+		t.Fatalf("SubmitPoliceVerification at the current version: %v", err)
+	}
+	if verified.Version != stale+1 {
+		t.Errorf("version after submit = %d, want %d", verified.Version, stale+1)
+	}
+
+	if _, err := store.RevokePoliceVerification(ctx, dh.ID, stale, "forged"); !errors.Is(err, model.ErrPreconditionFailed) {
+		t.Fatalf("RevokePoliceVerification at a stale version = %v, want %v", err, model.ErrPreconditionFailed)
+	}
+	if _, err := store.SubmitPoliceVerification(ctx, dh.ID, stale, time.Now(), "docs/pv.pdf"); !errors.Is(err, model.ErrPreconditionFailed) {
+		t.Fatalf("SubmitPoliceVerification at a stale version = %v, want %v", err, model.ErrPreconditionFailed)
+	}
+	if _, err := store.RevokePoliceVerification(ctx, dh.ID, 0, "forged"); err != nil {
+		t.Errorf("RevokePoliceVerification without a version: %v", err)
+	}
+}
+
+func TestSoftDeleteAndRestoreDriverHelper(t *testing.T) {
+	ctx := model.WithActor(context.Background(), "clerk")
+	store := NewDriverHelperStore(NewDB())
+	dh := newTestDriver("234567890124", "9876543210", "MH1220110012345")
+	if err := store.CreateDriverHelper(ctx, &dh); err != nil {
+		t.Fatalf("CreateDriverHelper: %v", err)
+	}
+
+	if err := store.DeleteDriverHelper(ctx, dh.ID, dh.Version+1, "left"); !errors.Is(err, model.ErrPreconditionFailed) {
+		t.Fatalf("DeleteDriverHelper at a stale version = %v, want %v", err, model.ErrPreconditionFailed)
+	}
+	if err := store.DeleteDriverHelper(ctx, dh.ID, dh.Version, "left"); err != nil {
+		t.Fatalf("DeleteDriverHelper: %v", err)
+	}
+	if err := store.DeleteDriverHelper(ctx, dh.ID, 0, "left"); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("DeleteDriverHelper of a deleted row = %v, want %v", err, model.ErrNotFound)
+	}
+
+	if _, err := store.DriverHelperByID(ctx, dh.ID); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("DriverHelperByID of a deleted row = %v, want %v", err, model.ErrNotFound)
+	}
+	if drivers, _ := store.Drivers(ctx); len(drivers) != 0 {
+		t.Errorf("Drivers = %+v, want the deleted row left out", drivers)
+	}
+	if page, _ := store.DriverHelpers(ctx, model.DriverHelperFilter{}); len(page.Items) != 0 || page.Total != 0 {
+		t.Errorf("DriverHelpers = %+v, want the deleted row left out", page)
+	}
+	if _, err := store.DriverHelperByMobileNumber(ctx, dh.MobileNumber); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("DriverHelperByMobileNumber of a deleted row = %v, want %v", err, model.ErrNotFound)
+	}
+
+	withDeleted := model.WithDeleted(ctx)
+	deleted, err := store.DriverHelperByID(withDeleted, dh.ID)
+	if err != nil {
+		t.Fatalf("DriverHelperByID including deleted rows: %v", err)
+	}
+	if deleted.DeletedAt == nil || deleted.DeletedBy != "clerk" || deleted.DeleteReason != "left" || deleted.Version != dh.Version+1 {
+		t.Errorf("deleted row = %+v, want it marked deleted by clerk for \"left\" at version %d", deleted, dh.Version+1)
+	}
+	if page, _ := store.DriverHelpers(withDeleted, model.DriverHelperFilter{}); len(page.Items) != 1 {
+		t.Errorf("DriverHelpers including deleted rows = %+v, want the deleted row", page)
+	}
+
+	deleted.FirstName = "Ravindra"
+	if err := store.UpdateDriverHelper(ctx, &deleted); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("UpdateDriverHelper of a deleted row = %v, want %v", err, model.ErrNotFound)
+	}
+
+	restored, err := store.RestoreDriverHelper(ctx, dh.ID)
+	if err != nil {
+		t.Fatalf("RestoreDriverHelper: %v", err)
+	}
+	if restored.DeletedAt != nil || restored.DeletedBy != "" || restored.DeleteReason != "" || restored.Version != dh.Version+2 {
+		t.Errorf("restored row = %+v, want it undeleted at version %d", restored, dh.Version+2)
+	}
+	if _, err := store.DriverHelperByID(ctx, dh.ID); err != nil {
+		t.Errorf("DriverHelperByID after restore: %v", err)
+	}
+	if _, err := store.RestoreDriverHelper(ctx, dh.ID); !errors.Is(err, model.ErrConflict) {
+		t.Errorf("RestoreDriverHelper of a live row = %v, want %v", err, model.ErrConflict)
+	}
+	if _, err := store.RestoreDriverHelper(ctx, uuid.New()); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("RestoreDriverHelper of a missing row = %v, want %v", err, model.ErrNotFound)
+	}
+}
+
+func TestRestoreDriverHelperWhoseIdentifiersWereReused(t *testing.T) {
+	ctx := context.Background()
+	store := NewDriverHelperStore(NewDB())
+	dh := newTestDriver("234567890124", "9876543210", "MH1220110012345")
+	if err := store.CreateDriverHelper(ctx, &dh); err != nil {
+		t.Fatalf("CreateDriverHelper: %v", err)
+	}
+	if err := store.DeleteDriverHelper(ctx, dh.ID, 0, "duplicate entry"); err != nil {
+		t.Fatalf("DeleteDriverHelper: %v", err)
+	}
+
+	reuse := newTestDriver("234567890124", "9876543211", "MH1220110012346")
+	if err := store.CreateDriverHelper(ctx, &reuse); err != nil {
+		t.Fatalf("CreateDriverHelper reusing a deleted row's Aadhaar number: %v", err)
+	}
+	if _, err := store.RestoreDriverHelper(ctx, dh.ID); !errors.Is(err, model.ErrConflict) {
+		t.Errorf("RestoreDriverHelper = %v, want %v", err, model.ErrConflict)
+	}
+}
+
+func TestPurgeDeletedDriverHelpers(t *testing.T) {
+	ctx := context.Background()
+	db := NewDB()
+	drivers, vehicles := NewDriverHelperStore(db), NewVehicleStore(db)
+
+	old := newTestDriver("234567890124", "9876543210", "MH1220110012345")
+	recent := newTestDriver("345678901238", "9876543211", "MH1220110012346")
+	live := newTestDriver("456789012341", "9876543212", "MH1220110012347")
+	for _, dh := range []*model.DriverHelper{&old, &recent, &live} {
+		if err := drivers.CreateDriverHelper(ctx, dh); err != nil {
+			t.Fatalf("CreateDriverHelper: %v", err)
+		}
+	}
+	v := newTestVehicle("DL1PC0001", old.ID)
+	if err := vehicles.CreateVehicle(ctx, &v); err != nil {
+		t.Fatalf("CreateVehicle: %v", err)
+	}
+	for _, dh := range []model.DriverHelper{old, recent} {
+		if err := drivers.DeleteDriverHelper(ctx, dh.ID, 0, "left"); err != nil {
+			t.Fatalf("DeleteDriverHelper: %v", err)
+		}
+	}
+	backdateDeletion(db, old.ID, 60*24*time.Hour)
+
+	// The vehicle keeps its deleted driver until the driver is purged.
+	if stored, err := vehicles.VehicleByID(ctx, v.ID); err != nil || stored.DriverHelperID != old.ID {
+		t.Fatalf("vehicle after its driver's deletion = %+v, %v; want it still assigned", stored, err)
+	}
+
+	ids, err := drivers.PurgeDeletedDriverHelpers(ctx, time.Now().Add(-30*24*time.Hour))
+	if err != nil {
+		t.Fatalf("PurgeDeletedDriverHelpers: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != old.ID {
+		t.Fatalf("purged %v, want only the row deleted before the cutoff (%s)", ids, old.ID)
+	}
+	if _, err := drivers.DriverHelperByID(model.WithDeleted(ctx), old.ID); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("DriverHelperByID of a purged row = %v, want %v", err, model.ErrNotFound)
+	}
+	if _, err := drivers.DriverHelperByID(model.WithDeleted(ctx), recent.ID); err != nil {
+		t.Errorf("a row deleted after the cutoff was purged: %v", err)
+	}
+	if _, err := drivers.DriverHelperByID(ctx, live.ID); err != nil {
+		t.Errorf("a live row was purged: %v", err)
+	}
+
+	stored, err := vehicles.VehicleByID(ctx, v.ID)
+	if err != nil {
+		t.Fatalf("the purged driver's vehicle is gone: %v", err)
+	}
+	if stored.DriverHelperID != uuid.Nil {
+		t.Errorf("vehicle driver_helper_id = %s, want it cleared by the purge", stored.DriverHelperID)
+	}
+
+	if ids, err := drivers.PurgeDeletedDriverHelpers(ctx, time.Now().Add(-30*24*time.Hour)); err != nil || len(ids) != 0 {
+		t.Errorf("second PurgeDeletedDriverHelpers = %v, %v; want nothing left to purge", ids, err)
+	}
+}
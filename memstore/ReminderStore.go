@@ -0,0 +1,45 @@
+package memstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+type ReminderStore struct {
+	db *DB
+}
+
+var _ model.ReminderStore = (*ReminderStore)(nil)
+
+func NewReminderStore(db *DB) *ReminderStore {
+	return &ReminderStore{db: db}
+}
+
+// reminderKey mirrors the primary key of reminders_sent; due_date is a DATE column.
+func reminderKey(r model.Reminder) model.Reminder {
+	r.DueDate = r.DueDate.UTC().Truncate(24 * time.Hour)
+	r.SentAt = time.Time{}
+	return r
+}
+
+func (s *ReminderStore) ClaimReminder(ctx context.Context, r model.Reminder) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	key := reminderKey(r)
+	if _, sent := s.db.remindersSent[key]; sent {
+		return false, nil
+	}
+	s.db.remindersSent[key] = r.SentAt
+	return true, nil
+}
+
+func (s *ReminderStore) ReleaseReminder(ctx context.Context, r model.Reminder) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	delete(s.db.remindersSent, reminderKey(r))
+	return nil
+}
@@ -0,0 +1,290 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+	"github.com/arjunsaxaena/driver_vehicle_profile/validation"
+)
+
+type VehicleStore struct {
+	db *DB
+}
+
+var _ model.VehicleStore = (*VehicleStore)(nil)
+
+func NewVehicleStore(db *DB) *VehicleStore {
+	return &VehicleStore{db: db}
+}
+
+func (s *VehicleStore) CreateVehicle(ctx context.Context, v *model.Vehicle) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if err := s.checkDriverHelperAssignable(v.DriverHelperID); err != nil {
+		return err
+	}
+
+	if err := v.Validate(); err != nil {
+		return err
+	}
+	if _, exists := s.db.vehicles[v.ID]; exists {
+		return fmt.Errorf("failed to insert vehicle: duplicate key value violates unique constraint \"vehicles_pkey\": %w", model.ErrConflict)
+	}
+	if s.vehicleNumberTaken(v.VehicleNumberCanonical, v.ID) {
+		return fmt.Errorf("vehicle with number %s already exists: %w", v.VehicleNumber, model.ErrConflict)
+	}
+
+	row := *v
+	row.DeletedAt, row.DeletedBy, row.DeleteReason = nil, "", ""
+	row.CreatedAt = time.Now()
+	row.UpdatedAt = row.CreatedAt
+	row.Version = 1
+	s.db.vehicles[v.ID] = vehicleRow{seq: s.db.nextSeq(), v: row}
+	*v = row
+	return nil
+}
+
+func (s *VehicleStore) UpdateVehicle(ctx context.Context, v *model.Vehicle) error {
+	if err := v.Validate(); err != nil {
+		return err
+	}
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	existing, ok := s.db.vehicles[v.ID]
+	if !ok || existing.v.DeletedAt != nil {
+		return model.NotFoundError("vehicle", v.ID)
+	}
+	if v.DriverHelperID != existing.v.DriverHelperID {
+		if err := s.checkDriverHelperAssignable(v.DriverHelperID); err != nil {
+			return err
+		}
+	}
+	if err := checkVersion("vehicle", v.ID, existing.v.Version, v.Version); err != nil {
+		return err
+	}
+	if s.vehicleNumberTaken(v.VehicleNumberCanonical, v.ID) {
+		return fmt.Errorf("vehicle with number %s already exists: %w", v.VehicleNumber, model.ErrConflict)
+	}
+
+	row := *v
+	row.CreatedAt = existing.v.CreatedAt
+	row.UpdatedAt = time.Now()
+	row.Version = existing.v.Version + 1
+	row.DeletedAt, row.DeletedBy, row.DeleteReason = nil, "", ""
+	s.db.vehicles[v.ID] = vehicleRow{seq: existing.seq, v: row}
+	*v = row
+	return nil
+}
+
+func (s *VehicleStore) PatchVehicle(ctx context.Context, v *model.Vehicle, columns []string) error {
+	if err := model.ValidatePatchColumns(v, columns); err != nil {
+		return err
+	}
+	if err := v.ValidateColumns(columns); err != nil {
+		return err
+	}
+	if slices.Contains(columns, "vehicle_number") {
+		columns = append(slices.Clone(columns), "vehicle_number_canonical")
+	}
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	existing, ok := s.db.vehicles[v.ID]
+	if !ok || existing.v.DeletedAt != nil {
+		return model.NotFoundError("vehicle", v.ID)
+	}
+	if err := checkVersion("vehicle", v.ID, existing.v.Version, v.Version); err != nil {
+		return err
+	}
+	if slices.Contains(columns, "driver_helper_id") {
+		if err := s.checkDriverHelperAssignable(v.DriverHelperID); err != nil {
+			return err
+		}
+	}
+	if slices.Contains(columns, "vehicle_number") && s.vehicleNumberTaken(v.VehicleNumberCanonical, v.ID) {
+		return fmt.Errorf("vehicle with number %s already exists: %w", v.VehicleNumber, model.ErrConflict)
+	}
+
+	row := existing.v
+	model.CopyColumns(&row, v, columns)
+	row.UpdatedAt = time.Now()
+	row.Version++
+	s.db.vehicles[v.ID] = vehicleRow{seq: existing.seq, v: row}
+	*v = row
+	return nil
+}
+
+func (s *VehicleStore) DeleteVehicle(ctx context.Context, id uuid.UUID, expectedVersion int, reason string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	row, ok := s.db.vehicles[id]
+	if !ok || row.v.DeletedAt != nil {
+		return model.NotFoundError("vehicle", id)
+	}
+	if err := checkVersion("vehicle", id, row.v.Version, expectedVersion); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	row.v.DeletedAt, row.v.DeletedBy, row.v.DeleteReason = &now, model.Actor(ctx), reason
+	row.v.UpdatedAt = now
+	row.v.Version++
+	s.db.vehicles[id] = row
+	return nil
+}
+
+func (s *VehicleStore) RestoreVehicle(ctx context.Context, id uuid.UUID) (model.Vehicle, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	row, ok := s.db.vehicles[id]
+	if !ok {
+		return model.Vehicle{}, model.NotFoundError("vehicle", id)
+	}
+	if row.v.DeletedAt == nil {
+		return row.v, model.NotDeletedError("vehicle", id)
+	}
+	if s.vehicleNumberTaken(row.v.VehicleNumberCanonical, id) {
+		return row.v, fmt.Errorf("vehicle number of vehicle %s has been reused by another vehicle: %w", id, model.ErrConflict)
+	}
+
+	row.v.DeletedAt, row.v.DeletedBy, row.v.DeleteReason = nil, "", ""
+	row.v.UpdatedAt = time.Now()
+	row.v.Version++
+	s.db.vehicles[id] = row
+	return row.v, nil
+}
+
+func (s *VehicleStore) PurgeDeletedVehicles(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	var ids []uuid.UUID
+	for id, row := range s.db.vehicles {
+		if row.v.DeletedAt != nil && row.v.DeletedAt.Before(deletedBefore) {
+			delete(s.db.vehicles, id)
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (s *VehicleStore) Vehicles(ctx context.Context, filter model.VehicleFilter) (model.Page[model.Vehicle], error) {
+	if err := filter.Normalize(); err != nil {
+		return model.Page[model.Vehicle]{}, err
+	}
+
+	vehicles := s.selectWhere(ctx, func(v model.Vehicle) bool {
+		switch {
+		case filter.RouteNumber != "" && v.RouteNumber != filter.RouteNumber,
+			filter.MinSeatsAvailable != nil && v.SeatsAvailable < *filter.MinSeatsAvailable,
+			filter.MaxSeatsAvailable != nil && v.SeatsAvailable > *filter.MaxSeatsAvailable:
+			return false
+		}
+		return true
+	})
+	return paginate(vehicles, filter.ListOptions, model.Vehicle.SortKey, func(v model.Vehicle) uuid.UUID { return v.ID })
+}
+
+func (s *VehicleStore) VehicleByID(ctx context.Context, id uuid.UUID) (model.Vehicle, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	row, ok := s.db.vehicles[id]
+	if !ok || (row.v.DeletedAt != nil && !model.IncludesDeleted(ctx)) {
+		return model.Vehicle{}, model.NotFoundError("vehicle", id)
+	}
+	return row.v, nil
+}
+
+func (s *VehicleStore) VehiclesByDriverHelperID(ctx context.Context, driverHelperID uuid.UUID) ([]model.Vehicle, error) {
+	return s.selectWhere(ctx, func(v model.Vehicle) bool { return v.DriverHelperID == driverHelperID }), nil
+}
+
+func (s *VehicleStore) VehiclesByRouteNumber(ctx context.Context, routeNumber string) ([]model.Vehicle, error) {
+	return s.selectWhere(ctx, func(v model.Vehicle) bool { return v.RouteNumber == routeNumber }), nil
+}
+
+func (s *VehicleStore) VehicleByNumber(ctx context.Context, number string) (model.Vehicle, error) {
+	canonical, err := model.CanonicalVehicleNumber(number)
+	if err != nil {
+		return model.Vehicle{}, err
+	}
+
+	vehicles := s.selectWhere(ctx, func(v model.Vehicle) bool { return v.VehicleNumberCanonical == canonical })
+	if len(vehicles) == 0 {
+		return model.Vehicle{}, fmt.Errorf("vehicle with number %s %w", number, model.ErrNotFound)
+	}
+	// Deleted vehicles may share the number; prefer the current one, then the most recently deleted.
+	sort.SliceStable(vehicles, func(i, j int) bool {
+		a, b := vehicles[i].DeletedAt, vehicles[j].DeletedAt
+		return a == nil && b != nil || a != nil && b != nil && a.After(*b)
+	})
+	return vehicles[0], nil
+}
+
+func (s *VehicleStore) ExpiredCertificatesVehicles(ctx context.Context) ([]model.Vehicle, error) {
+	now := time.Now()
+	return s.selectWhere(ctx, func(v model.Vehicle) bool {
+		return validation.Expired(v.InsuranceExpiryDate, now) || validation.Expired(v.PollutionCertificateExpiryDate, now) ||
+			validation.Expired(v.FitnessCertificateExpiryDate, now)
+	}), nil
+}
+
+// checkDriverHelperAssignable must be called with s.db.mu held; uuid.Nil means no driver/helper is assigned.
+func (s *VehicleStore) checkDriverHelperAssignable(id uuid.UUID) error {
+	if id == uuid.Nil {
+		return nil
+	}
+	row, exists := s.db.driverHelpers[id]
+	if !exists || row.dh.DeletedAt != nil {
+		return fmt.Errorf("driver helper with ID %s does not exist: %w", id, model.ErrForeignKey)
+	}
+	return row.dh.CheckAssignable(time.Now())
+}
+
+// vehicleNumberTaken must be called with s.db.mu held. Like the partial unique
+// index in Postgres, it compares canonical numbers and ignores deleted vehicles.
+func (s *VehicleStore) vehicleNumberTaken(canonical string, except uuid.UUID) bool {
+	for id, row := range s.db.vehicles {
+		if id != except && row.v.DeletedAt == nil && row.v.VehicleNumberCanonical == canonical {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *VehicleStore) selectWhere(ctx context.Context, match func(model.Vehicle) bool) []model.Vehicle {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	includeDeleted := model.IncludesDeleted(ctx)
+	var rows []vehicleRow
+	for _, row := range s.db.vehicles {
+		if (includeDeleted || row.v.DeletedAt == nil) && match(row.v) {
+			rows = append(rows, row)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].seq < rows[j].seq })
+
+	var vehicles []model.Vehicle
+	for _, row := range rows {
+		vehicles = append(vehicles, row.v)
+	}
+	return vehicles
+}
@@ -0,0 +1,131 @@
+package memstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+func newTestVehicle(number string, driverHelperID uuid.UUID) model.Vehicle {
+	valid := time.Now().AddDate(1, 0, 0)
+	return model.Vehicle{VehicleNumber: number, RouteNumber: "R1", TotalStudentsCapacity: 30, SeatsAvailable: 30,
+		DriverHelperID: driverHelperID, InsuranceExpiryDate: valid, PollutionCertificateExpiryDate: valid, FitnessCertificateExpiryDate: valid}
+}
+
+func TestUpdateVehicleKeepsDriverWithLapsedLicense(t *testing.T) {
+	ctx := context.Background()
+	db := NewDB()
+	drivers, vehicles := NewDriverHelperStore(db), NewVehicleStore(db)
+
+	newDriver := func(aadhaar, mobile, license string) model.DriverHelper {
+		t.Helper()
+		expiry := time.Now().AddDate(1, 0, 0)
+		dh := model.DriverHelper{UserType: "Driver", FirstName: "Ravi", BloodGroup: "O+", AadharNumber: aadhaar,
+			MobileNumber: mobile, LicenseNumber: license, LicenseExpiryDate: &expiry, PoliceVerification: "No"}
+		if err := drivers.CreateDriverHelper(ctx, &dh); err != nil {
+			t.Fatalf("CreateDriverHelper: %v", err)
+		}
+		return dh
+	}
+	lapse := func(dh model.DriverHelper) {
+		expired := time.Now().AddDate(0, 0, -2)
+		row := db.driverHelpers[dh.ID]
+		row.dh.LicenseExpiryDate = &expired
+		db.driverHelpers[dh.ID] = row
+	}
+	assigned := newDriver("234567890124", "9876543210", "MH1220110012345")
+	other := newDriver("345678901238", "9876543211", "MH1220110012346")
+
+	v := model.Vehicle{VehicleNumber: "DL1PC0001", RouteNumber: "R1", TotalStudentsCapacity: 30, SeatsAvailable: 30,
+		DriverHelperID: assigned.ID, InsuranceExpiryDate: time.Now().AddDate(1, 0, 0),
+		PollutionCertificateExpiryDate: time.Now().AddDate(1, 0, 0), FitnessCertificateExpiryDate: time.Now().AddDate(1, 0, 0)}
+	if err := vehicles.CreateVehicle(ctx, &v); err != nil {
+		t.Fatalf("CreateVehicle: %v", err)
+	}
+	lapse(assigned)
+	lapse(other)
+
+	v.RouteNumber = "R2"
+	if err := vehicles.UpdateVehicle(ctx, &v); err != nil {
+		t.Fatalf("UpdateVehicle keeping the assigned driver: %v", err)
+	}
+
+	v.DriverHelperID = other.ID
+	if err := vehicles.UpdateVehicle(ctx, &v); !errors.Is(err, model.ErrValidation) {
+		t.Fatalf("UpdateVehicle assigning a driver with a lapsed license = %v, want a validation error", err)
+	}
+}
+
+func TestSoftDeleteRestoreAndPurgeVehicle(t *testing.T) {
+	ctx := model.WithActor(context.Background(), "clerk")
+	db := NewDB()
+	store := NewVehicleStore(db)
+	v := newTestVehicle("DL1PC0001", uuid.Nil)
+	if err := store.CreateVehicle(ctx, &v); err != nil {
+		t.Fatalf("CreateVehicle: %v", err)
+	}
+
+	if err := store.DeleteVehicle(ctx, v.ID, v.Version+1, "sold"); !errors.Is(err, model.ErrPreconditionFailed) {
+		t.Fatalf("DeleteVehicle at a stale version = %v, want %v", err, model.ErrPreconditionFailed)
+	}
+	if err := store.DeleteVehicle(ctx, v.ID, v.Version, "sold"); err != nil {
+		t.Fatalf("DeleteVehicle: %v", err)
+	}
+	if _, err := store.VehicleByID(ctx, v.ID); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("VehicleByID of a deleted vehicle = %v, want %v", err, model.ErrNotFound)
+	}
+	if _, err := store.VehicleByNumber(ctx, "DL 1P C 0001"); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("VehicleByNumber of a deleted vehicle = %v, want %v", err, model.ErrNotFound)
+	}
+	if page, _ := store.Vehicles(ctx, model.VehicleFilter{}); len(page.Items) != 0 {
+		t.Errorf("Vehicles = %+v, want the deleted vehicle left out", page.Items)
+	}
+	deleted, err := store.VehicleByID(model.WithDeleted(ctx), v.ID)
+	if err != nil {
+		t.Fatalf("VehicleByID including deleted rows: %v", err)
+	}
+	if deleted.DeletedAt == nil || deleted.DeletedBy != "clerk" || deleted.DeleteReason != "sold" || deleted.Version != v.Version+1 {
+		t.Errorf("deleted vehicle = %+v, want it marked deleted by clerk at version %d", deleted, v.Version+1)
+	}
+
+	restored, err := store.RestoreVehicle(ctx, v.ID)
+	if err != nil {
+		t.Fatalf("RestoreVehicle: %v", err)
+	}
+	if restored.DeletedAt != nil || restored.DeleteReason != "" || restored.Version != v.Version+2 {
+		t.Errorf("restored vehicle = %+v, want it undeleted at version %d", restored, v.Version+2)
+	}
+	if _, err := store.RestoreVehicle(ctx, v.ID); !errors.Is(err, model.ErrConflict) {
+		t.Errorf("RestoreVehicle of a live vehicle = %v, want %v", err, model.ErrConflict)
+	}
+
+	if err := store.DeleteVehicle(ctx, v.ID, 0, "sold"); err != nil {
+		t.Fatalf("DeleteVehicle: %v", err)
+	}
+	reuse := newTestVehicle("DL-1PC-0001", uuid.Nil)
+	if err := store.CreateVehicle(ctx, &reuse); err != nil {
+		t.Fatalf("CreateVehicle reusing a deleted vehicle's number: %v", err)
+	}
+	if _, err := store.RestoreVehicle(ctx, v.ID); !errors.Is(err, model.ErrConflict) {
+		t.Errorf("RestoreVehicle whose number was reused = %v, want %v", err, model.ErrConflict)
+	}
+
+	if ids, err := store.PurgeDeletedVehicles(ctx, time.Now().Add(-time.Hour)); err != nil || len(ids) != 0 {
+		t.Errorf("PurgeDeletedVehicles before the deletion = %v, %v; want nothing purged", ids, err)
+	}
+	ids, err := store.PurgeDeletedVehicles(ctx, time.Now().Add(time.Hour))
+	if err != nil || len(ids) != 1 || ids[0] != v.ID {
+		t.Fatalf("PurgeDeletedVehicles = %v, %v; want [%s]", ids, err, v.ID)
+	}
+	if _, err := store.VehicleByID(model.WithDeleted(ctx), v.ID); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("VehicleByID of a purged vehicle = %v, want %v", err, model.ErrNotFound)
+	}
+	if _, err := store.VehicleByID(ctx, reuse.ID); err != nil {
+		t.Errorf("a live vehicle was purged: %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+type WebhookStore struct {
+	db *DB
+}
+
+var _ model.WebhookStore = (*WebhookStore)(nil)
+
+func NewWebhookStore(db *DB) *WebhookStore {
+	return &WebhookStore{db: db}
+}
+
+func (s *WebhookStore) CreateWebhook(ctx context.Context, sub *model.WebhookSubscription) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if slices.ContainsFunc(s.db.webhooks, func(w model.WebhookSubscription) bool { return w.ID == sub.ID }) {
+		return fmt.Errorf("webhook %s already exists: %w", sub.ID, model.ErrConflict)
+	}
+	sub.Events = slices.Clone(sub.Events)
+	s.db.webhooks = append(s.db.webhooks, *sub)
+	return nil
+}
+
+func (s *WebhookStore) Webhooks(ctx context.Context) ([]model.WebhookSubscription, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return slices.Clone(s.db.webhooks), nil
+}
+
+func (s *WebhookStore) WebhookByID(ctx context.Context, id uuid.UUID) (model.WebhookSubscription, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	i := slices.IndexFunc(s.db.webhooks, func(w model.WebhookSubscription) bool { return w.ID == id })
+	if i < 0 {
+		return model.WebhookSubscription{}, model.NotFoundError("webhook", id)
+	}
+	return s.db.webhooks[i], nil
+}
+
+// DeleteWebhook cascades to the subscription's deliveries and their attempts, as the foreign keys do in Postgres.
+func (s *WebhookStore) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	i := slices.IndexFunc(s.db.webhooks, func(w model.WebhookSubscription) bool { return w.ID == id })
+	if i < 0 {
+		return model.NotFoundError("webhook", id)
+	}
+	s.db.webhooks = slices.Delete(s.db.webhooks, i, i+1)
+
+	deleted := make(map[uuid.UUID]bool)
+	s.db.webhookDeliveries = slices.DeleteFunc(s.db.webhookDeliveries, func(d model.WebhookDelivery) bool {
+		deleted[d.ID] = d.SubscriptionID == id
+		return deleted[d.ID]
+	})
+	s.db.webhookAttempts = slices.DeleteFunc(s.db.webhookAttempts, func(a model.WebhookAttempt) bool {
+		return deleted[a.DeliveryID]
+	})
+	return nil
+}
+
+func (s *WebhookStore) EnqueueDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, d := range deliveries {
+		if !slices.ContainsFunc(s.db.webhooks, func(w model.WebhookSubscription) bool { return w.ID == d.SubscriptionID }) {
+			return fmt.Errorf("webhook %s does not exist: %w", d.SubscriptionID, model.ErrForeignKey)
+		}
+	}
+	for _, d := range deliveries {
+		d.Payload = slices.Clone(d.Payload)
+		s.db.webhookDeliveries = append(s.db.webhookDeliveries, d)
+	}
+	return nil
+}
+
+func (s *WebhookStore) ClaimDueDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]model.WebhookDelivery, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	var due []int
+	for i, d := range s.db.webhookDeliveries {
+		if d.Status == model.DeliveryPending && d.NextAttemptAt != nil && !d.NextAttemptAt.After(now) {
+			due = append(due, i)
+		}
+	}
+	slices.SortStableFunc(due, func(a, b int) int {
+		return s.db.webhookDeliveries[a].NextAttemptAt.Compare(*s.db.webhookDeliveries[b].NextAttemptAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]model.WebhookDelivery, 0, len(due))
+	for _, i := range due {
+		d := &s.db.webhookDeliveries[i]
+		lease := leaseUntil
+		d.NextAttemptAt, d.UpdatedAt = &lease, now
+		claimed = append(claimed, *d)
+	}
+	return claimed, nil
+}
+
+func (s *WebhookStore) RecordAttempt(ctx context.Context, d model.WebhookDelivery, attempt model.WebhookAttempt) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	i := slices.IndexFunc(s.db.webhookDeliveries, func(existing model.WebhookDelivery) bool { return existing.ID == d.ID })
+	if i < 0 {
+		return fmt.Errorf("webhook delivery %s does not exist: %w", d.ID, model.ErrForeignKey)
+	}
+	stored := &s.db.webhookDeliveries[i]
+	stored.Status, stored.Attempts, stored.NextAttemptAt = d.Status, d.Attempts, d.NextAttemptAt
+	stored.LastError, stored.DeliveredAt, stored.UpdatedAt = d.LastError, d.DeliveredAt, d.UpdatedAt
+	s.db.webhookAttempts = append(s.db.webhookAttempts, attempt)
+	return nil
+}
+
+func (s *WebhookStore) WebhookDeliveries(ctx context.Context, filter model.WebhookDeliveryFilter) ([]model.WebhookDelivery, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var deliveries []model.WebhookDelivery
+	for _, d := range s.db.webhookDeliveries {
+		if (filter.SubscriptionID == uuid.Nil || d.SubscriptionID == filter.SubscriptionID) &&
+			(filter.Status == "" || d.Status == filter.Status) {
+			deliveries = append(deliveries, d)
+		}
+	}
+	slices.SortStableFunc(deliveries, func(a, b model.WebhookDelivery) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID.String(), a.ID.String()))
+	})
+	if filter.Limit > 0 && len(deliveries) > filter.Limit {
+		deliveries = deliveries[:filter.Limit]
+	}
+	return deliveries, nil
+}
+
+func (s *WebhookStore) WebhookDeliveryByID(ctx context.Context, id uuid.UUID) (model.WebhookDelivery, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return s.deliveryByID(id)
+}
+
+// deliveryByID must be called with db.mu held.
+func (s *WebhookStore) deliveryByID(id uuid.UUID) (model.WebhookDelivery, error) {
+	i := slices.IndexFunc(s.db.webhookDeliveries, func(d model.WebhookDelivery) bool { return d.ID == id })
+	if i < 0 {
+		return model.WebhookDelivery{}, model.NotFoundError("webhook delivery", id)
+	}
+	return s.db.webhookDeliveries[i], nil
+}
+
+func (s *WebhookStore) WebhookAttempts(ctx context.Context, deliveryID uuid.UUID) ([]model.WebhookAttempt, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var attempts []model.WebhookAttempt
+	for _, a := range s.db.webhookAttempts {
+		if a.DeliveryID == deliveryID {
+			attempts = append(attempts, a)
+		}
+	}
+	return attempts, nil
+}
+
+func (s *WebhookStore) RequeueDelivery(ctx context.Context, id uuid.UUID, now time.Time) (model.WebhookDelivery, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	d, err := s.deliveryByID(id)
+	if err != nil {
+		return d, err
+	}
+	if d.Status != model.DeliveryDead {
+		return d, fmt.Errorf("webhook delivery with ID %s is %s, not dead: %w", id, d.Status, model.ErrConflict)
+	}
+	i := slices.IndexFunc(s.db.webhookDeliveries, func(existing model.WebhookDelivery) bool { return existing.ID == id })
+	d.Status, d.Attempts, d.NextAttemptAt, d.UpdatedAt = model.DeliveryPending, 0, &now, now
+	s.db.webhookDeliveries[i] = d
+	return d, nil
+}
@@ -0,0 +1,112 @@
+package memstore
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+// DB is the in-memory counterpart of the PostgreSQL database: it holds the
+// driver_helpers, vehicles, audit_log, api_keys, reminders_sent and webhook
+// "tables" shared by the stores in this package.
+type DB struct {
+	mu            sync.RWMutex
+	seq           int64
+	driverHelpers map[uuid.UUID]driverHelperRow
+	vehicles      map[uuid.UUID]vehicleRow
+	auditLog      []model.AuditEntry
+	apiKeys       []model.APIKey
+	remindersSent map[model.Reminder]time.Time
+
+	webhooks          []model.WebhookSubscription
+	webhookDeliveries []model.WebhookDelivery
+	webhookAttempts   []model.WebhookAttempt
+}
+
+// seq preserves insertion order so listings come back in the same order Postgres returns a heap scan.
+type driverHelperRow struct {
+	seq int64
+	dh  model.DriverHelper
+}
+
+type vehicleRow struct {
+	seq int64
+	v   model.Vehicle
+}
+
+func NewDB() *DB {
+	return &DB{
+		driverHelpers: make(map[uuid.UUID]driverHelperRow),
+		vehicles:      make(map[uuid.UUID]vehicleRow),
+		remindersSent: make(map[model.Reminder]time.Time),
+	}
+}
+
+func (db *DB) nextSeq() int64 {
+	db.seq++
+	return db.seq
+}
+
+// checkVersion mirrors the conditional writes of the Postgres stores: a
+// non-zero expected version must match the stored one.
+func checkVersion(entity string, id uuid.UUID, stored, expected int) error {
+	if expected != 0 && expected != stored {
+		return model.PreconditionFailedError(entity, id, expected)
+	}
+	return nil
+}
+
+func NewStores(db *DB) model.Stores {
+	return model.Stores{
+		DriverHelpers: NewDriverHelperStore(db),
+		Vehicles:      NewVehicleStore(db),
+		Audit:         NewAuditStore(db),
+		Webhooks:      NewWebhookStore(db),
+	}
+}
+
+// UnitOfWork gives each transaction a private copy of the DB and writes it
+// back on success. The DB stays locked meanwhile, so transactions are
+// serialized and never need a retry.
+type UnitOfWork struct {
+	db *DB
+}
+
+var _ model.UnitOfWork = (*UnitOfWork)(nil)
+
+func NewUnitOfWork(db *DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+func (u *UnitOfWork) WithTx(ctx context.Context, fn func(tx model.Stores) error) error {
+	u.db.mu.Lock()
+	defer u.db.mu.Unlock()
+
+	tx := u.db.copy()
+	if err := fn(NewStores(tx)); err != nil {
+		return err
+	}
+	u.db.seq, u.db.driverHelpers, u.db.vehicles, u.db.auditLog = tx.seq, tx.driverHelpers, tx.vehicles, tx.auditLog
+	u.db.webhooks, u.db.webhookDeliveries, u.db.webhookAttempts = tx.webhooks, tx.webhookDeliveries, tx.webhookAttempts
+	return nil
+}
+
+// copy must be called with db.mu held.
+func (db *DB) copy() *DB {
+	return &DB{
+		seq:           db.seq,
+		driverHelpers: maps.Clone(db.driverHelpers),
+		vehicles:      maps.Clone(db.vehicles),
+		auditLog:      slices.Clone(db.auditLog),
+
+		webhooks:          slices.Clone(db.webhooks),
+		webhookDeliveries: slices.Clone(db.webhookDeliveries),
+		webhookAttempts:   slices.Clone(db.webhookAttempts),
+	}
+}
@@ -0,0 +1,92 @@
+package memstore
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+func TestUnitOfWorkRollsBackOnError(t *testing.T) {
+	ctx := context.Background()
+	db := NewDB()
+	dh := newTestDriver("234567890124", "9876543210", "MH1220110012345")
+	if err := NewDriverHelperStore(db).CreateDriverHelper(ctx, &dh); err != nil {
+		t.Fatalf("CreateDriverHelper: %v", err)
+	}
+	sub := model.WebhookSubscription{ID: uuid.New(), URL: "https://erp.example/hooks", Secret: "s", Events: model.EventFilter{"*"}}
+	if err := NewWebhookStore(db).CreateWebhook(ctx, &sub); err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+	before := db.copy()
+
+	// writeAll touches every table, then fails with err.
+	writeAll := func(err error) func(tx model.Stores) error {
+		return func(tx model.Stores) error {
+			update := dh
+			update.FirstName = "Ravindra"
+			if err := tx.DriverHelpers.UpdateDriverHelper(ctx, &update); err != nil {
+				return err
+			}
+			v := newTestVehicle("DL1PC0001", dh.ID)
+			if err := tx.Vehicles.CreateVehicle(ctx, &v); err != nil {
+				return err
+			}
+			entry := model.AuditEntry{ID: uuid.New(), EntityType: model.EntityVehicle, EntityID: v.ID, Operation: model.OperationCreate, CreatedAt: time.Now()}
+			if err := tx.Audit.RecordAudit(ctx, &entry); err != nil {
+				return err
+			}
+			delivery := model.WebhookDelivery{ID: uuid.New(), SubscriptionID: sub.ID, EventID: uuid.New(), EventType: "vehicle.created", Payload: model.RawJSON(`{}`)}
+			if err := tx.Webhooks.EnqueueDeliveries(ctx, []model.WebhookDelivery{delivery}); err != nil {
+				return err
+			}
+			if err := tx.Webhooks.DeleteWebhook(ctx, sub.ID); err != nil {
+				return err
+			}
+			return err
+		}
+	}
+
+	boom := errors.New("boom")
+	uow := NewUnitOfWork(db)
+	if err := uow.WithTx(ctx, writeAll(boom)); !errors.Is(err, boom) {
+		t.Fatalf("WithTx = %v, want %v", err, boom)
+	}
+	if !reflect.DeepEqual(db.copy(), before) {
+		t.Fatalf("a failed unit of work changed the DB:\n got %+v\nwant %+v", db.copy(), before)
+	}
+
+	// A failed write inside fn is rolled back with the writes before it.
+	err := uow.WithTx(ctx, func(tx model.Stores) error {
+		update := dh
+		update.FirstName = "Ravindra"
+		if err := tx.DriverHelpers.UpdateDriverHelper(ctx, &update); err != nil {
+			return err
+		}
+		duplicate := newTestDriver("234567890124", "9876543211", "MH1220110012346")
+		return tx.DriverHelpers.CreateDriverHelper(ctx, &duplicate)
+	})
+	if !errors.Is(err, model.ErrConflict) {
+		t.Fatalf("WithTx = %v, want %v", err, model.ErrConflict)
+	}
+	if !reflect.DeepEqual(db.copy(), before) {
+		t.Fatalf("a unit of work with a failed write changed the DB:\n got %+v\nwant %+v", db.copy(), before)
+	}
+
+	if err := uow.WithTx(ctx, writeAll(nil)); err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+	stored, err := NewDriverHelperStore(db).DriverHelperByID(ctx, dh.ID)
+	if err != nil || stored.FirstName != "Ravindra" {
+		t.Errorf("DriverHelperByID after commit = %+v, %v; want the update", stored, err)
+	}
+	if len(db.vehicles) != 1 || len(db.auditLog) != 1 || len(db.webhooks) != 0 {
+		t.Errorf("committed DB has %d vehicles, %d audit entries and %d webhooks; want 1, 1 and 0",
+			len(db.vehicles), len(db.auditLog), len(db.webhooks))
+	}
+}
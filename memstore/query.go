@@ -0,0 +1,57 @@
+package memstore
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+// paginate sorts rows the way the keyset queries in controllers do (sort
+// column, then id) and cuts out the page following opts.Cursor.
+func paginate[T any](rows []T, opts model.ListOptions, sortKey func(T, string) any, idOf func(T) uuid.UUID) (model.Page[T], error) {
+	page := model.Page[T]{Total: len(rows)}
+
+	compare := func(a, b T) int {
+		if c := model.CompareSortKeys(sortKey(a, opts.Sort), sortKey(b, opts.Sort)); c != 0 {
+			return c
+		}
+		ida, idb := idOf(a), idOf(b)
+		return bytes.Compare(ida[:], idb[:])
+	}
+	if opts.Descending() {
+		asc := compare
+		compare = func(a, b T) int { return -asc(a, b) }
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return compare(rows[i], rows[j]) < 0 })
+
+	if opts.Cursor != "" {
+		var zero T
+		value, id, err := model.DecodeCursor(opts.Cursor, sortKey(zero, opts.Sort))
+		if err != nil {
+			return page, model.NewFieldError("cursor", "invalid cursor for sort %s", opts.Sort)
+		}
+		start := sort.Search(len(rows), func(i int) bool {
+			c := model.CompareSortKeys(sortKey(rows[i], opts.Sort), value)
+			if c == 0 {
+				rid := idOf(rows[i])
+				c = bytes.Compare(rid[:], id[:])
+			}
+			if opts.Descending() {
+				c = -c
+			}
+			return c > 0
+		})
+		rows = rows[start:]
+	}
+
+	if len(rows) > opts.Limit {
+		rows = rows[:opts.Limit]
+		last := rows[len(rows)-1]
+		page.NextCursor = model.EncodeCursor(sortKey(last, opts.Sort), idOf(last))
+	}
+	page.Items = rows
+	return page, nil
+}
@@ -0,0 +1,91 @@
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+func TestDriverHelpersPaginateAcrossPages(t *testing.T) {
+	ctx := context.Background()
+	store := NewDriverHelperStore(NewDB())
+
+	soon := time.Date(2027, 1, 31, 0, 0, 0, 0, time.UTC)
+	later := time.Date(2028, 6, 30, 0, 0, 0, 0, time.UTC)
+	aadhaars := []string{"234567890124", "345678901238", "456789012341", "567890123458", "678901234560", "789012345674", "876543210988"}
+	expiries := []*time.Time{nil, &soon, nil, &later, &soon, nil, &soon}
+	var created []model.DriverHelper
+	for i, aadhaar := range aadhaars {
+		dh := newTestDriver(aadhaar, fmt.Sprintf("987654321%d", i), fmt.Sprintf("MH122011001234%d", i))
+		if expiries[i] == nil {
+			dh.UserType, dh.LicenseNumber = "Helper", ""
+		}
+		dh.LicenseExpiryDate = expiries[i]
+		if err := store.CreateDriverHelper(ctx, &dh); err != nil {
+			t.Fatalf("CreateDriverHelper: %v", err)
+		}
+		created = append(created, dh)
+	}
+
+	// Helpers without a license sort first, as if it expired at the zero time; id breaks ties.
+	expiryOf := func(dh model.DriverHelper) time.Time {
+		if dh.LicenseExpiryDate == nil {
+			return time.Time{}
+		}
+		return *dh.LicenseExpiryDate
+	}
+	want := slices.Clone(created)
+	slices.SortFunc(want, func(a, b model.DriverHelper) int {
+		if c := expiryOf(a).Compare(expiryOf(b)); c != 0 {
+			return c
+		}
+		return bytes.Compare(a.ID[:], b.ID[:])
+	})
+
+	for _, order := range []string{"asc", "desc"} {
+		for _, limit := range []int{1, 2, 3, 7, 8} {
+			var got []uuid.UUID
+			filter := model.DriverHelperFilter{ListOptions: model.ListOptions{Limit: limit, Sort: "license_expiry_date", Order: order}}
+			for pages := 0; ; pages++ {
+				if pages > len(created) {
+					t.Fatalf("order %s, limit %d: pagination does not end", order, limit)
+				}
+				page, err := store.DriverHelpers(ctx, filter)
+				if err != nil {
+					t.Fatalf("order %s, limit %d: DriverHelpers: %v", order, limit, err)
+				}
+				if page.Total != len(created) {
+					t.Errorf("order %s, limit %d: Total = %d, want %d", order, limit, page.Total, len(created))
+				}
+				if len(page.Items) > limit {
+					t.Errorf("order %s, limit %d: page holds %d rows", order, limit, len(page.Items))
+				}
+				for _, dh := range page.Items {
+					got = append(got, dh.ID)
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				filter.Cursor = page.NextCursor
+			}
+
+			var wantIDs []uuid.UUID
+			for _, dh := range want {
+				wantIDs = append(wantIDs, dh.ID)
+			}
+			if order == "desc" {
+				slices.Reverse(wantIDs)
+			}
+			if !slices.Equal(got, wantIDs) {
+				t.Errorf("order %s, limit %d: paged through %v, want %v", order, limit, got, wantIDs)
+			}
+		}
+	}
+}
@@ -0,0 +1,12 @@
+-- One row per expiry reminder sent, so each is sent only once per channel
+-- however often and on however many replicas the reminder job runs.
+CREATE TABLE reminders_sent (
+    entity_type VARCHAR(32) NOT NULL,
+    entity_id UUID NOT NULL,
+    document VARCHAR(64) NOT NULL,
+    due_date DATE NOT NULL,
+    days_before INTEGER NOT NULL,
+    channel VARCHAR(32) NOT NULL,
+    sent_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (entity_type, entity_id, document, due_date, days_before, channel)
+);
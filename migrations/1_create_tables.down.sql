@@ -1,2 +1,8 @@
+-- vehicles references driver_helpers, so it goes first. Only the order and the
+-- enum types changed since this script was first written, and it used to fail,
+-- so no database depends on its old behaviour.
+DROP TABLE vehicles;
 DROP TABLE driver_helpers;
-DROP TABLE vehicles;
\ No newline at end of file
+DROP TYPE blood_group_enum;
+DROP TYPE police_verification_enum;
+DROP TYPE user_type_enum;
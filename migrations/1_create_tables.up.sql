@@ -43,7 +43,11 @@ CREATE TABLE vehicles (
     vehicle_number VARCHAR(20) NOT NULL UNIQUE,
     route_number VARCHAR(20),
     total_students_capacity INT NOT NULL CHECK (total_students_capacity > 0),
-    seats_available INT NOT NULL DEFAULT total_students_capacity CHECK (seats_available >= 0 AND seats_available <= total_students_capacity),
+    -- This once defaulted to total_students_capacity, which PostgreSQL rejects
+    -- ("cannot use column reference in DEFAULT expression"), so no database
+    -- ever reached version 1 with that script and editing it in place is safe.
+    -- The service always sends seats_available.
+    seats_available INT NOT NULL CHECK (seats_available >= 0 AND seats_available <= total_students_capacity),
     
     -- Driver/Helper Information
     driver_helper_id UUID REFERENCES driver_helpers(id) ON DELETE CASCADE,
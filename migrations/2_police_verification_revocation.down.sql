@@ -0,0 +1,3 @@
+ALTER TABLE driver_helpers
+    DROP COLUMN police_verification_revoked_at,
+    DROP COLUMN police_verification_revocation_reason;
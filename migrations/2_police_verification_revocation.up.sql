@@ -0,0 +1,3 @@
+ALTER TABLE driver_helpers
+    ADD COLUMN police_verification_revoked_at TIMESTAMP,
+    ADD COLUMN police_verification_revocation_reason VARCHAR(255) NOT NULL DEFAULT '';
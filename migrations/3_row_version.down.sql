@@ -0,0 +1,2 @@
+ALTER TABLE vehicles DROP COLUMN version;
+ALTER TABLE driver_helpers DROP COLUMN version;
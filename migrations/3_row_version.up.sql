@@ -0,0 +1,2 @@
+ALTER TABLE driver_helpers ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE vehicles ADD COLUMN version INT NOT NULL DEFAULT 1;
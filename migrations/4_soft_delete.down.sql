@@ -0,0 +1,20 @@
+DROP INDEX vehicles_deleted_at_idx;
+DROP INDEX driver_helpers_deleted_at_idx;
+
+DROP INDEX vehicles_vehicle_number_key;
+ALTER TABLE vehicles ADD CONSTRAINT vehicles_vehicle_number_key UNIQUE (vehicle_number);
+
+ALTER TABLE vehicles
+    DROP CONSTRAINT vehicles_driver_helper_id_fkey,
+    ADD CONSTRAINT vehicles_driver_helper_id_fkey
+        FOREIGN KEY (driver_helper_id) REFERENCES driver_helpers(id) ON DELETE CASCADE;
+
+ALTER TABLE vehicles
+    DROP COLUMN deleted_at,
+    DROP COLUMN deleted_by,
+    DROP COLUMN delete_reason;
+
+ALTER TABLE driver_helpers
+    DROP COLUMN deleted_at,
+    DROP COLUMN deleted_by,
+    DROP COLUMN delete_reason;
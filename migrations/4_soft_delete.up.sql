@@ -0,0 +1,22 @@
+ALTER TABLE driver_helpers
+    ADD COLUMN deleted_at TIMESTAMP,
+    ADD COLUMN deleted_by VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN delete_reason VARCHAR(255) NOT NULL DEFAULT '';
+
+ALTER TABLE vehicles
+    ADD COLUMN deleted_at TIMESTAMP,
+    ADD COLUMN deleted_by VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN delete_reason VARCHAR(255) NOT NULL DEFAULT '';
+
+-- Purging a driver/helper unassigns their vehicles instead of deleting them.
+ALTER TABLE vehicles
+    DROP CONSTRAINT vehicles_driver_helper_id_fkey,
+    ADD CONSTRAINT vehicles_driver_helper_id_fkey
+        FOREIGN KEY (driver_helper_id) REFERENCES driver_helpers(id) ON DELETE SET NULL;
+
+-- A deleted vehicle's number may be reused by a new vehicle.
+ALTER TABLE vehicles DROP CONSTRAINT vehicles_vehicle_number_key;
+CREATE UNIQUE INDEX vehicles_vehicle_number_key ON vehicles (vehicle_number) WHERE deleted_at IS NULL;
+
+CREATE INDEX driver_helpers_deleted_at_idx ON driver_helpers (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX vehicles_deleted_at_idx ON vehicles (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,13 @@
+CREATE TABLE audit_log (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    entity_type VARCHAR(20) NOT NULL,
+    entity_id UUID NOT NULL,
+    operation VARCHAR(20) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    -- [{"field": ..., "before": ..., "after": ...}]
+    changes JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- No foreign key to the audited tables: history must outlive purged rows.
+CREATE INDEX audit_log_entity_idx ON audit_log (entity_type, entity_id, created_at, id);
@@ -0,0 +1,10 @@
+DROP INDEX driver_helpers_license_number_bidx_key;
+DROP INDEX driver_helpers_aadhar_number_bidx_key;
+
+-- Fails while sealed values remain; decrypt them before migrating down.
+ALTER TABLE driver_helpers
+    DROP COLUMN license_number_bidx,
+    DROP COLUMN aadhar_number_bidx,
+    ALTER COLUMN emergency_contact_number TYPE VARCHAR(15),
+    ALTER COLUMN license_number TYPE VARCHAR(20),
+    ALTER COLUMN aadhar_number TYPE VARCHAR(12);
@@ -0,0 +1,13 @@
+-- Sealed values are much longer than the plaintext they replace.
+ALTER TABLE driver_helpers
+    ALTER COLUMN aadhar_number TYPE TEXT,
+    ALTER COLUMN license_number TYPE TEXT,
+    ALTER COLUMN emergency_contact_number TYPE TEXT,
+    ADD COLUMN aadhar_number_bidx BYTEA,
+    ADD COLUMN license_number_bidx BYTEA;
+
+-- Existing rows get their blind indexes from the reencrypt command; until
+-- they have them these indexes cannot see them, so the service refuses to
+-- start (see controllers.CheckBlindIndexes).
+CREATE UNIQUE INDEX driver_helpers_aadhar_number_bidx_key ON driver_helpers (aadhar_number_bidx) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX driver_helpers_license_number_bidx_key ON driver_helpers (license_number_bidx) WHERE deleted_at IS NULL;
@@ -0,0 +1,4 @@
+DROP INDEX vehicles_vehicle_number_canonical_key;
+CREATE UNIQUE INDEX vehicles_vehicle_number_key ON vehicles (vehicle_number) WHERE deleted_at IS NULL;
+
+ALTER TABLE vehicles DROP COLUMN vehicle_number_canonical;
@@ -0,0 +1,27 @@
+ALTER TABLE vehicles ADD COLUMN vehicle_number_canonical VARCHAR(20);
+
+-- Mirrors validation.CanonicalRegistration for existing rows. Numbers it
+-- cannot parse keep their upper-cased spelling without separators.
+UPDATE vehicles SET vehicle_number_canonical = UPPER(REGEXP_REPLACE(vehicle_number, '[\s-]', '', 'g'));
+UPDATE vehicles SET vehicle_number_canonical = m[1] || LPAD(m[2], 2, '0') || m[3] || LPAD(m[4], 4, '0')
+FROM (
+    SELECT id, REGEXP_MATCH(vehicle_number_canonical, '^([A-Z]{2})(\d{1,2})([A-Z]{0,3})(\d{1,4})$') AS m
+    FROM vehicles
+) parsed
+WHERE vehicles.id = parsed.id AND parsed.m IS NOT NULL
+    -- Without a series the digits only split one way when there are 2 or 6 of them.
+    AND (parsed.m[3] <> '' OR LENGTH(parsed.m[2] || parsed.m[4]) IN (2, 6));
+-- Other numbers without a series are split where they separate the RTO code from the number.
+UPDATE vehicles SET vehicle_number_canonical = m[1] || LPAD(m[2], 2, '0') || LPAD(m[3], 4, '0')
+FROM (
+    SELECT id, REGEXP_MATCH(UPPER(TRIM(vehicle_number)), '^([A-Z]{2})[ -]*(\d{1,2})[ -]+(\d{1,4})$') AS m
+    FROM vehicles
+) parsed
+WHERE vehicles.id = parsed.id AND parsed.m IS NOT NULL;
+
+ALTER TABLE vehicles ALTER COLUMN vehicle_number_canonical SET NOT NULL;
+
+-- Fails if two vehicles that are not deleted are spelled differently but
+-- have the same canonical number; delete or renumber one before migrating.
+DROP INDEX vehicles_vehicle_number_key;
+CREATE UNIQUE INDEX vehicles_vehicle_number_canonical_key ON vehicles (vehicle_number_canonical) WHERE deleted_at IS NULL;
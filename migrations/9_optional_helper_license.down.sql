@@ -0,0 +1,7 @@
+DROP INDEX driver_helpers_license_expiry_date_idx;
+
+-- Fails while helpers without a license expiry date remain.
+UPDATE driver_helpers SET license_number = '' WHERE license_number IS NULL;
+ALTER TABLE driver_helpers
+    ALTER COLUMN license_expiry_date SET NOT NULL,
+    ALTER COLUMN license_number SET NOT NULL;
@@ -0,0 +1,7 @@
+-- Helpers need no license; drivers are still required to have one by the service.
+ALTER TABLE driver_helpers
+    ALTER COLUMN license_number DROP NOT NULL,
+    ALTER COLUMN license_expiry_date DROP NOT NULL;
+
+CREATE INDEX driver_helpers_license_expiry_date_idx ON driver_helpers (license_expiry_date)
+    WHERE user_type = 'Driver' AND deleted_at IS NULL;
@@ -0,0 +1,163 @@
+// Package migrations embeds the versioned SQL schema files and applies them
+// with golang-migrate, so the service binary needs no migration sidecar.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+//go:embed *.sql
+var FS embed.FS
+
+// ErrSchemaDrift is returned by Status.Err when the database schema does not
+// match the migrations embedded in this binary.
+var ErrSchemaDrift = errors.New("schema drift")
+
+type Status struct {
+	Version  uint `json:"version"`
+	Dirty    bool `json:"dirty"`
+	Expected uint `json:"expected"`
+}
+
+func (s Status) UpToDate() bool {
+	return !s.Dirty && s.Version == s.Expected
+}
+
+// Runner applies the embedded migrations over a single dedicated connection.
+// golang-migrate holds a Postgres advisory lock while migrating, so concurrent
+// replicas starting with auto-migrate enabled wait for each other.
+type Runner struct {
+	m *migrate.Migrate
+}
+
+func NewRunner(ctx context.Context, db *sql.DB, lockTimeout time.Duration) (*Runner, error) {
+	src, err := iofs.New(FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded migrations: %w", err)
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire migration connection: %w", err)
+	}
+
+	// WithConnection (unlike WithInstance) leaves the shared *sql.DB open on Close.
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to initialise migration driver: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "postgres", driver)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to initialise migrations: %w", err)
+	}
+	if lockTimeout > 0 {
+		m.LockTimeout = lockTimeout
+	}
+
+	return &Runner{m: m}, nil
+}
+
+func (r *Runner) Close() error {
+	_, err := r.m.Close()
+	return err
+}
+
+func (r *Runner) Up() error {
+	return ignoreNoChange(r.m.Up())
+}
+
+// Down rolls back the most recently applied migration only.
+func (r *Runner) Down() error {
+	return ignoreNoChange(r.m.Steps(-1))
+}
+
+func (r *Runner) Goto(version uint) error {
+	return ignoreNoChange(r.m.Migrate(version))
+}
+
+func (r *Runner) Status() (Status, error) {
+	expected, err := LatestVersion()
+	if err != nil {
+		return Status{}, err
+	}
+
+	version, dirty, err := r.m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return Status{}, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return Status{Version: version, Dirty: dirty, Expected: expected}, nil
+}
+
+// Err reports ErrSchemaDrift unless the database is clean and at exactly the latest embedded version.
+func (s Status) Err() error {
+	switch {
+	case s.Dirty:
+		return fmt.Errorf("%w: database is dirty at version %d; fix it manually and run `migrate goto`", ErrSchemaDrift, s.Version)
+	case s.Version < s.Expected:
+		return fmt.Errorf("%w: database is at version %d but this binary expects %d; run `migrate up` or enable auto-migrate", ErrSchemaDrift, s.Version, s.Expected)
+	case s.Version > s.Expected:
+		return fmt.Errorf("%w: database is at version %d, newer than this binary's latest migration %d", ErrSchemaDrift, s.Version, s.Expected)
+	}
+	return nil
+}
+
+// CurrentStatus reads the applied version straight from golang-migrate's
+// schema_migrations table. Unlike Runner it needs no dedicated connection, so
+// it is cheap enough for readiness probes.
+func CurrentStatus(ctx context.Context, db *sql.DB) (Status, error) {
+	expected, err := LatestVersion()
+	if err != nil {
+		return Status{}, err
+	}
+
+	status := Status{Expected: expected}
+	err = db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&status.Version, &status.Dirty)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return status, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return status, nil
+}
+
+// LatestVersion returns the highest migration version embedded in the binary.
+func LatestVersion() (uint, error) {
+	src, err := iofs.New(FS, ".")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read embedded migrations: %w", err)
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read embedded migrations: %w", err)
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read embedded migrations: %w", err)
+		}
+		version = next
+	}
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,125 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/lib/pq"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/storetest"
+)
+
+// TestUpAndDown applies every embedded migration one at a time, rolls them all
+// back one at a time, checks no table or type they created is left and applies
+// them again. It runs in a schema of its own so other tests may share the database.
+func TestUpAndDown(t *testing.T) {
+	dsn := os.Getenv(storetest.DSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", storetest.DSNEnv)
+	}
+	ctx := context.Background()
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// A single connection, so the search_path set below is the runner's too.
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+
+	schema := fmt.Sprintf("migrations_test_%d", time.Now().UnixNano())
+	if _, err := db.ExecContext(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatal(err)
+	}
+	defer db.ExecContext(ctx, "DROP SCHEMA "+schema+" CASCADE")
+	if _, err := db.ExecContext(ctx, "SET search_path TO "+schema+", public"); err != nil {
+		t.Fatal(err)
+	}
+
+	latest, err := LatestVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner, err := NewRunner(ctx, db, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// version is 0 before the first migration is applied.
+	version := func() uint {
+		t.Helper()
+		v, dirty, err := runner.m.Version()
+		if errors.Is(err, migrate.ErrNilVersion) {
+			return 0
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dirty {
+			t.Fatalf("schema is dirty at version %d", v)
+		}
+		return v
+	}
+
+	for v := version(); v < latest; v = version() {
+		if err := runner.m.Steps(1); err != nil {
+			t.Fatalf("migrating up from version %d: %v", v, err)
+		}
+	}
+	for v := version(); v > 0; v = version() {
+		if err := runner.Down(); err != nil {
+			t.Fatalf("migrating down from version %d: %v", v, err)
+		}
+	}
+
+	if err := runner.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var leftovers []string
+	rows, err := db.QueryContext(ctx, `
+		SELECT 'table ' || table_name FROM information_schema.tables
+		WHERE table_schema = $1 AND table_name <> 'schema_migrations'
+		UNION ALL
+		SELECT 'type ' || t.typname FROM pg_type t JOIN pg_namespace n ON n.oid = t.typnamespace
+		WHERE n.nspname = $1 AND t.typtype = 'e'`, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		leftovers = append(leftovers, name)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+	if len(leftovers) != 0 {
+		t.Errorf("rolling back every migration left %v", leftovers)
+	}
+
+	if runner, err = NewRunner(ctx, db, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.Up(); err != nil {
+		t.Fatalf("migrating up again: %v", err)
+	}
+	if err := runner.Close(); err != nil {
+		t.Fatal(err)
+	}
+	status, err := CurrentStatus(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := status.Err(); err != nil {
+		t.Errorf("after migrating down and up again: %v", err)
+	}
+}
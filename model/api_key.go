@@ -0,0 +1,66 @@
+package model
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey lets a machine client call the API. Only a hash of the secret is
+// kept; Prefix is the non-secret part of the key used to look it up.
+type APIKey struct {
+	ID         uuid.UUID  `db:"id" json:"id"`
+	Name       string     `db:"name" json:"name"`
+	Prefix     string     `db:"prefix" json:"prefix"`
+	KeyHash    []byte     `db:"key_hash" json:"-"`
+	Scopes     Scopes     `db:"scopes" json:"scopes"`
+	ExpiresAt  *time.Time `db:"expires_at" json:"expires_at"`
+	LastUsedAt *time.Time `db:"last_used_at" json:"last_used_at"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revoked_at"`
+	CreatedBy  string     `db:"created_by" json:"created_by"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// Active reports whether k may still be used at now.
+func (k APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// Scopes is stored as a JSON array of permission names.
+type Scopes []string
+
+func (s Scopes) Value() (driver.Value, error) {
+	if s == nil {
+		s = Scopes{}
+	}
+	return json.Marshal(s)
+}
+
+func (s *Scopes) Scan(src any) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, s)
+	case string:
+		return json.Unmarshal([]byte(data), s)
+	case nil:
+		*s = nil
+		return nil
+	}
+	return errors.New("unsupported type for API key scopes")
+}
+
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, key *APIKey) error
+	APIKeys(ctx context.Context) ([]APIKey, error)
+	APIKeyByPrefix(ctx context.Context, prefix string) (APIKey, error)
+	// RotateAPIKey replaces the secret of an unrevoked key, keeping its ID, name and scopes.
+	RotateAPIKey(ctx context.Context, id uuid.UUID, prefix string, keyHash []byte) (APIKey, error)
+	// RevokeAPIKey disables a key for good; revoking it again is a conflict.
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) (APIKey, error)
+	TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
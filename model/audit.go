@@ -0,0 +1,117 @@
+package model
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EntityDriverHelper = "driver_helper"
+	EntityVehicle      = "vehicle"
+)
+
+const (
+	OperationCreate  = "create"
+	OperationUpdate  = "update"
+	OperationDelete  = "delete"
+	OperationRestore = "restore"
+	// OperationPurge records the permanent removal of a soft-deleted row once
+	// its retention window has passed.
+	OperationPurge = "purge"
+)
+
+// AuditEntry records one write to a driver/helper or vehicle: who made it,
+// when, and how each changed field went from Before to After.
+type AuditEntry struct {
+	ID         uuid.UUID `db:"id" json:"id"`
+	EntityType string    `db:"entity_type" json:"entity_type"`
+	EntityID   uuid.UUID `db:"entity_id" json:"entity_id"`
+	Operation  string    `db:"operation" json:"operation"`
+	Actor      string    `db:"actor" json:"actor"`
+	Changes    Changes   `db:"changes" json:"changes"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// Changes is stored as a JSON array.
+type Changes []FieldChange
+
+func (c Changes) Value() (driver.Value, error) {
+	if c == nil {
+		c = Changes{}
+	}
+	return json.Marshal(c)
+}
+
+func (c *Changes) Scan(src any) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, c)
+	case string:
+		return json.Unmarshal([]byte(data), c)
+	case nil:
+		*c = nil
+		return nil
+	}
+	return errors.New("unsupported type for audit changes")
+}
+
+type AuditFilter struct {
+	ListOptions
+	Operation string `form:"operation"`
+}
+
+var auditSortFields = map[string]bool{"created_at": true}
+
+func (f *AuditFilter) Normalize() error {
+	verr := &ValidationError{}
+	f.ListOptions.normalize(verr, auditSortFields)
+	switch f.Operation {
+	case "", OperationCreate, OperationUpdate, OperationDelete, OperationRestore, OperationPurge:
+	default:
+		verr.Add("operation", "invalid operation: %s", f.Operation)
+	}
+	return verr.Err()
+}
+
+func (e AuditEntry) SortKey(field string) any {
+	return ColumnValue(e, field)
+}
+
+type AuditStore interface {
+	RecordAudit(ctx context.Context, entry *AuditEntry) error
+	// AuditHistory lists the entries for one entity, oldest first by default.
+	AuditHistory(ctx context.Context, entityType string, entityID uuid.UUID, filter AuditFilter) (Page[AuditEntry], error)
+}
+
+// auditIgnoredColumns change on every write and would only add noise to a diff.
+var auditIgnoredColumns = map[string]bool{
+	"id": true, "created_at": true, "updated_at": true, "version": true,
+}
+
+// Diff lists the changed fields between two values of the same entity type,
+// leaving out bookkeeping columns. Diffing against the zero value describes a create.
+func Diff(before, after any) Changes {
+	changes := Changes{}
+	for _, column := range ChangedColumns(before, after) {
+		if auditIgnoredColumns[column] {
+			continue
+		}
+		changes = append(changes, FieldChange{
+			Field:  column,
+			Before: ColumnValue(before, column),
+			After:  ColumnValue(after, column),
+		})
+	}
+	return changes
+}
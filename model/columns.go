@@ -0,0 +1,100 @@
+package model
+
+import (
+	"reflect"
+	"time"
+)
+
+// readOnlyColumns are maintained by the stores and can never be changed through an update.
+var readOnlyColumns = map[string]bool{
+	"id": true, "created_at": true, "updated_at": true, "version": true,
+	"police_verification_revoked_at": true, "police_verification_revocation_reason": true,
+	"deleted_at": true, "deleted_by": true, "delete_reason": true,
+	"vehicle_number_canonical": true,
+}
+
+func IsReadOnlyColumn(column string) bool {
+	return readOnlyColumns[column]
+}
+
+// ColumnValue returns the field of entity (a DriverHelper or Vehicle) tagged db:"column", or nil.
+func ColumnValue(entity any, column string) any {
+	rv := reflect.Indirect(reflect.ValueOf(entity))
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		if rt.Field(i).Tag.Get("db") == column {
+			return rv.Field(i).Interface()
+		}
+	}
+	return nil
+}
+
+// ChangedColumns lists, in struct order, the db columns whose values differ between before and after,
+// which must be values of the same struct type.
+func ChangedColumns(before, after any) []string {
+	bv, av := reflect.ValueOf(before), reflect.ValueOf(after)
+	rt := bv.Type()
+
+	var columns []string
+	for i := 0; i < rt.NumField(); i++ {
+		column := rt.Field(i).Tag.Get("db")
+		if column == "" || column == "-" {
+			continue
+		}
+		if !valuesEqual(bv.Field(i).Interface(), av.Field(i).Interface()) {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// valuesEqual compares times by instant, ignoring location and monotonic clock readings.
+func valuesEqual(a, b any) bool {
+	switch x := a.(type) {
+	case time.Time:
+		return x.Equal(b.(time.Time))
+	case *time.Time:
+		y := b.(*time.Time)
+		if x == nil || y == nil {
+			return x == y
+		}
+		return x.Equal(*y)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// ValidatePatchColumns rejects columns that entity does not have or that are read-only.
+func ValidatePatchColumns(entity any, columns []string) error {
+	rt := reflect.Indirect(reflect.ValueOf(entity)).Type()
+	known := make(map[string]bool, rt.NumField())
+	for i := 0; i < rt.NumField(); i++ {
+		known[rt.Field(i).Tag.Get("db")] = true
+	}
+
+	verr := &ValidationError{}
+	for _, column := range columns {
+		switch {
+		case !known[column]:
+			verr.Add(column, "unknown field %s", column)
+		case IsReadOnlyColumn(column):
+			verr.Add(column, "%s is read-only", column)
+		}
+	}
+	return verr.Err()
+}
+
+// CopyColumns copies the fields of src tagged with the given columns onto dst;
+// both must point to the same struct type.
+func CopyColumns(dst, src any, columns []string) {
+	dv := reflect.ValueOf(dst).Elem()
+	sv := reflect.Indirect(reflect.ValueOf(src))
+	rt := dv.Type()
+	for _, column := range columns {
+		for i := 0; i < rt.NumField(); i++ {
+			if rt.Field(i).Tag.Get("db") == column {
+				dv.Field(i).Set(sv.Field(i))
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package model
+
+import "context"
+
+type contextKey int
+
+const (
+	includeDeletedKey contextKey = iota
+	actorKey
+)
+
+// WithDeleted returns a context whose store reads also return soft-deleted rows.
+func WithDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeDeletedKey, true)
+}
+
+func IncludesDeleted(ctx context.Context) bool {
+	include, _ := ctx.Value(includeDeletedKey).(bool)
+	return include
+}
+
+// WithActor records who is making the store writes done with the returned context.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// Actor returns the actor recorded by WithActor, or "" if there is none.
+func Actor(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey).(string)
+	return actor
+}
@@ -1,71 +1,132 @@
 package model
 
 import (
+	"context"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type DriverHelper struct {
-	ID                             uuid.UUID  `db:"id" json:"id"`
-	UserType                       string     `db:"user_type" json:"user_type"`
-	FirstName                      string     `db:"first_name" json:"first_name"`
-	LastName                       string     `db:"last_name" json:"last_name"`
-	MobileNumber                   string     `db:"mobile_number" json:"mobile_number"`
-	AadharNumber                   string     `db:"aadhar_number" json:"aadhar_number"`
-	LicenseNumber                  string     `db:"license_number" json:"license_number"`
-	LicenseExpiryDate              time.Time  `db:"license_expiry_date" json:"license_expiry_date"`
-	LicenseDocumentPath            string     `db:"license_document_path" json:"license_document_path"`
-	PoliceVerification             string     `db:"police_verification" json:"police_verification"`
-	PoliceVerificationDate         *time.Time `db:"police_verification_date" json:"police_verification_date"`
-	PoliceVerificationDocumentPath string     `db:"police_verification_document_path" json:"police_verification_document_path"`
-	AdditionalDocumentsPath        string     `db:"additional_documents_path" json:"additional_documents_path"`
-	BloodGroup                     string     `db:"blood_group" json:"blood_group"`
-	EmergencyContactName           string     `db:"emergency_contact_name" json:"emergency_contact_name"`
-	EmergencyContactNumber         string     `db:"emergency_contact_number" json:"emergency_contact_number"`
-	EmergencyContactRelation       string     `db:"emergency_contact_relation" json:"emergency_contact_relation"`
-	CreatedAt                      time.Time  `db:"created_at" json:"created_at"`
-	UpdatedAt                      time.Time  `db:"updated_at" json:"updated_at"`
+	ID                                 uuid.UUID  `db:"id" json:"id"`
+	UserType                           string     `db:"user_type" json:"user_type"`
+	FirstName                          string     `db:"first_name" json:"first_name"`
+	LastName                           string     `db:"last_name" json:"last_name"`
+	MobileNumber                       string     `db:"mobile_number" json:"mobile_number"`
+	AadharNumber                       string     `db:"aadhar_number" json:"aadhar_number"`
+	LicenseNumber                      string     `db:"license_number" json:"license_number"`
+	LicenseExpiryDate                  *time.Time `db:"license_expiry_date" json:"license_expiry_date"`
+	LicenseDocumentPath                string     `db:"license_document_path" json:"license_document_path"`
+	PoliceVerification                 string     `db:"police_verification" json:"police_verification"`
+	PoliceVerificationDate             *time.Time `db:"police_verification_date" json:"police_verification_date"`
+	PoliceVerificationDocumentPath     string     `db:"police_verification_document_path" json:"police_verification_document_path"`
+	PoliceVerificationRevokedAt        *time.Time `db:"police_verification_revoked_at" json:"police_verification_revoked_at"`
+	PoliceVerificationRevocationReason string     `db:"police_verification_revocation_reason" json:"police_verification_revocation_reason"`
+	AdditionalDocumentsPath            string     `db:"additional_documents_path" json:"additional_documents_path"`
+	BloodGroup                         string     `db:"blood_group" json:"blood_group"`
+	EmergencyContactName               string     `db:"emergency_contact_name" json:"emergency_contact_name"`
+	EmergencyContactNumber             string     `db:"emergency_contact_number" json:"emergency_contact_number"`
+	EmergencyContactRelation           string     `db:"emergency_contact_relation" json:"emergency_contact_relation"`
+	Version                            int        `db:"version" json:"version"`
+	DeletedAt                          *time.Time `db:"deleted_at" json:"deleted_at"`
+	DeletedBy                          string     `db:"deleted_by" json:"deleted_by"`
+	DeleteReason                       string     `db:"delete_reason" json:"delete_reason"`
+	CreatedAt                          time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt                          time.Time  `db:"updated_at" json:"updated_at"`
 }
 
 type Vehicle struct {
-	ID                             uuid.UUID `db:"id" json:"id"`
-	VehicleNumber                  string    `db:"vehicle_number" json:"vehicle_number"`
-	RouteNumber                    string    `db:"route_number" json:"route_number"`
-	TotalStudentsCapacity          int       `db:"total_students_capacity" json:"total_students_capacity"`
-	SeatsAvailable                 int       `db:"seats_available" json:"seats_available"`
-	DriverHelperID                 uuid.UUID `db:"driver_helper_id" json:"driver_helper_id"`
-	InsuranceNumber                string    `db:"insurance_number" json:"insurance_number"`
-	InsuranceExpiryDate            time.Time `db:"insurance_expiry_date" json:"insurance_expiry_date"`
-	PollutionCertificateNumber     string    `db:"pollution_certificate_number" json:"pollution_certificate_number"`
-	PollutionCertificateExpiryDate time.Time `db:"pollution_certificate_expiry_date" json:"pollution_certificate_expiry_date"`
-	FitnessCertificateNumber       string    `db:"fitness_certificate_number" json:"fitness_certificate_number"`
-	FitnessCertificateExpiryDate   time.Time `db:"fitness_certificate_expiry_date" json:"fitness_certificate_expiry_date"`
-	VehicleDocumentPath            string    `db:"vehicle_document_path" json:"vehicle_document_path"`
-	CreatedAt                      time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt                      time.Time `db:"updated_at" json:"updated_at"`
+	ID                             uuid.UUID  `db:"id" json:"id"`
+	VehicleNumber                  string     `db:"vehicle_number" json:"vehicle_number"`
+	VehicleNumberCanonical         string     `db:"vehicle_number_canonical" json:"vehicle_number_canonical"`
+	RouteNumber                    string     `db:"route_number" json:"route_number"`
+	TotalStudentsCapacity          int        `db:"total_students_capacity" json:"total_students_capacity"`
+	SeatsAvailable                 int        `db:"seats_available" json:"seats_available"`
+	DriverHelperID                 uuid.UUID  `db:"driver_helper_id" json:"driver_helper_id"`
+	InsuranceNumber                string     `db:"insurance_number" json:"insurance_number"`
+	InsuranceExpiryDate            time.Time  `db:"insurance_expiry_date" json:"insurance_expiry_date"`
+	PollutionCertificateNumber     string     `db:"pollution_certificate_number" json:"pollution_certificate_number"`
+	PollutionCertificateExpiryDate time.Time  `db:"pollution_certificate_expiry_date" json:"pollution_certificate_expiry_date"`
+	FitnessCertificateNumber       string     `db:"fitness_certificate_number" json:"fitness_certificate_number"`
+	FitnessCertificateExpiryDate   time.Time  `db:"fitness_certificate_expiry_date" json:"fitness_certificate_expiry_date"`
+	VehicleDocumentPath            string     `db:"vehicle_document_path" json:"vehicle_document_path"`
+	Version                        int        `db:"version" json:"version"`
+	DeletedAt                      *time.Time `db:"deleted_at" json:"deleted_at"`
+	DeletedBy                      string     `db:"deleted_by" json:"deleted_by"`
+	DeleteReason                   string     `db:"delete_reason" json:"delete_reason"`
+	CreatedAt                      time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt                      time.Time  `db:"updated_at" json:"updated_at"`
 }
 
+// Update, patch and delete methods are conditional on the Version (or expectedVersion)
+// passed in when it is non-zero, failing with ErrPreconditionFailed if the stored row
+// has moved on. Every successful write increments the stored version.
+//
+// Deletes are soft: the row is kept, recording when, by whom (the context's Actor)
+// and why, until it is restored or purged. Reads skip deleted rows unless the
+// context comes from WithDeleted; writes never touch them.
 type DriverHelperStore interface {
-	DriverHelperByID(id uuid.UUID) (DriverHelper, error)
-	DriverHelpers() ([]DriverHelper, error)
-	Drivers() ([]DriverHelper, error)
-	Helpers() ([]DriverHelper, error)
-	VerifiedDriverHelpers() ([]DriverHelper, error)
-	PendingVerificationDriverHelpers() ([]DriverHelper, error)
-	CreateDriverHelper(dh *DriverHelper) error
-	UpdateDriverHelper(dh *DriverHelper) error
-	DeleteDriverHelper(id uuid.UUID) error
-	DriverHelperByMobileNumber(mobile string) (DriverHelper, error)
+	DriverHelperByID(ctx context.Context, id uuid.UUID) (DriverHelper, error)
+	DriverHelpers(ctx context.Context, filter DriverHelperFilter) (Page[DriverHelper], error)
+	Drivers(ctx context.Context) ([]DriverHelper, error)
+	Helpers(ctx context.Context) ([]DriverHelper, error)
+	VerifiedDriverHelpers(ctx context.Context) ([]DriverHelper, error)
+	PendingVerificationDriverHelpers(ctx context.Context) ([]DriverHelper, error)
+	VerificationsDueForRenewal(ctx context.Context, verifiedBefore time.Time) ([]DriverHelper, error)
+	// LicensesExpiringBetween lists drivers whose license expires on or after from and
+	// before to, soonest first. Helpers need no license and are never listed.
+	LicensesExpiringBetween(ctx context.Context, from, to time.Time) ([]DriverHelper, error)
+	// ExpiredLicenses lists drivers whose license expired before asOf, longest expired first.
+	ExpiredLicenses(ctx context.Context, asOf time.Time) ([]DriverHelper, error)
+	// SubmitPoliceVerification and RevokePoliceVerification are conditional,
+	// like updates and deletes, on a non-zero expectedVersion.
+	SubmitPoliceVerification(ctx context.Context, id uuid.UUID, expectedVersion int, date time.Time, documentPath string) (DriverHelper, error)
+	RevokePoliceVerification(ctx context.Context, id uuid.UUID, expectedVersion int, reason string) (DriverHelper, error)
+	CreateDriverHelper(ctx context.Context, dh *DriverHelper) error
+	UpdateDriverHelper(ctx context.Context, dh *DriverHelper) error
+	// PatchDriverHelper writes only the named columns of dh and reloads dh from the stored row.
+	PatchDriverHelper(ctx context.Context, dh *DriverHelper, columns []string) error
+	DeleteDriverHelper(ctx context.Context, id uuid.UUID, expectedVersion int, reason string) error
+	// RestoreDriverHelper undoes a soft delete; restoring a row that is not deleted is a conflict.
+	RestoreDriverHelper(ctx context.Context, id uuid.UUID) (DriverHelper, error)
+	// PurgeDeletedDriverHelpers permanently removes rows deleted before deletedBefore,
+	// unassigning their vehicles, and returns the IDs of the removed rows.
+	PurgeDeletedDriverHelpers(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error)
+	DriverHelperByMobileNumber(ctx context.Context, mobile string) (DriverHelper, error)
 }
 
 type VehicleStore interface {
-	CreateVehicle(v *Vehicle) error
-	UpdateVehicle(v *Vehicle) error
-	DeleteVehicle(id uuid.UUID) error
-	Vehicles() ([]Vehicle, error)
-	VehicleByID(id uuid.UUID) (Vehicle, error)
-	VehiclesByDriverHelperID(driverHelperID uuid.UUID) ([]Vehicle, error)
-	VehiclesByRouteNumber(routeNumber string) ([]Vehicle, error)
-	ExpiredCertificatesVehicles() ([]Vehicle, error)
+	CreateVehicle(ctx context.Context, v *Vehicle) error
+	UpdateVehicle(ctx context.Context, v *Vehicle) error
+	// PatchVehicle writes only the named columns of v and reloads v from the stored row.
+	PatchVehicle(ctx context.Context, v *Vehicle, columns []string) error
+	DeleteVehicle(ctx context.Context, id uuid.UUID, expectedVersion int, reason string) error
+	RestoreVehicle(ctx context.Context, id uuid.UUID) (Vehicle, error)
+	PurgeDeletedVehicles(ctx context.Context, deletedBefore time.Time) ([]uuid.UUID, error)
+	Vehicles(ctx context.Context, filter VehicleFilter) (Page[Vehicle], error)
+	VehicleByID(ctx context.Context, id uuid.UUID) (Vehicle, error)
+	VehiclesByDriverHelperID(ctx context.Context, driverHelperID uuid.UUID) ([]Vehicle, error)
+	VehiclesByRouteNumber(ctx context.Context, routeNumber string) ([]Vehicle, error)
+	// VehicleByNumber finds a vehicle by its registration number, however it is spelled.
+	VehicleByNumber(ctx context.Context, number string) (Vehicle, error)
+	// ExpiredCertificatesVehicles lists vehicles whose insurance, pollution or fitness
+	// certificate expired before today.
+	ExpiredCertificatesVehicles(ctx context.Context) ([]Vehicle, error)
+}
+
+// Stores groups the stores that can take part in one unit of work.
+type Stores struct {
+	DriverHelpers DriverHelperStore
+	Vehicles      VehicleStore
+	Audit         AuditStore
+	// Webhooks is where webhook deliveries are queued, so that the events of
+	// a write are committed, or rolled back, together with it.
+	Webhooks WebhookStore
+}
+
+// UnitOfWork runs fn with stores bound to a single transaction, committing if
+// fn returns nil and rolling back otherwise. fn may be run more than once when
+// the transaction has to be retried, so it must not have other side effects.
+type UnitOfWork interface {
+	WithTx(ctx context.Context, fn func(tx Stores) error) error
 }
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors returned (wrapped) by every store implementation. Callers
+// should test for them with errors.Is rather than matching error strings.
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")
+	ErrValidation  = errors.New("validation failed")
+	ErrForeignKey  = errors.New("foreign key violation")
+	ErrUnavailable = errors.New("service unavailable")
+	// ErrPreconditionFailed reports a conditional write against a stale row version.
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError carries one entry per rejected field and matches ErrValidation.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+func (e *ValidationError) Add(field, format string, args ...any) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Err returns nil when no field was rejected, so callers can `return verr.Err()`.
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// PrefixFields qualifies the field names of a validation error, e.g. "mobile_number" -> "driver.mobile_number";
+// other errors are returned unchanged.
+func PrefixFields(err error, prefix string) error {
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		return err
+	}
+	prefixed := &ValidationError{Fields: make([]FieldError, len(verr.Fields))}
+	for i, f := range verr.Fields {
+		prefixed.Fields[i] = FieldError{Field: prefix + "." + f.Field, Message: f.Message}
+	}
+	return prefixed
+}
+
+func NewFieldError(field, format string, args ...any) error {
+	verr := &ValidationError{}
+	verr.Add(field, format, args...)
+	return verr
+}
+
+func NotFoundError(entity string, id any) error {
+	return fmt.Errorf("%s with ID %v %w", entity, id, ErrNotFound)
+}
+
+func PreconditionFailedError(entity string, id any, version int) error {
+	return fmt.Errorf("%s with ID %v is no longer at version %d: %w", entity, id, version, ErrPreconditionFailed)
+}
+
+func NotDeletedError(entity string, id any) error {
+	return fmt.Errorf("%s with ID %v is not deleted: %w", entity, id, ErrConflict)
+}
+
+func RevokedError(entity string, id any) error {
+	return fmt.Errorf("%s with ID %v is revoked: %w", entity, id, ErrConflict)
+}
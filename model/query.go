@@ -0,0 +1,183 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+// ListOptions holds the keyset pagination and sorting parameters shared by list queries.
+// Cursor is the opaque NextCursor of the previous page.
+type ListOptions struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit"`
+	Sort   string `form:"sort"`
+	Order  string `form:"order"`
+}
+
+type Page[T any] struct {
+	Items      []T
+	NextCursor string
+	Total      int
+}
+
+type DriverHelperFilter struct {
+	ListOptions
+	UserType             string     `form:"user_type"`
+	PoliceVerification   string     `form:"police_verification"`
+	BloodGroup           string     `form:"blood_group"`
+	LicenseExpiresAfter  *time.Time `form:"license_expires_after" time_format:"2006-01-02"`
+	LicenseExpiresBefore *time.Time `form:"license_expires_before" time_format:"2006-01-02"`
+}
+
+type VehicleFilter struct {
+	ListOptions
+	RouteNumber       string `form:"route_number"`
+	MinSeatsAvailable *int   `form:"seats_available_min"`
+	MaxSeatsAvailable *int   `form:"seats_available_max"`
+}
+
+var driverHelperSortFields = map[string]bool{
+	"created_at": true, "updated_at": true, "first_name": true, "last_name": true, "license_expiry_date": true,
+}
+
+var vehicleSortFields = map[string]bool{
+	"created_at": true, "updated_at": true, "vehicle_number": true, "route_number": true,
+	"seats_available": true, "total_students_capacity": true, "insurance_expiry_date": true,
+}
+
+// Normalize fills in defaults and rejects unknown sort fields, orders and malformed cursors.
+func (f *DriverHelperFilter) Normalize() error {
+	verr := &ValidationError{}
+	f.ListOptions.normalize(verr, driverHelperSortFields)
+	if f.UserType != "" && f.UserType != "Driver" && f.UserType != "Helper" {
+		verr.Add("user_type", "invalid user_type: %s; must be 'Driver' or 'Helper'", f.UserType)
+	}
+	if f.PoliceVerification != "" && f.PoliceVerification != "Yes" && f.PoliceVerification != "No" {
+		verr.Add("police_verification", "invalid police_verification: %s; must be 'Yes' or 'No'", f.PoliceVerification)
+	}
+	if f.BloodGroup != "" && !ValidBloodGroup(f.BloodGroup) {
+		verr.Add("blood_group", "invalid blood_group: %s", f.BloodGroup)
+	}
+	return verr.Err()
+}
+
+func (f *VehicleFilter) Normalize() error {
+	verr := &ValidationError{}
+	f.ListOptions.normalize(verr, vehicleSortFields)
+	if f.MinSeatsAvailable != nil && f.MaxSeatsAvailable != nil && *f.MinSeatsAvailable > *f.MaxSeatsAvailable {
+		verr.Add("seats_available_min", "seats_available_min must not exceed seats_available_max")
+	}
+	return verr.Err()
+}
+
+func (o *ListOptions) normalize(verr *ValidationError, sortFields map[string]bool) {
+	if o.Limit <= 0 {
+		o.Limit = DefaultPageLimit
+	}
+	if o.Limit > MaxPageLimit {
+		o.Limit = MaxPageLimit
+	}
+	if o.Sort == "" {
+		o.Sort = "created_at"
+	}
+	if !sortFields[o.Sort] {
+		verr.Add("sort", "invalid sort: %s", o.Sort)
+	}
+	o.Order = strings.ToLower(o.Order)
+	if o.Order == "" {
+		o.Order = "asc"
+	}
+	if o.Order != "asc" && o.Order != "desc" {
+		verr.Add("order", "invalid order: %s; must be 'asc' or 'desc'", o.Order)
+	}
+	if o.Cursor != "" {
+		if _, err := decodeCursor(o.Cursor); err != nil {
+			verr.Add("cursor", "invalid cursor")
+		}
+	}
+}
+
+func (o ListOptions) Descending() bool {
+	return o.Order == "desc"
+}
+
+// SortKey returns the value of the column named by field, as used for keyset pagination.
+// Helpers without a license sort as if it expired at the zero time.
+func (dh DriverHelper) SortKey(field string) any {
+	if field == "license_expiry_date" {
+		if dh.LicenseExpiryDate == nil {
+			return time.Time{}
+		}
+		return *dh.LicenseExpiryDate
+	}
+	return ColumnValue(dh, field)
+}
+
+func (v Vehicle) SortKey(field string) any {
+	return ColumnValue(v, field)
+}
+
+type cursor struct {
+	Value json.RawMessage `json:"v"`
+	ID    uuid.UUID       `json:"id"`
+}
+
+// EncodeCursor builds the opaque cursor pointing just after the row with the given sort key and ID.
+func EncodeCursor(sortKey any, id uuid.UUID) string {
+	raw, _ := json.Marshal(sortKey)
+	b, _ := json.Marshal(cursor{Value: raw, ID: id})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor returns the sort key stored in s, typed like zero, and the row ID.
+func DecodeCursor(s string, zero any) (any, uuid.UUID, error) {
+	c, err := decodeCursor(s)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	ptr := reflect.New(reflect.TypeOf(zero))
+	if err := json.Unmarshal(c.Value, ptr.Interface()); err != nil {
+		return nil, uuid.Nil, err
+	}
+	return ptr.Elem().Interface(), c.ID, nil
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(b, &c)
+	return c, err
+}
+
+// CompareSortKeys orders two sort keys of the same type, returning -1, 0 or 1.
+func CompareSortKeys(a, b any) int {
+	switch x := a.(type) {
+	case string:
+		return strings.Compare(x, b.(string))
+	case int:
+		y := b.(int)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	case time.Time:
+		return x.Compare(b.(time.Time))
+	}
+	return 0
+}
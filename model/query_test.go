@@ -0,0 +1,137 @@
+package model
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	id := uuid.New()
+	expiry := time.Date(2027, 3, 31, 0, 0, 0, 0, time.FixedZone("IST", 5*60*60+30*60))
+	tests := []struct {
+		name string
+		key  any
+	}{
+		{name: "string", key: "Ravi"},
+		{name: "empty string", key: ""},
+		{name: "int", key: 42},
+		{name: "negative int", key: -1},
+		{name: "time", key: expiry},
+		{name: "zero time", key: time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := EncodeCursor(tt.key, id)
+			if strings.ContainsAny(s, "+/=") {
+				t.Errorf("cursor %q is not URL safe", s)
+			}
+			key, gotID, err := DecodeCursor(s, tt.key)
+			if err != nil {
+				t.Fatalf("DecodeCursor: %v", err)
+			}
+			if gotID != id {
+				t.Errorf("DecodeCursor ID = %s, want %s", gotID, id)
+			}
+			if CompareSortKeys(key, tt.key) != 0 {
+				t.Errorf("DecodeCursor key = %#v, want %#v", key, tt.key)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejectsTampering(t *testing.T) {
+	valid := EncodeCursor("Ravi", uuid.New())
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	tests := []struct {
+		name   string
+		cursor string
+		zero   any
+	}{
+		{name: "not base64", cursor: "not a cursor!", zero: ""},
+		{name: "padded base64", cursor: valid + "==", zero: ""},
+		{name: "truncated", cursor: valid[:len(valid)/2], zero: ""},
+		{name: "not JSON", cursor: encode("Ravi"), zero: ""},
+		{name: "malformed ID", cursor: encode(`{"v":"Ravi","id":"42"}`), zero: ""},
+		{name: "string key for an int sort", cursor: valid, zero: 0},
+		{name: "string key for a time sort", cursor: valid, zero: time.Time{}},
+		{name: "int key for a string sort", cursor: EncodeCursor(42, uuid.New()), zero: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if key, id, err := DecodeCursor(tt.cursor, tt.zero); err == nil {
+				t.Errorf("DecodeCursor(%q) = %#v, %s; want an error", tt.cursor, key, id)
+			}
+		})
+	}
+}
+
+func TestListOptionsNormalize(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       ListOptions
+		want       ListOptions
+		wantFields []string
+	}{
+		{name: "defaults", want: ListOptions{Limit: DefaultPageLimit, Sort: "created_at", Order: "asc"}},
+		{name: "negative limit", opts: ListOptions{Limit: -5}, want: ListOptions{Limit: DefaultPageLimit, Sort: "created_at", Order: "asc"}},
+		{name: "limit above the maximum", opts: ListOptions{Limit: MaxPageLimit + 1}, want: ListOptions{Limit: MaxPageLimit, Sort: "created_at", Order: "asc"}},
+		{name: "explicit options", opts: ListOptions{Limit: 10, Sort: "first_name", Order: "DESC"},
+			want: ListOptions{Limit: 10, Sort: "first_name", Order: "desc"}},
+		{name: "valid cursor", opts: ListOptions{Cursor: EncodeCursor("Ravi", uuid.Nil), Sort: "first_name"},
+			want: ListOptions{Cursor: EncodeCursor("Ravi", uuid.Nil), Limit: DefaultPageLimit, Sort: "first_name", Order: "asc"}},
+		{name: "unknown sort", opts: ListOptions{Sort: "aadhar_number"}, wantFields: []string{"sort"}},
+		{name: "unknown order", opts: ListOptions{Order: "sideways"}, wantFields: []string{"order"}},
+		{name: "malformed cursor", opts: ListOptions{Cursor: "%%%"}, wantFields: []string{"cursor"}},
+		{name: "every error at once", opts: ListOptions{Cursor: "%%%", Sort: "id", Order: "up"}, wantFields: []string{"sort", "order", "cursor"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verr := &ValidationError{}
+			opts := tt.opts
+			opts.normalize(verr, driverHelperSortFields)
+
+			var fields []string
+			for _, f := range verr.Fields {
+				fields = append(fields, f.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.wantFields, ",") {
+				t.Errorf("rejected fields = %v, want %v", fields, tt.wantFields)
+			}
+			if tt.wantFields == nil && opts != tt.want {
+				t.Errorf("normalized options = %+v, want %+v", opts, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareSortKeys(t *testing.T) {
+	early := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		a, b any
+		want int
+	}{
+		{name: "strings less", a: "Anil", b: "Ravi", want: -1},
+		{name: "strings equal", a: "Ravi", b: "Ravi", want: 0},
+		{name: "strings greater", a: "ravi", b: "Ravi", want: 1},
+		{name: "empty string first", a: "", b: "A", want: -1},
+		{name: "ints less", a: 3, b: 40, want: -1},
+		{name: "ints equal", a: 7, b: 7, want: 0},
+		{name: "ints greater", a: 0, b: -1, want: 1},
+		{name: "times less", a: early, b: early.Add(time.Second), want: -1},
+		{name: "same instant in another zone", a: early, b: early.In(time.FixedZone("IST", 5*60*60+30*60)), want: 0},
+		{name: "times greater", a: early, b: time.Time{}, want: 1},
+		{name: "unsortable type", a: 1.5, b: 2.5, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CompareSortKeys(tt.a, tt.b); got != tt.want {
+				t.Errorf("CompareSortKeys(%v, %v) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
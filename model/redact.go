@@ -0,0 +1,99 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+)
+
+// piiMasks masks the DriverHelper columns holding personal data for callers
+// not allowed to see them.
+var piiMasks = map[string]func(string) string{
+	"aadhar_number":            MaskAadhaar,
+	"mobile_number":            maskTrailing,
+	"license_number":           maskTrailing,
+	"emergency_contact_number": maskTrailing,
+}
+
+// MaskAadhaar keeps only the last four digits of an Aadhaar number: XXXX-XXXX-1234.
+func MaskAadhaar(aadhaar string) string {
+	if aadhaar == "" {
+		return ""
+	}
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, aadhaar)
+	if len(digits) < 4 {
+		return "XXXX-XXXX-XXXX"
+	}
+	return "XXXX-XXXX-" + digits[len(digits)-4:]
+}
+
+// maskTrailing replaces all but the last four characters of s with X.
+func maskTrailing(s string) string {
+	if len(s) <= 4 {
+		return strings.Repeat("X", len(s))
+	}
+	return strings.Repeat("X", len(s)-4) + s[len(s)-4:]
+}
+
+// Redacted returns a copy of dh with its personal data masked.
+func (dh DriverHelper) Redacted() DriverHelper {
+	dh.AadharNumber = MaskAadhaar(dh.AadharNumber)
+	dh.MobileNumber = maskTrailing(dh.MobileNumber)
+	dh.LicenseNumber = maskTrailing(dh.LicenseNumber)
+	dh.EmergencyContactNumber = maskTrailing(dh.EmergencyContactNumber)
+	return dh
+}
+
+// Redacted returns a copy of c with the before and after values of personal
+// data fields masked.
+func (c Changes) Redacted() Changes {
+	redacted := make(Changes, len(c))
+	for i, change := range c {
+		if mask, ok := piiMasks[change.Field]; ok {
+			change.Before, change.After = maskValue(mask, change.Before), maskValue(mask, change.After)
+		}
+		redacted[i] = change
+	}
+	return redacted
+}
+
+func maskValue(mask func(string) string, v any) any {
+	if s, ok := v.(string); ok {
+		return mask(s)
+	}
+	return v
+}
+
+var digitRun = regexp.MustCompile(`\+?\d+`)
+
+// RedactText masks mobile and Aadhaar numbers - standalone runs of 10 to 12
+// digits, which excludes UUID groups - in free text such as request paths and
+// error messages before they are logged.
+func RedactText(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range digitRun.FindAllStringIndex(s, -1) {
+		start, end := loc[0], loc[1]
+		digits := strings.TrimPrefix(s[start:end], "+")
+		if len(digits) < 10 || len(digits) > 12 || isWordByte(s, start-1) || isWordByte(s, end) {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(maskTrailing(s[start:end]))
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func isWordByte(s string, i int) bool {
+	if i < 0 || i >= len(s) {
+		return false
+	}
+	c := s[i]
+	return c == '-' || c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
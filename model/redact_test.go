@@ -0,0 +1,117 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMaskAadhaar(t *testing.T) {
+	tests := []struct {
+		aadhaar string
+		want    string
+	}{
+		{aadhaar: "234567890124", want: "XXXX-XXXX-0124"},
+		{aadhaar: "2345 6789 0124", want: "XXXX-XXXX-0124"},
+		{aadhaar: "2345-6789-0124", want: "XXXX-XXXX-0124"},
+		{aadhaar: "0124", want: "XXXX-XXXX-0124"},
+		{aadhaar: "124", want: "XXXX-XXXX-XXXX"},
+		{aadhaar: "ab-cd", want: "XXXX-XXXX-XXXX"},
+		{aadhaar: "", want: ""},
+	}
+	for _, tt := range tests {
+		if got := MaskAadhaar(tt.aadhaar); got != tt.want {
+			t.Errorf("MaskAadhaar(%q) = %q, want %q", tt.aadhaar, got, tt.want)
+		}
+	}
+}
+
+func TestMaskTrailing(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{s: "9876543210", want: "XXXXXX3210"},
+		{s: "MH1220110012345", want: "XXXXXXXXXXX2345"},
+		{s: "12345", want: "X2345"},
+		{s: "1234", want: "XXXX"},
+		{s: "12", want: "XX"},
+		{s: "", want: ""},
+	}
+	for _, tt := range tests {
+		if got := maskTrailing(tt.s); got != tt.want {
+			t.Errorf("maskTrailing(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestDriverHelperRedacted(t *testing.T) {
+	dh := DriverHelper{FirstName: "Ravi", AadharNumber: "234567890124", MobileNumber: "9876543210",
+		LicenseNumber: "MH1220110012345", EmergencyContactName: "Asha", EmergencyContactNumber: "9876500000"}
+
+	got := dh.Redacted()
+	want := dh
+	want.AadharNumber, want.MobileNumber = "XXXX-XXXX-0124", "XXXXXX3210"
+	want.LicenseNumber, want.EmergencyContactNumber = "XXXXXXXXXXX2345", "XXXXXX0000"
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Redacted = %+v, want %+v", got, want)
+	}
+	if dh.AadharNumber != "234567890124" {
+		t.Errorf("Redacted modified its receiver: %+v", dh)
+	}
+	if empty := (DriverHelper{UserType: "Helper"}).Redacted(); empty != (DriverHelper{UserType: "Helper"}) {
+		t.Errorf("Redacted of empty fields = %+v, want them left empty", empty)
+	}
+}
+
+func TestChangesRedacted(t *testing.T) {
+	changes := Changes{
+		{Field: "aadhar_number", Before: "234567890124", After: "345678901238"},
+		{Field: "mobile_number", Before: nil, After: "9876543210"},
+		{Field: "license_number", Before: "MH1220110012345", After: ""},
+		{Field: "emergency_contact_number", Before: "9876500000", After: "9876511111"},
+		{Field: "first_name", Before: "Ravi", After: "Ravindra"},
+		{Field: "total_students_capacity", Before: 30, After: 40},
+	}
+	want := Changes{
+		{Field: "aadhar_number", Before: "XXXX-XXXX-0124", After: "XXXX-XXXX-1238"},
+		{Field: "mobile_number", Before: nil, After: "XXXXXX3210"},
+		{Field: "license_number", Before: "XXXXXXXXXXX2345", After: ""},
+		{Field: "emergency_contact_number", Before: "XXXXXX0000", After: "XXXXXX1111"},
+		{Field: "first_name", Before: "Ravi", After: "Ravindra"},
+		{Field: "total_students_capacity", Before: 30, After: 40},
+	}
+	if got := changes.Redacted(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Redacted = %+v, want %+v", got, want)
+	}
+	if changes[0].Before != "234567890124" {
+		t.Errorf("Redacted modified its receiver: %+v", changes[0])
+	}
+	if got := (Changes{}).Redacted(); got == nil || len(got) != 0 {
+		t.Errorf("Redacted of no changes = %#v, want an empty list", got)
+	}
+}
+
+func TestRedactText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "mobile number in a path", text: "/api/driver_helpers/mobile/9876543210", want: "/api/driver_helpers/mobile/XXXXXX3210"},
+		{name: "international mobile number", text: "call +919876543210", want: "call XXXXXXXXX3210"},
+		{name: "aadhaar number in an error", text: `duplicate key (aadhar_number)=(234567890124)`, want: `duplicate key (aadhar_number)=(XXXXXXXX0124)`},
+		{name: "several numbers", text: "9876543210,9876543211", want: "XXXXXX3210,XXXXXX3211"},
+		{name: "UUID groups", text: "/api/vehicles/123e4567-e89b-12d3-a456-426614174000", want: "/api/vehicles/123e4567-e89b-12d3-a456-426614174000"},
+		{name: "short numbers", text: "page 2 of 123456789", want: "page 2 of 123456789"},
+		{name: "long numbers", text: "id 1234567890123", want: "id 1234567890123"},
+		{name: "part of a word", text: "MH1220110012345", want: "MH1220110012345"},
+		{name: "empty", text: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RedactText(tt.text); got != tt.want {
+				t.Errorf("RedactText(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reminder is a notice, sent through one channel, that a document of a
+// vehicle or driver is about to expire. A reminder is sent at most once per
+// entity, document, expiry date, threshold and channel, so renewing the
+// document starts its reminders afresh.
+type Reminder struct {
+	EntityType string    `db:"entity_type" json:"entity_type"`
+	EntityID   uuid.UUID `db:"entity_id" json:"entity_id"`
+	// Document is the compliance rule the expiring document falls under, e.g. "insurance".
+	Document string    `db:"document" json:"document"`
+	DueDate  time.Time `db:"due_date" json:"due_date"`
+	// DaysBefore is the reminder threshold, in days before DueDate, that was reached.
+	DaysBefore int       `db:"days_before" json:"days_before"`
+	Channel    string    `db:"channel" json:"channel"`
+	SentAt     time.Time `db:"sent_at" json:"sent_at"`
+}
+
+// ReminderStore deduplicates reminders across runs and replicas.
+type ReminderStore interface {
+	// ClaimReminder records r as sent, reporting false if it already was.
+	ClaimReminder(ctx context.Context, r Reminder) (bool, error)
+	// ReleaseReminder forgets a claimed reminder that could not be delivered, so it is tried again.
+	ReleaseReminder(ctx context.Context, r Reminder) error
+}
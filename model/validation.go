@@ -0,0 +1,179 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/validation"
+)
+
+var validBloodGroups = map[string]bool{
+	"A+": true, "A-": true, "B+": true, "B-": true, "AB+": true, "AB-": true, "O+": true, "O-": true,
+}
+
+func ValidBloodGroup(bg string) bool {
+	return validBloodGroups[bg]
+}
+
+// Validate checks the fields every DriverHelperStore enforces before a driver/helper is written,
+// normalizing the identity and contact numbers to the form they are stored in.
+// All rejected fields are reported in a single *ValidationError.
+func (dh *DriverHelper) Validate() error {
+	return dh.ValidateColumns(nil)
+}
+
+// ValidateColumns is Validate for a write of only the given columns, or of all
+// of them when columns is nil. Identity numbers and the license expiry date are
+// only checked when written, so other fields of a driver/helper recorded before
+// these rules, or whose license has since expired, can still be changed.
+func (dh *DriverHelper) ValidateColumns(columns []string) error {
+	written := func(column string) bool { return columns == nil || slices.Contains(columns, column) }
+
+	verr := &ValidationError{}
+	if dh.UserType != "Driver" && dh.UserType != "Helper" {
+		verr.Add("user_type", "invalid user_type: %s; must be 'Driver' or 'Helper'", dh.UserType)
+	}
+	if !ValidBloodGroup(dh.BloodGroup) {
+		verr.Add("blood_group", "invalid blood_group: %s; must be one of: A+, A-, B+, B-, AB+, AB-, O+, O-", dh.BloodGroup)
+	}
+	if written("mobile_number") {
+		normalizeField(verr, "mobile_number", &dh.MobileNumber, validation.NormalizeMobile)
+	}
+	if written("aadhar_number") {
+		normalizeField(verr, "aadhar_number", &dh.AadharNumber, validation.NormalizeAadhaar)
+	}
+	if written("license_number") && dh.LicenseNumber != "" {
+		normalizeField(verr, "license_number", &dh.LicenseNumber, validation.NormalizeLicense)
+	}
+	if written("license_expiry_date") && dh.LicenseExpiryDate != nil {
+		if err := validation.LicenseExpiry(*dh.LicenseExpiryDate, time.Now()); err != nil {
+			verr.Add("license_expiry_date", "invalid license_expiry_date: %s; %v", dh.LicenseExpiryDate.Format(time.DateOnly), err)
+		}
+	}
+	// Helpers need no license.
+	if dh.UserType == "Driver" && (written("user_type") || written("license_number") || written("license_expiry_date")) {
+		if dh.LicenseNumber == "" {
+			verr.Add("license_number", "license_number is required for drivers")
+		}
+		if dh.LicenseExpiryDate == nil {
+			verr.Add("license_expiry_date", "license_expiry_date is required for drivers")
+		}
+	}
+	if dh.PoliceVerification == "Yes" {
+		if dh.PoliceVerificationDate == nil {
+			verr.Add("police_verification_date", "police_verification_date is required when police_verification is 'Yes'")
+		}
+		if dh.PoliceVerificationDocumentPath == "" {
+			verr.Add("police_verification_document_path", "police_verification_document_path is required when police_verification is 'Yes'")
+		}
+	}
+	return verr.Err()
+}
+
+// normalizeField replaces *value with its normalized form, or reports why it was rejected.
+func normalizeField(verr *ValidationError, field string, value *string, normalize func(string) (string, error)) {
+	normalized, err := normalize(*value)
+	if err != nil {
+		verr.Add(field, "invalid %s: %s; %v", field, *value, err)
+		return
+	}
+	*value = normalized
+}
+
+// Validate checks the fields every VehicleStore enforces before a vehicle is
+// written, and sets VehicleNumberCanonical from VehicleNumber.
+func (v *Vehicle) Validate() error {
+	return v.ValidateColumns(nil)
+}
+
+// ValidateColumns is Validate for a write of only the given columns, or of all
+// of them when columns is nil. The registration number is only parsed when
+// written, so vehicles recorded before it was can still be changed.
+func (v *Vehicle) ValidateColumns(columns []string) error {
+	verr := &ValidationError{}
+	if columns == nil || slices.Contains(columns, "vehicle_number") {
+		if canonical, err := validation.CanonicalRegistration(v.VehicleNumber); err != nil {
+			verr.Add("vehicle_number", "invalid vehicle_number: %s; %v", v.VehicleNumber, err)
+		} else {
+			v.VehicleNumberCanonical = canonical
+		}
+	}
+	v.validateSeats(verr)
+	return verr.Err()
+}
+
+// CheckAssignable rejects assigning dh to a vehicle as of now if dh is a
+// driver whose license has expired.
+func (dh DriverHelper) CheckAssignable(now time.Time) error {
+	if dh.UserType != "Driver" {
+		return nil
+	}
+	if dh.LicenseExpiryDate == nil {
+		return NewFieldError("driver_helper_id", "driver %s has no license expiry date recorded and cannot be assigned", dh.ID)
+	}
+	if validation.LicenseExpiry(*dh.LicenseExpiryDate, now) != nil {
+		return NewFieldError("driver_helper_id", "driver %s cannot be assigned; their license expired on %s",
+			dh.ID, dh.LicenseExpiryDate.Format(time.DateOnly))
+	}
+	return nil
+}
+
+// CanonicalVehicleNumber returns the form vehicle numbers are stored and looked up in.
+func CanonicalVehicleNumber(number string) (string, error) {
+	canonical, err := validation.CanonicalRegistration(number)
+	if err != nil {
+		return "", NewFieldError("number", "invalid vehicle number: %s; %v", number, err)
+	}
+	return canonical, nil
+}
+
+// validateSeats mirrors the CHECK constraints on the vehicles table.
+func (v *Vehicle) validateSeats(verr *ValidationError) {
+	if v.TotalStudentsCapacity <= 0 {
+		verr.Add("total_students_capacity", "invalid total_students_capacity: %d; must be greater than 0", v.TotalStudentsCapacity)
+	}
+	if v.SeatsAvailable < 0 || v.SeatsAvailable > v.TotalStudentsCapacity {
+		verr.Add("seats_available", "invalid seats_available: %d; must be between 0 and total_students_capacity", v.SeatsAvailable)
+	}
+}
+
+// policeVerificationColumns are the columns written when a police verification is submitted or revoked.
+var policeVerificationColumns = []string{
+	"police_verification", "police_verification_date", "police_verification_document_path",
+	"police_verification_revoked_at", "police_verification_revocation_reason",
+}
+
+// ApplyPoliceVerification marks dh as police verified on date and re-runs
+// Validate, so a submitted verification obeys the same rules as CreateDriverHelper.
+func (dh *DriverHelper) ApplyPoliceVerification(date time.Time, documentPath string) error {
+	if date.After(time.Now()) {
+		return NewFieldError("police_verification_date", "police_verification_date must not be in the future")
+	}
+
+	dh.PoliceVerification = "Yes"
+	dh.PoliceVerificationDate = nil
+	if !date.IsZero() {
+		dh.PoliceVerificationDate = &date
+	}
+	dh.PoliceVerificationDocumentPath = documentPath
+	dh.PoliceVerificationRevokedAt = nil
+	dh.PoliceVerificationRevocationReason = ""
+	return dh.ValidateColumns(policeVerificationColumns)
+}
+
+// RevokePoliceVerification sets police_verification back to 'No', recording
+// when and why. The verification date and document are kept for reference.
+func (dh *DriverHelper) RevokePoliceVerification(reason string, at time.Time) error {
+	if reason == "" {
+		return NewFieldError("reason", "reason is required to revoke a police verification")
+	}
+	if dh.PoliceVerification != "Yes" {
+		return fmt.Errorf("driver/helper %s has no police verification to revoke: %w", dh.ID, ErrConflict)
+	}
+
+	dh.PoliceVerification = "No"
+	dh.PoliceVerificationRevokedAt = &at
+	dh.PoliceVerificationRevocationReason = reason
+	return nil
+}
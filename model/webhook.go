@@ -0,0 +1,161 @@
+package model
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookSubscription asks for the events matching Events to be posted to
+// URL, signed with Secret. Secret is only shown when the subscription is created.
+type WebhookSubscription struct {
+	ID        uuid.UUID   `db:"id" json:"id"`
+	URL       string      `db:"url" json:"url"`
+	Secret    string      `db:"secret" json:"-"`
+	Events    EventFilter `db:"events" json:"events"`
+	CreatedBy string      `db:"created_by" json:"created_by"`
+	CreatedAt time.Time   `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time   `db:"updated_at" json:"updated_at"`
+}
+
+// EventFilter is stored as a JSON array of event types; "*" matches every
+// event and "vehicle.*" every vehicle event.
+type EventFilter []string
+
+func (f EventFilter) Matches(eventType string) bool {
+	for _, pattern := range f {
+		if pattern == "*" || pattern == eventType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(eventType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f EventFilter) Value() (driver.Value, error) {
+	if f == nil {
+		f = EventFilter{}
+	}
+	return json.Marshal(f)
+}
+
+func (f *EventFilter) Scan(src any) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, f)
+	case string:
+		return json.Unmarshal([]byte(data), f)
+	case nil:
+		*f = nil
+		return nil
+	}
+	return errors.New("unsupported type for webhook event filter")
+}
+
+// RawJSON is a JSON document stored in a JSONB column and rendered as is.
+type RawJSON []byte
+
+func (j RawJSON) Value() (driver.Value, error) {
+	if j == nil {
+		return "null", nil
+	}
+	return string(j), nil
+}
+
+func (j *RawJSON) Scan(src any) error {
+	switch data := src.(type) {
+	case []byte:
+		*j = slices.Clone(data)
+	case string:
+		*j = RawJSON(data)
+	case nil:
+		*j = nil
+	default:
+		return errors.New("unsupported type for JSON document")
+	}
+	return nil
+}
+
+func (j RawJSON) MarshalJSON() ([]byte, error) {
+	if j == nil {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+const (
+	// DeliveryPending deliveries are waiting for their next attempt.
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	// DeliveryDead deliveries ran out of attempts; they stay on the dead-letter
+	// list until they are redelivered.
+	DeliveryDead = "dead"
+)
+
+// WebhookDelivery is one event on its way to one subscription.
+type WebhookDelivery struct {
+	ID             uuid.UUID `db:"id" json:"id"`
+	SubscriptionID uuid.UUID `db:"subscription_id" json:"subscription_id"`
+	EventID        uuid.UUID `db:"event_id" json:"event_id"`
+	EventType      string    `db:"event_type" json:"event_type"`
+	Payload        RawJSON   `db:"payload" json:"payload"`
+	Status         string    `db:"status" json:"status"`
+	// Attempts counts the attempts since the delivery was queued or last redelivered.
+	Attempts      int        `db:"attempts" json:"attempts"`
+	NextAttemptAt *time.Time `db:"next_attempt_at" json:"next_attempt_at"`
+	LastError     string     `db:"last_error" json:"last_error"`
+	DeliveredAt   *time.Time `db:"delivered_at" json:"delivered_at"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// WebhookAttempt records one POST of a delivery and how the receiver answered.
+type WebhookAttempt struct {
+	ID         uuid.UUID `db:"id" json:"id"`
+	DeliveryID uuid.UUID `db:"delivery_id" json:"delivery_id"`
+	Attempt    int       `db:"attempt" json:"attempt"`
+	// StatusCode is zero when no response was received.
+	StatusCode  int       `db:"status_code" json:"status_code"`
+	Error       string    `db:"error" json:"error"`
+	DurationMS  int64     `db:"duration_ms" json:"duration_ms"`
+	AttemptedAt time.Time `db:"attempted_at" json:"attempted_at"`
+}
+
+// WebhookDeliveryFilter narrows a listing of deliveries; zero fields match everything.
+type WebhookDeliveryFilter struct {
+	SubscriptionID uuid.UUID
+	Status         string
+	// Limit caps the number of deliveries returned, newest first.
+	Limit int
+}
+
+type WebhookStore interface {
+	CreateWebhook(ctx context.Context, sub *WebhookSubscription) error
+	Webhooks(ctx context.Context) ([]WebhookSubscription, error)
+	WebhookByID(ctx context.Context, id uuid.UUID) (WebhookSubscription, error)
+	// DeleteWebhook removes a subscription along with its deliveries.
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+
+	EnqueueDeliveries(ctx context.Context, deliveries []WebhookDelivery) error
+	// ClaimDueDeliveries returns up to limit pending deliveries due by now,
+	// oldest due first, and pushes their next attempt back to leaseUntil so
+	// that no other dispatcher picks them up meanwhile.
+	ClaimDueDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]WebhookDelivery, error)
+	// RecordAttempt stores attempt together with the state of d it resulted in.
+	RecordAttempt(ctx context.Context, d WebhookDelivery, attempt WebhookAttempt) error
+	WebhookDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]WebhookDelivery, error)
+	WebhookDeliveryByID(ctx context.Context, id uuid.UUID) (WebhookDelivery, error)
+	// WebhookAttempts lists the attempts of a delivery, oldest first.
+	WebhookAttempts(ctx context.Context, deliveryID uuid.UUID) ([]WebhookAttempt, error)
+	// RequeueDelivery moves a dead delivery back to pending, due at now and
+	// with a fresh set of attempts; requeueing any other delivery is a conflict.
+	RequeueDelivery(ctx context.Context, id uuid.UUID, now time.Time) (WebhookDelivery, error)
+}
@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/validation"
+)
+
+// Email sends messages as plain-text mail through an SMTP relay.
+type Email struct {
+	// Addr is the relay's host:port.
+	Addr string
+	// Username and Password authenticate with PLAIN auth; no auth is attempted
+	// when Username is empty.
+	Username string
+	Password string
+	// From and To are bare addresses such as fleet@example.com; see validation.ValidateMailAddress.
+	From string
+	To   []string
+}
+
+func (e *Email) Name() string { return "email" }
+
+func (e *Email) Notify(ctx context.Context, msg Message) error {
+	for _, addr := range append([]string{e.From}, e.To...) {
+		if err := validation.ValidateMailAddress(addr); err != nil {
+			return err
+		}
+	}
+
+	var auth smtp.Auth
+	if e.Username != "" {
+		host, _, err := net.SplitHostPort(e.Addr)
+		if err != nil {
+			return fmt.Errorf("invalid SMTP address %q: %w", e.Addr, err)
+		}
+		auth = smtp.PlainAuth("", e.Username, e.Password, host)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", encodeHeader(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	// Quoted-printable keeps the body 7-bit with lines short enough for any
+	// relay; the writer also turns LF line endings into CRLF.
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	qp := quotedprintable.NewWriter(&b)
+	if _, err := qp.Write([]byte(msg.Body)); err != nil {
+		return fmt.Errorf("failed to encode mail body: %w", err)
+	}
+	if err := qp.Close(); err != nil {
+		return fmt.Errorf("failed to encode mail body: %w", err)
+	}
+
+	// net/smtp takes no context, so give up waiting for the relay once ctx is done.
+	done := make(chan error, 1)
+	go func() { done <- smtp.SendMail(e.Addr, auth, e.From, e.To, []byte(b.String())) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to send mail through %s: %w", e.Addr, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// encodeHeader folds line breaks in s into spaces, so it stays a single header
+// line, and encodes anything outside printable ASCII as an RFC 2047 encoded-word.
+func encodeHeader(s string) string {
+	s = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(s)
+	return mime.QEncoding.Encode("utf-8", s)
+}
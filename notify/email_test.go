@@ -0,0 +1,216 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeHeader(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{in: "Insurance of DL1PC0001 expires in 7 days", want: "Insurance of DL1PC0001 expires in 7 days"},
+		{in: "Expiring\r\nBcc: attacker@example.com", want: "Expiring Bcc: attacker@example.com"},
+		{in: "Expiring\nBcc: attacker@example.com", want: "Expiring Bcc: attacker@example.com"},
+		{in: "Licence of Rāvi expires", want: "=?utf-8?q?Licence_of_R=C4=81vi_expires?="},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got := encodeHeader(tt.in)
+			if got != tt.want {
+				t.Errorf("encodeHeader(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			if strings.ContainsAny(got, "\r\n") {
+				t.Errorf("encodeHeader(%q) = %q contains a line break", tt.in, got)
+			}
+		})
+	}
+}
+
+// smtpSession is what a fakeSMTP server was sent in one mail transaction.
+type smtpSession struct {
+	from string
+	rcpt []string
+	data string
+}
+
+// fakeSMTP accepts one connection on a loopback listener and speaks just
+// enough SMTP for smtp.SendMail, recording the envelope and message.
+func fakeSMTP(t *testing.T) (addr string, session <-chan smtpSession) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	done := make(chan smtpSession, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+		tp := textproto.NewConn(conn)
+		var s smtpSession
+		tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			switch verb := strings.ToUpper(strings.Fields(line + " ")[0]); verb {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250 localhost")
+			case "MAIL":
+				s.from = strings.Trim(strings.TrimPrefix(line, "MAIL FROM:"), "<>")
+				tp.PrintfLine("250 OK")
+			case "RCPT":
+				s.rcpt = append(s.rcpt, strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>"))
+				tp.PrintfLine("250 OK")
+			case "DATA":
+				tp.PrintfLine("354 go ahead")
+				data, err := tp.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				s.data = string(data)
+				tp.PrintfLine("250 OK")
+			case "QUIT":
+				tp.PrintfLine("221 bye")
+				done <- s
+				return
+			default:
+				tp.PrintfLine("502 %s not implemented", verb)
+			}
+		}
+	}()
+	return l.Addr().String(), done
+}
+
+func TestEmailNotify(t *testing.T) {
+	addr, session := fakeSMTP(t)
+	e := &Email{Addr: addr, From: "fleet@example.com", To: []string{"office@example.com", "principal@example.com"}}
+	msg := Message{
+		Subject: "Insurance expiring\r\nBcc: attacker@example.com",
+		Body:    "The insurance of vehicle DL1PC0001 expires tomorrow.\nPlease renew it.",
+	}
+	if err := e.Notify(context.Background(), msg); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	var s smtpSession
+	select {
+	case s = <-session:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the SMTP server saw no complete session")
+	}
+	if s.from != "fleet@example.com" {
+		t.Errorf("MAIL FROM = %q", s.from)
+	}
+	if strings.Join(s.rcpt, ",") != "office@example.com,principal@example.com" {
+		t.Errorf("RCPT TO = %v", s.rcpt)
+	}
+
+	m, err := mail.ReadMessage(strings.NewReader(s.data))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	if got := m.Header.Get("Subject"); got != "Insurance expiring Bcc: attacker@example.com" {
+		t.Errorf("Subject = %q", got)
+	}
+	if got := m.Header.Get("Bcc"); got != "" {
+		t.Errorf("the subject injected a Bcc header: %q", got)
+	}
+	if got := m.Header.Get("To"); got != "office@example.com, principal@example.com" {
+		t.Errorf("To = %q", got)
+	}
+	if got := m.Header.Get("Content-Type"); got != "text/plain; charset=UTF-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := m.Header.Get("Content-Transfer-Encoding"); got != "quoted-printable" {
+		t.Errorf("Content-Transfer-Encoding = %q", got)
+	}
+	body, err := io.ReadAll(quotedprintable.NewReader(m.Body))
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	// ReadDotBytes has already turned the CRLF line endings on the wire back into LF.
+	if want := "The insurance of vehicle DL1PC0001 expires tomorrow.\nPlease renew it.\n"; string(body) != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+}
+
+func TestEmailNotifyEncodesBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "non-ASCII", body: "Licence of Rāvi Kumār (रवि) expires on 31 March."},
+		{name: "long line", body: "Vehicles with expiring documents: " + strings.Repeat("DL1PC0001, ", 30) + "DL1PC0002."},
+		{name: "equals signs", body: "seats_available=0\nroute=R1"},
+		{name: "trailing spaces", body: "Renew it.   \nThank you.\t"},
+		{name: "leading dot", body: "Summary\n.\n..done"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, session := fakeSMTP(t)
+			e := &Email{Addr: addr, From: "fleet@example.com", To: []string{"office@example.com"}}
+			if err := e.Notify(context.Background(), Message{Subject: "Expiring documents", Body: tt.body}); err != nil {
+				t.Fatalf("Notify: %v", err)
+			}
+			var s smtpSession
+			select {
+			case s = <-session:
+			case <-time.After(5 * time.Second):
+				t.Fatal("the SMTP server saw no complete session")
+			}
+
+			m, err := mail.ReadMessage(strings.NewReader(s.data))
+			if err != nil {
+				t.Fatalf("parse message: %v", err)
+			}
+			if got := m.Header.Get("Content-Transfer-Encoding"); got != "quoted-printable" {
+				t.Errorf("Content-Transfer-Encoding = %q", got)
+			}
+			raw, err := io.ReadAll(m.Body)
+			if err != nil {
+				t.Fatalf("read body: %v", err)
+			}
+			for _, line := range strings.Split(string(raw), "\n") {
+				if len(line) > 76 {
+					t.Errorf("encoded line %q is longer than 76 characters", line)
+				}
+				for i := 0; i < len(line); i++ {
+					if line[i] > '~' {
+						t.Fatalf("encoded line %q is not 7-bit", line)
+					}
+				}
+			}
+			body, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(string(raw))))
+			if err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			// smtp.SendMail ends the data with a line break of its own.
+			if want := tt.body + "\n"; string(body) != want {
+				t.Errorf("decoded body = %q, want %q", body, want)
+			}
+		})
+	}
+}
+
+func TestEmailNotifyRejectsInjectedAddress(t *testing.T) {
+	e := &Email{Addr: "127.0.0.1:1", From: "fleet@example.com", To: []string{"office@example.com\r\nBcc: attacker@example.com"}}
+	if err := e.Notify(context.Background(), Message{Subject: "s", Body: "b"}); err == nil || !strings.Contains(err.Error(), "line breaks") {
+		t.Fatalf("Notify = %v, want a line break error before connecting", err)
+	}
+}
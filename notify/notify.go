@@ -0,0 +1,53 @@
+// Package notify delivers messages to people and systems outside the
+// service: email over SMTP, text messages through an SMS gateway's HTTP API,
+// and JSON webhooks.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Message is a notice to deliver. Subject and Body are plain text; Data is
+// the structured form of the same notice, sent by notifiers that carry JSON.
+type Message struct {
+	Subject string
+	Body    string
+	Data    any
+}
+
+// Notifier delivers messages through one channel.
+type Notifier interface {
+	// Name identifies the channel, e.g. "email".
+	Name() string
+	Notify(ctx context.Context, msg Message) error
+}
+
+// post sends body to url as JSON and treats any status other than 2xx as a failure.
+func post(ctx context.Context, client *http.Client, url string, body []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s responded %s: %s", url, resp.Status, bytes.TrimSpace(snippet))
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// SMS sends the body of each message as a text message through an SMS
+// gateway that accepts a JSON POST of {"to": [...], "message": "..."}.
+type SMS struct {
+	Client *http.Client
+	URL    string
+	// APIKey, when set, is sent as a bearer token.
+	APIKey string
+	To     []string
+}
+
+func (s *SMS) Name() string { return "sms" }
+
+func (s *SMS) Notify(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(struct {
+		To      []string `json:"to"`
+		Message string   `json:"message"`
+	}{s.To, msg.Body})
+	if err != nil {
+		return err
+	}
+	header := http.Header{}
+	if s.APIKey != "" {
+		header.Set("Authorization", "Bearer "+s.APIKey)
+	}
+	return post(ctx, s.Client, s.URL, body, header)
+}
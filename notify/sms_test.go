@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSMSNotify(t *testing.T) {
+	var got struct {
+		To      []string `json:"to"`
+		Message string   `json:"message"`
+	}
+	var auth, contentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, contentType = r.Header.Get("Authorization"), r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	sms := &SMS{Client: srv.Client(), URL: srv.URL, APIKey: "gateway-key", To: []string{"+919876543210", "+919876543211"}}
+	if err := sms.Notify(context.Background(), Message{Subject: "ignored", Body: "Insurance of DL1PC0001 expires tomorrow"}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if got.Message != "Insurance of DL1PC0001 expires tomorrow" {
+		t.Errorf("message = %q", got.Message)
+	}
+	if len(got.To) != 2 || got.To[0] != "+919876543210" || got.To[1] != "+919876543211" {
+		t.Errorf("to = %v", got.To)
+	}
+	if auth != "Bearer gateway-key" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if contentType != "application/json" {
+		t.Errorf("Content-Type = %q", contentType)
+	}
+}
+
+func TestSMSNotifyGatewayError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	sms := &SMS{Client: srv.Client(), URL: srv.URL, To: []string{"+919876543210"}}
+	if err := sms.Notify(context.Background(), Message{Body: "hello"}); err == nil {
+		t.Fatal("Notify succeeded against a gateway that responded 429")
+	}
+}
@@ -0,0 +1,90 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers that sign a webhook body, both for reminder webhooks and for
+// deliveries of the webhooks package.
+const (
+	// SignatureHeader carries the Sign signature of the timestamp and body.
+	SignatureHeader = "X-DVP-Signature"
+	// TimestampHeader is when the webhook was sent, in Unix seconds.
+	TimestampHeader = "X-DVP-Timestamp"
+)
+
+// SignatureTolerance is how far a receiver should let TimestampHeader stray
+// from its own clock before rejecting a webhook as a replay.
+const SignatureTolerance = 5 * time.Minute
+
+// Sign returns the value of SignatureHeader for body sent at timestamp:
+// "sha256=" followed by the hex-encoded HMAC-SHA256, under secret, of the
+// TimestampHeader value, a ".", and body. Covering the timestamp stops a
+// captured webhook from being replayed later with a fresh one.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SetSignature sets the TimestampHeader and SignatureHeader of a webhook of
+// body sent at now.
+func SetSignature(header http.Header, secret string, now time.Time, body []byte) {
+	header.Set(TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	header.Set(SignatureHeader, Sign(secret, now, body))
+}
+
+// Verify checks the TimestampHeader and SignatureHeader values of a webhook
+// of body as a receiver would: the signature must match and the timestamp
+// must be within SignatureTolerance of now.
+func Verify(secret, timestamp, signature string, body []byte, now time.Time) error {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q", TimestampHeader, timestamp)
+	}
+	sent := time.Unix(unix, 0)
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, sent, body))) {
+		return fmt.Errorf("%s does not match", SignatureHeader)
+	}
+	if skew := now.Sub(sent).Abs(); skew > SignatureTolerance {
+		return fmt.Errorf("%s is %s away from now, more than the %s tolerated", TimestampHeader, skew.Round(time.Second), SignatureTolerance)
+	}
+	return nil
+}
+
+// Webhook posts messages as JSON: the message's Data when it has any,
+// otherwise its subject and body. When Secret is set the body is signed,
+// together with the time it is sent, in SignatureHeader and TimestampHeader
+// so the receiver can check it came from this service and is not a replay.
+type Webhook struct {
+	Client *http.Client
+	URL    string
+	Secret string
+}
+
+func (w *Webhook) Name() string { return "webhook" }
+
+func (w *Webhook) Notify(ctx context.Context, msg Message) error {
+	payload := msg.Data
+	if payload == nil {
+		payload = map[string]string{"subject": msg.Subject, "body": msg.Body}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	header := http.Header{}
+	if w.Secret != "" {
+		SetSignature(header, w.Secret, time.Now(), body)
+	}
+	return post(ctx, w.Client, w.URL, body, header)
+}
@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// received is a request captured by a test webhook receiver.
+type received struct {
+	body      []byte
+	signature string
+	timestamp string
+}
+
+func newReceiver(t *testing.T, status int) (*httptest.Server, *received) {
+	t.Helper()
+	got := &received{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("read body: %v", err)
+		}
+		got.body, got.signature, got.timestamp = body, r.Header.Get(SignatureHeader), r.Header.Get(TimestampHeader)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, got
+}
+
+func TestWebhookNotify(t *testing.T) {
+	tests := []struct {
+		name     string
+		secret   string
+		msg      Message
+		wantBody string
+	}{
+		{
+			name:     "data",
+			secret:   "0123456789abcdef",
+			msg:      Message{Subject: "s", Body: "b", Data: map[string]any{"event": "reminder.expiring", "days_left": 7}},
+			wantBody: `{"days_left":7,"event":"reminder.expiring"}`,
+		},
+		{
+			name:     "subject and body",
+			secret:   "0123456789abcdef",
+			msg:      Message{Subject: "Insurance expiring", Body: "Renew it"},
+			wantBody: `{"body":"Renew it","subject":"Insurance expiring"}`,
+		},
+		{
+			name:     "unsigned",
+			msg:      Message{Subject: "Insurance expiring", Body: "Renew it"},
+			wantBody: `{"body":"Renew it","subject":"Insurance expiring"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, got := newReceiver(t, http.StatusNoContent)
+			w := &Webhook{Client: srv.Client(), URL: srv.URL, Secret: tt.secret}
+			if err := w.Notify(context.Background(), tt.msg); err != nil {
+				t.Fatalf("Notify: %v", err)
+			}
+			if string(got.body) != tt.wantBody {
+				t.Errorf("body = %s, want %s", got.body, tt.wantBody)
+			}
+
+			if tt.secret == "" {
+				if got.signature != "" || got.timestamp != "" {
+					t.Errorf("unsigned webhook sent %s %q and %s %q", SignatureHeader, got.signature, TimestampHeader, got.timestamp)
+				}
+				return
+			}
+			if err := Verify(tt.secret, got.timestamp, got.signature, got.body, time.Now()); err != nil {
+				t.Errorf("Verify: %v", err)
+			}
+			// Reminder webhooks are signed like deliveries, never over the body alone.
+			mac := hmac.New(sha256.New, []byte(tt.secret))
+			mac.Write(got.body)
+			if got.signature == "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+				t.Errorf("%s signs the body without the timestamp", SignatureHeader)
+			}
+		})
+	}
+}
+
+func TestWebhookNotifyReceiverError(t *testing.T) {
+	srv, _ := newReceiver(t, http.StatusInternalServerError)
+	w := &Webhook{Client: srv.Client(), URL: srv.URL, Secret: "0123456789abcdef"}
+	if err := w.Notify(context.Background(), Message{Subject: "s", Body: "b"}); err == nil {
+		t.Fatal("Notify succeeded against a receiver that responded 500")
+	}
+}
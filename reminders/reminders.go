@@ -0,0 +1,187 @@
+// Package reminders warns the transport office ahead of vehicle certificates
+// and driving licenses expiring, so they can be renewed in time.
+package reminders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/compliance"
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+	"github.com/arjunsaxaena/driver_vehicle_profile/notify"
+	"github.com/arjunsaxaena/driver_vehicle_profile/validation"
+)
+
+// DefaultDaysBefore are the thresholds reminders are sent at when none are configured.
+var DefaultDaysBefore = []int{30, 15, 7, 1}
+
+// Job finds documents nearing expiry and sends a reminder through every
+// notifier each time one crosses a threshold. Each reminder is claimed in the
+// reminder store before it is sent, so running the job again, or on several
+// replicas at once, does not repeat it.
+type Job struct {
+	Stores    model.Stores
+	Reminders model.ReminderStore
+	Notifiers []notify.Notifier
+	// DaysBefore are the thresholds, in days before expiry, to send reminders at.
+	DaysBefore []int
+}
+
+// due is a document expiring within the largest threshold.
+type due struct {
+	entityType string
+	entityID   uuid.UUID
+	name       string
+	document   compliance.Rule
+	dueDate    time.Time
+	daysLeft   int
+}
+
+// Payload is the structured form of a reminder, sent as the body of webhook reminders.
+type Payload struct {
+	Event      string    `json:"event"`
+	EntityType string    `json:"entity_type"`
+	EntityID   uuid.UUID `json:"entity_id"`
+	Name       string    `json:"name"`
+	Document   string    `json:"document"`
+	DueDate    string    `json:"due_date"`
+	DaysLeft   int       `json:"days_left"`
+	DaysBefore int       `json:"days_before"`
+}
+
+// Run sends the reminders due as of now.
+func (j *Job) Run(ctx context.Context, now time.Time) error {
+	today := validation.StartOfDay(now)
+	thresholds := slices.Clone(j.DaysBefore)
+	if len(thresholds) == 0 {
+		thresholds = DefaultDaysBefore
+	}
+	slices.Sort(thresholds)
+
+	docs, err := j.expiring(ctx, today, thresholds[len(thresholds)-1])
+	if err != nil {
+		return err
+	}
+
+	var sent, failed int
+	var errs []error
+	for _, d := range docs {
+		// The smallest threshold the document is within; expiring lists none past the largest.
+		i, _ := slices.BinarySearch(thresholds, d.daysLeft)
+		daysBefore := thresholds[i]
+		msg := message(d, daysBefore)
+		for _, n := range j.Notifiers {
+			r := model.Reminder{
+				EntityType: d.entityType,
+				EntityID:   d.entityID,
+				Document:   string(d.document),
+				DueDate:    d.dueDate,
+				DaysBefore: daysBefore,
+				Channel:    n.Name(),
+				SentAt:     now,
+			}
+			claimed, err := j.Reminders.ClaimReminder(ctx, r)
+			if err != nil {
+				return err
+			}
+			if !claimed {
+				continue
+			}
+			if err := n.Notify(ctx, msg); err != nil {
+				failed++
+				errs = append(errs, fmt.Errorf("%s reminder for %s %s: %w", n.Name(), d.entityType, d.entityID, err))
+				if err := j.Reminders.ReleaseReminder(ctx, r); err != nil {
+					return err
+				}
+				continue
+			}
+			sent++
+		}
+	}
+
+	if sent > 0 || failed > 0 {
+		slog.Info("sent expiry reminders", "sent", sent, "failed", failed)
+	}
+	return errors.Join(errs...)
+}
+
+// expiring lists the documents that expire from today up to within days of it.
+func (j *Job) expiring(ctx context.Context, today time.Time, within int) ([]due, error) {
+	var docs []due
+	add := func(entityType string, id uuid.UUID, name string, rule compliance.Rule, dueDate time.Time) {
+		if dueDate.IsZero() {
+			return
+		}
+		if days := validation.DaysUntil(dueDate, today); days >= 0 && days <= within {
+			docs = append(docs, due{entityType, id, name, rule, validation.StartOfDay(dueDate), days})
+		}
+	}
+
+	// Vehicles have no query for certificates expiring within a window, so rate them all.
+	reports, err := compliance.Engine{}.Vehicles(ctx, j.Stores.Vehicles, today)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vehicles: %w", err)
+	}
+	for _, r := range reports {
+		for _, c := range r.Checks {
+			if c.DueDate != nil {
+				add(r.EntityType, r.ID, r.Name, c.Rule, *c.DueDate)
+			}
+		}
+	}
+
+	drivers, err := j.Stores.DriverHelpers.LicensesExpiringBetween(ctx, today, today.AddDate(0, 0, within+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expiring licenses: %w", err)
+	}
+	for _, dh := range drivers {
+		if dh.LicenseExpiryDate != nil {
+			add(model.EntityDriverHelper, dh.ID, strings.TrimSpace(dh.FirstName+" "+dh.LastName), compliance.RuleLicense, *dh.LicenseExpiryDate)
+		}
+	}
+	return docs, nil
+}
+
+var documentNames = map[compliance.Rule]string{
+	compliance.RuleInsurance: "insurance",
+	compliance.RulePollution: "pollution under control certificate",
+	compliance.RuleFitness:   "fitness certificate",
+	compliance.RuleLicense:   "driving license",
+}
+
+func message(d due, daysBefore int) notify.Message {
+	what := "vehicle " + d.name
+	if d.entityType == model.EntityDriverHelper {
+		what = "driver " + d.name
+	}
+	when := fmt.Sprintf("in %d days", d.daysLeft)
+	switch d.daysLeft {
+	case 0:
+		when = "today"
+	case 1:
+		when = "tomorrow"
+	}
+	date := d.dueDate.Format(time.DateOnly)
+	return notify.Message{
+		Subject: fmt.Sprintf("The %s of %s expires %s", documentNames[d.document], what, when),
+		Body: fmt.Sprintf("The %s of %s expires %s, on %s. Please renew it before then.",
+			documentNames[d.document], what, when, date),
+		Data: Payload{
+			Event:      "reminder.expiring",
+			EntityType: d.entityType,
+			EntityID:   d.entityID,
+			Name:       d.name,
+			Document:   string(d.document),
+			DueDate:    date,
+			DaysLeft:   d.daysLeft,
+			DaysBefore: daysBefore,
+		},
+	}
+}
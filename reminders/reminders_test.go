@@ -0,0 +1,162 @@
+package reminders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/memstore"
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+	"github.com/arjunsaxaena/driver_vehicle_profile/notify"
+	"github.com/arjunsaxaena/driver_vehicle_profile/validation"
+)
+
+// recorder is a notifier that keeps the payloads it is sent, or fails while err is set.
+type recorder struct {
+	name string
+	err  error
+	sent []Payload
+}
+
+func (r *recorder) Name() string { return r.name }
+
+func (r *recorder) Notify(ctx context.Context, msg notify.Message) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.sent = append(r.sent, msg.Data.(Payload))
+	return nil
+}
+
+// sent summarizes payloads as "name document days_left/days_before", sorted.
+func sent(payloads []Payload) []string {
+	var s []string
+	for _, p := range payloads {
+		s = append(s, fmt.Sprintf("%s %s %d/%d", p.Name, p.Document, p.DaysLeft, p.DaysBefore))
+	}
+	sort.Strings(s)
+	return s
+}
+
+func equal(got, want []string) bool {
+	return fmt.Sprint(got) == fmt.Sprint(want)
+}
+
+// newFleet stores one vehicle per entry of insuranceDays, its insurance
+// expiring that many days after now and its other certificates next year,
+// and a driver whose license expires in 15 days.
+func newFleet(t *testing.T, now time.Time, insuranceDays ...int) (model.Stores, model.ReminderStore) {
+	t.Helper()
+	ctx := context.Background()
+	db := memstore.NewDB()
+	stores := memstore.NewStores(db)
+
+	nextYear := now.AddDate(1, 0, 0)
+	for i, days := range insuranceDays {
+		v := model.Vehicle{
+			VehicleNumber:                  fmt.Sprintf("DL1PC%04d", i+1),
+			RouteNumber:                    "R1",
+			TotalStudentsCapacity:          30,
+			SeatsAvailable:                 30,
+			InsuranceExpiryDate:            now.AddDate(0, 0, days),
+			PollutionCertificateExpiryDate: nextYear,
+			FitnessCertificateExpiryDate:   nextYear,
+		}
+		if err := stores.Vehicles.CreateVehicle(ctx, &v); err != nil {
+			t.Fatalf("CreateVehicle: %v", err)
+		}
+	}
+
+	license := now.AddDate(0, 0, 15)
+	dh := model.DriverHelper{UserType: "Driver", FirstName: "Ravi", LastName: "Kumar", BloodGroup: "O+",
+		AadharNumber: "234567890124", MobileNumber: "9876543210", LicenseNumber: "MH1220110012345",
+		LicenseExpiryDate: &license, PoliceVerification: "No"}
+	if err := stores.DriverHelpers.CreateDriverHelper(ctx, &dh); err != nil {
+		t.Fatalf("CreateDriverHelper: %v", err)
+	}
+	return stores, memstore.NewReminderStore(db)
+}
+
+// middayToday keeps a test's "later the same day" run from crossing midnight.
+func middayToday() time.Time {
+	return validation.StartOfDay(time.Now()).Add(12 * time.Hour)
+}
+
+func TestRunThresholds(t *testing.T) {
+	ctx := context.Background()
+	now := middayToday()
+	stores, reminders := newFleet(t, now, 45, 31, 30, 20, 15, 10, 7, 1, 0, -1)
+	email := &recorder{name: "email"}
+	job := &Job{Stores: stores, Reminders: reminders, Notifiers: []notify.Notifier{email}, DaysBefore: DefaultDaysBefore}
+
+	if err := job.Run(ctx, now); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := []string{
+		"DL1PC0003 insurance 30/30",
+		"DL1PC0004 insurance 20/30",
+		"DL1PC0005 insurance 15/15",
+		"DL1PC0006 insurance 10/15",
+		"DL1PC0007 insurance 7/7",
+		"DL1PC0008 insurance 1/1",
+		"DL1PC0009 insurance 0/1",
+		"Ravi Kumar license 15/15",
+	}
+	if got := sent(email.sent); !equal(got, want) {
+		t.Errorf("first run sent\n%v\nwant\n%v", got, want)
+	}
+
+	email.sent = nil
+	if err := job.Run(ctx, now.Add(time.Hour)); err != nil {
+		t.Fatalf("Run again: %v", err)
+	}
+	if len(email.sent) != 0 {
+		t.Errorf("running again the same day sent %v", sent(email.sent))
+	}
+
+	// Five days on, only documents that crossed a threshold since are reminded;
+	// the rest were already reminded at the threshold they are still within.
+	email.sent = nil
+	if err := job.Run(ctx, now.AddDate(0, 0, 5)); err != nil {
+		t.Fatalf("Run five days later: %v", err)
+	}
+	want = []string{
+		"DL1PC0002 insurance 26/30",
+		"DL1PC0004 insurance 15/15",
+		"DL1PC0006 insurance 5/7",
+	}
+	if got := sent(email.sent); !equal(got, want) {
+		t.Errorf("run five days later sent\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestRunReleasesFailedReminders(t *testing.T) {
+	ctx := context.Background()
+	now := middayToday()
+	stores, reminders := newFleet(t, now, 7)
+	email := &recorder{name: "email"}
+	sms := &recorder{name: "sms", err: errors.New("gateway down")}
+	job := &Job{Stores: stores, Reminders: reminders, Notifiers: []notify.Notifier{email, sms}}
+
+	if err := job.Run(ctx, now); err == nil {
+		t.Fatal("Run succeeded with a failing notifier")
+	}
+	want := []string{"DL1PC0001 insurance 7/7", "Ravi Kumar license 15/15"}
+	if got := sent(email.sent); !equal(got, want) {
+		t.Errorf("email sent %v, want %v", got, want)
+	}
+
+	email.sent, sms.err = nil, nil
+	if err := job.Run(ctx, now); err != nil {
+		t.Fatalf("Run after the gateway recovered: %v", err)
+	}
+	if len(email.sent) != 0 {
+		t.Errorf("email was sent again: %v", sent(email.sent))
+	}
+	if got := sent(sms.sent); !equal(got, want) {
+		t.Errorf("sms sent %v, want %v", got, want)
+	}
+}
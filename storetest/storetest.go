@@ -0,0 +1,284 @@
+// Package storetest holds the behaviour every model store implementation must
+// share, so the in-memory and PostgreSQL stores are held to the same cases.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+// DSNEnv names the environment variable holding the DSN of a disposable
+// PostgreSQL database for tests. Tests needing one skip when it is unset.
+const DSNEnv = "DVP_TEST_DATABASE_DSN"
+
+// Run runs the suite, calling newStores for empty stores at the start of each case.
+func Run(t *testing.T, newStores func(t *testing.T) model.Stores) {
+	cases := []struct {
+		name string
+		run  func(t *testing.T, stores model.Stores)
+	}{
+		{"DriverHelperCRUD", testDriverHelperCRUD},
+		{"DriverHelperNotFound", testDriverHelperNotFound},
+		{"DuplicateAadhaar", testDuplicateAadhaar},
+		{"VehicleCRUD", testVehicleCRUD},
+		{"VehicleNotFound", testVehicleNotFound},
+		{"DuplicateVehicleNumber", testDuplicateVehicleNumber},
+		{"VehicleWithUnknownDriverHelper", testVehicleWithUnknownDriverHelper},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.run(t, newStores(t))
+		})
+	}
+}
+
+func newDriver(aadhaar, mobile, license string) model.DriverHelper {
+	expiry := time.Now().AddDate(1, 0, 0).Truncate(24 * time.Hour)
+	return model.DriverHelper{UserType: "Driver", FirstName: "Ravi", LastName: "Kumar", BloodGroup: "O+",
+		AadharNumber: aadhaar, MobileNumber: mobile, LicenseNumber: license, LicenseExpiryDate: &expiry,
+		PoliceVerification: "No"}
+}
+
+func newVehicle(number string, driverHelperID uuid.UUID) model.Vehicle {
+	valid := time.Now().AddDate(1, 0, 0).Truncate(24 * time.Hour)
+	return model.Vehicle{VehicleNumber: number, RouteNumber: "R1", TotalStudentsCapacity: 30, SeatsAvailable: 30,
+		DriverHelperID: driverHelperID, InsuranceExpiryDate: valid, PollutionCertificateExpiryDate: valid,
+		FitnessCertificateExpiryDate: valid}
+}
+
+func createDriver(t *testing.T, stores model.Stores, aadhaar, mobile, license string) model.DriverHelper {
+	t.Helper()
+	dh := newDriver(aadhaar, mobile, license)
+	if err := stores.DriverHelpers.CreateDriverHelper(context.Background(), &dh); err != nil {
+		t.Fatalf("CreateDriverHelper: %v", err)
+	}
+	return dh
+}
+
+func testDriverHelperCRUD(t *testing.T, stores model.Stores) {
+	ctx := context.Background()
+	dh := createDriver(t, stores, "234567890124", "9876543210", "MH1220110012345")
+	if dh.ID == uuid.Nil || dh.Version != 1 {
+		t.Fatalf("created row = %+v, want an ID at version 1", dh)
+	}
+
+	got, err := stores.DriverHelpers.DriverHelperByID(ctx, dh.ID)
+	if err != nil {
+		t.Fatalf("DriverHelperByID: %v", err)
+	}
+	if got.AadharNumber != dh.AadharNumber || got.MobileNumber != dh.MobileNumber ||
+		got.LicenseNumber != dh.LicenseNumber || got.FirstName != dh.FirstName || got.Version != 1 {
+		t.Errorf("DriverHelperByID = %+v, want %+v", got, dh)
+	}
+	if got, err := stores.DriverHelpers.DriverHelperByMobileNumber(ctx, dh.MobileNumber); err != nil || got.ID != dh.ID {
+		t.Errorf("DriverHelperByMobileNumber = %+v, %v; want %s", got, err, dh.ID)
+	}
+
+	got.FirstName = "Ravindra"
+	if err := stores.DriverHelpers.UpdateDriverHelper(ctx, &got); err != nil {
+		t.Fatalf("UpdateDriverHelper: %v", err)
+	}
+	if got.Version != 2 {
+		t.Errorf("version after update = %d, want 2", got.Version)
+	}
+
+	patch := got
+	patch.LastName = "Sharma"
+	if err := stores.DriverHelpers.PatchDriverHelper(ctx, &patch, []string{"last_name"}); err != nil {
+		t.Fatalf("PatchDriverHelper: %v", err)
+	}
+	got, err = stores.DriverHelpers.DriverHelperByID(ctx, dh.ID)
+	if err != nil {
+		t.Fatalf("DriverHelperByID: %v", err)
+	}
+	if got.FirstName != "Ravindra" || got.LastName != "Sharma" || got.Version != 3 {
+		t.Errorf("row after update and patch = %+v, want Ravindra Sharma at version 3", got)
+	}
+
+	page, err := stores.DriverHelpers.DriverHelpers(ctx, model.DriverHelperFilter{})
+	if err != nil {
+		t.Fatalf("DriverHelpers: %v", err)
+	}
+	if page.Total != 1 || len(page.Items) != 1 || page.Items[0].ID != dh.ID {
+		t.Errorf("DriverHelpers = %+v, want only %s", page, dh.ID)
+	}
+
+	if err := stores.DriverHelpers.DeleteDriverHelper(ctx, dh.ID, got.Version, "left"); err != nil {
+		t.Fatalf("DeleteDriverHelper: %v", err)
+	}
+	if _, err := stores.DriverHelpers.DriverHelperByID(ctx, dh.ID); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("DriverHelperByID after delete = %v, want %v", err, model.ErrNotFound)
+	}
+}
+
+func testDriverHelperNotFound(t *testing.T, stores model.Stores) {
+	ctx := context.Background()
+	missing := uuid.New()
+
+	if _, err := stores.DriverHelpers.DriverHelperByID(ctx, missing); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("DriverHelperByID = %v, want %v", err, model.ErrNotFound)
+	}
+	if _, err := stores.DriverHelpers.DriverHelperByMobileNumber(ctx, "9999999999"); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("DriverHelperByMobileNumber = %v, want %v", err, model.ErrNotFound)
+	}
+	dh := newDriver("234567890124", "9876543210", "MH1220110012345")
+	dh.ID = missing
+	if err := stores.DriverHelpers.UpdateDriverHelper(ctx, &dh); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("UpdateDriverHelper = %v, want %v", err, model.ErrNotFound)
+	}
+	if err := stores.DriverHelpers.PatchDriverHelper(ctx, &dh, []string{"last_name"}); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("PatchDriverHelper = %v, want %v", err, model.ErrNotFound)
+	}
+	if err := stores.DriverHelpers.DeleteDriverHelper(ctx, missing, 0, "left"); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("DeleteDriverHelper = %v, want %v", err, model.ErrNotFound)
+	}
+	if _, err := stores.DriverHelpers.RestoreDriverHelper(ctx, missing); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("RestoreDriverHelper = %v, want %v", err, model.ErrNotFound)
+	}
+}
+
+func testDuplicateAadhaar(t *testing.T, stores model.Stores) {
+	ctx := context.Background()
+	first := createDriver(t, stores, "234567890124", "9876543210", "MH1220110012345")
+
+	dup := newDriver(first.AadharNumber, "9876543211", "MH1220110012346")
+	if err := stores.DriverHelpers.CreateDriverHelper(ctx, &dup); !errors.Is(err, model.ErrConflict) {
+		t.Errorf("CreateDriverHelper with a taken Aadhaar number = %v, want %v", err, model.ErrConflict)
+	}
+
+	second := createDriver(t, stores, "345678901238", "9876543212", "MH1220110012347")
+	second.AadharNumber = first.AadharNumber
+	if err := stores.DriverHelpers.UpdateDriverHelper(ctx, &second); !errors.Is(err, model.ErrConflict) {
+		t.Errorf("UpdateDriverHelper to a taken Aadhaar number = %v, want %v", err, model.ErrConflict)
+	}
+
+	if page, err := stores.DriverHelpers.DriverHelpers(ctx, model.DriverHelperFilter{}); err != nil || page.Total != 2 {
+		t.Errorf("DriverHelpers = %+v, %v; want the two rows created", page, err)
+	}
+}
+
+func testVehicleCRUD(t *testing.T, stores model.Stores) {
+	ctx := context.Background()
+	dh := createDriver(t, stores, "234567890124", "9876543210", "MH1220110012345")
+	v := newVehicle("DL 1P C 0001", dh.ID)
+	if err := stores.Vehicles.CreateVehicle(ctx, &v); err != nil {
+		t.Fatalf("CreateVehicle: %v", err)
+	}
+	if v.ID == uuid.Nil || v.Version != 1 {
+		t.Fatalf("created vehicle = %+v, want an ID at version 1", v)
+	}
+
+	got, err := stores.Vehicles.VehicleByID(ctx, v.ID)
+	if err != nil {
+		t.Fatalf("VehicleByID: %v", err)
+	}
+	if got.VehicleNumber != v.VehicleNumber || got.DriverHelperID != dh.ID || got.SeatsAvailable != 30 {
+		t.Errorf("VehicleByID = %+v, want %+v", got, v)
+	}
+	if got, err := stores.Vehicles.VehicleByNumber(ctx, "dl1pc0001"); err != nil || got.ID != v.ID {
+		t.Errorf("VehicleByNumber = %+v, %v; want %s", got, err, v.ID)
+	}
+	if vehicles, err := stores.Vehicles.VehiclesByDriverHelperID(ctx, dh.ID); err != nil || len(vehicles) != 1 {
+		t.Errorf("VehiclesByDriverHelperID = %+v, %v; want the vehicle", vehicles, err)
+	}
+
+	got.SeatsAvailable = 12
+	if err := stores.Vehicles.UpdateVehicle(ctx, &got); err != nil {
+		t.Fatalf("UpdateVehicle: %v", err)
+	}
+	patch := got
+	patch.RouteNumber = "R2"
+	if err := stores.Vehicles.PatchVehicle(ctx, &patch, []string{"route_number"}); err != nil {
+		t.Fatalf("PatchVehicle: %v", err)
+	}
+	got, err = stores.Vehicles.VehicleByID(ctx, v.ID)
+	if err != nil {
+		t.Fatalf("VehicleByID: %v", err)
+	}
+	if got.SeatsAvailable != 12 || got.RouteNumber != "R2" || got.Version != 3 {
+		t.Errorf("vehicle after update and patch = %+v, want 12 seats on R2 at version 3", got)
+	}
+
+	page, err := stores.Vehicles.Vehicles(ctx, model.VehicleFilter{RouteNumber: "R2"})
+	if err != nil {
+		t.Fatalf("Vehicles: %v", err)
+	}
+	if page.Total != 1 || len(page.Items) != 1 || page.Items[0].ID != v.ID {
+		t.Errorf("Vehicles on R2 = %+v, want only %s", page, v.ID)
+	}
+
+	if err := stores.Vehicles.DeleteVehicle(ctx, v.ID, got.Version, "sold"); err != nil {
+		t.Fatalf("DeleteVehicle: %v", err)
+	}
+	if _, err := stores.Vehicles.VehicleByID(ctx, v.ID); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("VehicleByID after delete = %v, want %v", err, model.ErrNotFound)
+	}
+}
+
+func testVehicleNotFound(t *testing.T, stores model.Stores) {
+	ctx := context.Background()
+	missing := uuid.New()
+
+	if _, err := stores.Vehicles.VehicleByID(ctx, missing); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("VehicleByID = %v, want %v", err, model.ErrNotFound)
+	}
+	if _, err := stores.Vehicles.VehicleByNumber(ctx, "DL1PC0001"); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("VehicleByNumber = %v, want %v", err, model.ErrNotFound)
+	}
+	v := newVehicle("DL1PC0001", uuid.Nil)
+	v.ID = missing
+	if err := stores.Vehicles.UpdateVehicle(ctx, &v); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("UpdateVehicle = %v, want %v", err, model.ErrNotFound)
+	}
+	if err := stores.Vehicles.PatchVehicle(ctx, &v, []string{"route_number"}); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("PatchVehicle = %v, want %v", err, model.ErrNotFound)
+	}
+	if err := stores.Vehicles.DeleteVehicle(ctx, missing, 0, "sold"); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("DeleteVehicle = %v, want %v", err, model.ErrNotFound)
+	}
+	if _, err := stores.Vehicles.RestoreVehicle(ctx, missing); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("RestoreVehicle = %v, want %v", err, model.ErrNotFound)
+	}
+}
+
+func testDuplicateVehicleNumber(t *testing.T, stores model.Stores) {
+	ctx := context.Background()
+	first := newVehicle("DL1PC0001", uuid.Nil)
+	if err := stores.Vehicles.CreateVehicle(ctx, &first); err != nil {
+		t.Fatalf("CreateVehicle: %v", err)
+	}
+
+	for _, number := range []string{"DL1PC0001", "dl-1p-c-0001", "DL 1PC 0001"} {
+		dup := newVehicle(number, uuid.Nil)
+		if err := stores.Vehicles.CreateVehicle(ctx, &dup); !errors.Is(err, model.ErrConflict) {
+			t.Errorf("CreateVehicle %q = %v, want %v", number, err, model.ErrConflict)
+		}
+	}
+
+	second := newVehicle("DL1PC0002", uuid.Nil)
+	if err := stores.Vehicles.CreateVehicle(ctx, &second); err != nil {
+		t.Fatalf("CreateVehicle: %v", err)
+	}
+	second.VehicleNumber = "DL 1P C 0001"
+	if err := stores.Vehicles.UpdateVehicle(ctx, &second); !errors.Is(err, model.ErrConflict) {
+		t.Errorf("UpdateVehicle to a taken number = %v, want %v", err, model.ErrConflict)
+	}
+	patch := second
+	patch.VehicleNumber = "dl1pc0001"
+	if err := stores.Vehicles.PatchVehicle(ctx, &patch, []string{"vehicle_number"}); !errors.Is(err, model.ErrConflict) {
+		t.Errorf("PatchVehicle to a taken number = %v, want %v", err, model.ErrConflict)
+	}
+}
+
+func testVehicleWithUnknownDriverHelper(t *testing.T, stores model.Stores) {
+	v := newVehicle("DL1PC0001", uuid.New())
+	if err := stores.Vehicles.CreateVehicle(context.Background(), &v); !errors.Is(err, model.ErrForeignKey) {
+		t.Errorf("CreateVehicle with an unknown driver/helper = %v, want %v", err, model.ErrForeignKey)
+	}
+}
@@ -0,0 +1,24 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// ValidateMailAddress rejects an address that is not a single bare mailbox
+// such as fleet@example.com, so it cannot smuggle extra headers or recipients
+// into a message.
+func ValidateMailAddress(addr string) error {
+	if strings.ContainsAny(addr, "\r\n") {
+		return fmt.Errorf("invalid mail address %q: must not contain line breaks", addr)
+	}
+	parsed, err := mail.ParseAddress(addr)
+	if err != nil {
+		return fmt.Errorf("invalid mail address %q: %w", addr, err)
+	}
+	if parsed.Name != "" || parsed.Address != addr {
+		return fmt.Errorf("invalid mail address %q: must be a bare address such as fleet@example.com", addr)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package validation
+
+import "testing"
+
+func TestValidateMailAddress(t *testing.T) {
+	tests := []struct {
+		addr    string
+		wantErr bool
+	}{
+		{addr: "fleet@example.com"},
+		{addr: "transport.office@school.example.in"},
+		{addr: "", wantErr: true},
+		{addr: "fleet", wantErr: true},
+		{addr: "Fleet <fleet@example.com>", wantErr: true},
+		{addr: "fleet@example.com, other@example.com", wantErr: true},
+		{addr: "fleet@example.com\r\nBcc: attacker@example.com", wantErr: true},
+		{addr: "fleet@example.com\nBcc: attacker@example.com", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if err := ValidateMailAddress(tt.addr); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateMailAddress(%q) = %v, want error %v", tt.addr, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Registration is an Indian vehicle registration number split into its parts.
+type Registration struct {
+	// State is the state or union territory code, or "BH" for Bharat series numbers.
+	State string
+	// RTO is the two-digit code of the registering office; for Bharat series
+	// numbers it is the year of registration.
+	RTO string
+	// Series is the letter series within the RTO, which may be empty.
+	Series string
+	// Number is the four-digit vehicle number.
+	Number string
+}
+
+var (
+	stateRegistration  = regexp.MustCompile(`^([A-Z]{2})(\d{1,2})([A-Z]{0,3})(\d{1,4})$`)
+	bharatRegistration = regexp.MustCompile(`^(\d{2})BH(\d{4})([A-Z]{1,2})$`)
+	// seriesless splits a registration with no series at the separator
+	// between its RTO code and number, e.g. "DL 1 1234".
+	seriesless = regexp.MustCompile(`^([A-Z]{2})[ -]*(\d{1,2})[ -]+(\d{1,4})$`)
+)
+
+// ParseRegistration parses a registration number however it is spaced,
+// hyphenated or cased: "DL 1P C 1234", "dl1pc1234" and "DL-01PC-1234" are the
+// same vehicle. It accepts state registrations (state code, RTO code, series
+// and number) and Bharat series registrations such as "22 BH 1234 AA".
+//
+// Without a series nothing but a separator tells the RTO code from the
+// number: "DL11234" could be RTO 1 number 1234 or RTO 11 number 234. Such
+// numbers must be written with the RTO code and number apart, as in
+// "DL 1 1234", unless their digits only split one way (2 or 6 of them).
+func ParseRegistration(number string) (Registration, error) {
+	compact := strings.ToUpper(stripSeparators(number))
+	if m := bharatRegistration.FindStringSubmatch(compact); m != nil {
+		return Registration{State: "BH", RTO: m[1], Series: m[3], Number: m[2]}, nil
+	}
+
+	m := stateRegistration.FindStringSubmatch(compact)
+	if m == nil {
+		return Registration{}, errors.New("must be a state code, RTO code, series and number, e.g. DL 1P C 1234, or a Bharat series number, e.g. 22 BH 1234 AA")
+	}
+	if !stateCodes[m[1]] {
+		return Registration{}, fmt.Errorf("unknown state code %s", m[1])
+	}
+	rto, num := m[2], m[4]
+	if m[3] == "" {
+		var err error
+		if rto, num, err = splitSeriesless(number, m[1], compact[2:]); err != nil {
+			return Registration{}, err
+		}
+	}
+	if strings.Trim(num, "0") == "" {
+		return Registration{}, errors.New("vehicle number must not be 0000")
+	}
+	return Registration{State: m[1], RTO: leftPad(rto, 2), Series: m[3], Number: leftPad(num, 4)}, nil
+}
+
+// splitSeriesless splits digits, the RTO code and number of a registration
+// with no series, where number separates them or they only split one way.
+func splitSeriesless(number, state, digits string) (rto, num string, err error) {
+	if m := seriesless.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(number))); m != nil {
+		return m[2], m[3], nil
+	}
+	switch len(digits) {
+	case 2:
+		return digits[:1], digits[1:], nil
+	case 6:
+		return digits[:2], digits[2:], nil
+	}
+	return "", "", fmt.Errorf("RTO code and number are ambiguous; separate them, e.g. %s %s %s or %s %s %s",
+		state, digits[:1], digits[1:], state, digits[:2], digits[2:])
+}
+
+// Canonical returns the form registration numbers are compared in: the parts
+// upper-cased and zero-padded with no separators, e.g. DL01PC1234 or 22BH1234AA.
+func (r Registration) Canonical() string {
+	if r.State == "BH" {
+		return r.RTO + "BH" + r.Number + r.Series
+	}
+	return r.State + r.RTO + r.Series + r.Number
+}
+
+// CanonicalRegistration returns the canonical form of a registration number.
+func CanonicalRegistration(number string) (string, error) {
+	r, err := ParseRegistration(number)
+	if err != nil {
+		return "", err
+	}
+	return r.Canonical(), nil
+}
+
+func leftPad(digits string, width int) string {
+	return strings.Repeat("0", width-len(digits)) + digits
+}
@@ -0,0 +1,101 @@
+package validation
+
+import (
+	"io/fs"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/migrations"
+)
+
+func TestCanonicalRegistration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		// The spellings of one bus that used to bypass the unique constraint.
+		{in: "DL 1P C 1234", want: "DL01PC1234"},
+		{in: "dl1pc1234", want: "DL01PC1234"},
+		{in: "DL-1PC-1234", want: "DL01PC1234"},
+		{in: "DL-01PC-1234", want: "DL01PC1234"},
+		{in: "MH 12 AB 5", want: "MH12AB0005"},
+		{in: "DL\t1P\tC 1234\n", want: "DL01PC1234"},
+
+		// Bharat series.
+		{in: "22 BH 1234 AA", want: "22BH1234AA"},
+		{in: "22bh1234a", want: "22BH1234A"},
+		{in: "22-BH-1234-AA", want: "22BH1234AA"},
+
+		// Without a series, separators decide where the RTO code ends.
+		{in: "DL 1 1234", want: "DL011234"},
+		{in: "DL 11 234", want: "DL110234"},
+		{in: "DL-11-234", want: "DL110234"},
+		{in: "DL1 1234", want: "DL011234"},
+		// ... unless the digits only split one way.
+		{in: "DL111234", want: "DL111234"},
+		{in: "DL11", want: "DL010001"},
+		// Otherwise the number is ambiguous.
+		{in: "DL11234", wantErr: true},
+		{in: "DL 11234", wantErr: true},
+		{in: "DL1234", wantErr: true},
+		{in: "DL123", wantErr: true},
+
+		{in: "XX 1 PC 1234", wantErr: true}, // unknown state code
+		{in: "DL 1 PC 0000", wantErr: true},
+		{in: "DL 1 PC 12345", wantErr: true},
+		{in: "DL 123 PC 1234", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := CanonicalRegistration(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CanonicalRegistration(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("CanonicalRegistration(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRegistrationParts(t *testing.T) {
+	got, err := ParseRegistration("22 BH 1234 AA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Registration{State: "BH", RTO: "22", Series: "AA", Number: "1234"}
+	if got != want {
+		t.Errorf("ParseRegistration = %+v, want %+v", got, want)
+	}
+}
+
+// TestStripSeparatorsMatchesMigration pins stripSeparators to the separators
+// migration 8 strips when backfilling vehicle_number_canonical, so existing
+// rows are canonicalized the way new writes are.
+func TestStripSeparatorsMatchesMigration(t *testing.T) {
+	sql, err := fs.ReadFile(migrations.FS, "8_vehicle_number_canonical.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const strip = `UPPER(REGEXP_REPLACE(vehicle_number, '[\s-]', '', 'g'))`
+	if !strings.Contains(string(sql), strip) {
+		t.Fatalf("migration 8 no longer backfills with %s; update stripSeparators and this test together", strip)
+	}
+
+	// PostgreSQL's \s is [[:space:]], which Go's RE2 spells the same way.
+	postgres := regexp.MustCompile(`[[:space:]-]`)
+	for r := rune(0); r < 0x80; r++ {
+		in := "DL" + string(r) + "1P" + string(r) + "C-1234"
+		if got, want := stripSeparators(in), postgres.ReplaceAllString(in, ""); got != want {
+			t.Errorf("stripSeparators(%q) = %q, want %q as in migration 8", in, got, want)
+		}
+	}
+	for _, in := range []string{"DL\u00a01PC1234", "DL\u20031PC1234", "DL_1PC_1234", "DL.1PC.1234"} {
+		if got := stripSeparators(in); got != in {
+			t.Errorf("stripSeparators(%q) = %q, want it left alone as in migration 8", in, got)
+		}
+	}
+}
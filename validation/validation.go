@@ -0,0 +1,170 @@
+// Package validation checks and normalizes the Indian identity, contact and
+// vehicle registration numbers the roster records, and the mail addresses
+// reminders are sent from and to.
+//
+// The Normalize functions accept the forms people commonly write these
+// numbers in and return the canonical form that is stored, or an error that
+// completes the sentence "invalid <field>: <value>; ...".
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NormalizeAadhaar strips the spaces and hyphens an Aadhaar number is usually
+// printed with and checks the result is a 12-digit number that does not start
+// with 0 or 1 and whose last digit is its Verhoeff check digit.
+func NormalizeAadhaar(aadhaar string) (string, error) {
+	digits := stripSeparators(aadhaar)
+	if len(digits) != 12 || !allDigits(digits) {
+		return "", errors.New("must be a 12-digit number")
+	}
+	if digits[0] == '0' || digits[0] == '1' {
+		return "", errors.New("must not start with 0 or 1")
+	}
+	if !verhoeffValid(digits) {
+		return "", errors.New("check digit does not match; the number is mistyped")
+	}
+	return digits, nil
+}
+
+// NormalizeMobile returns the 10-digit form of an Indian mobile number,
+// dropping spaces, hyphens and a +91 (or 91) country code. Mobile numbers
+// start with 6, 7, 8 or 9.
+func NormalizeMobile(mobile string) (string, error) {
+	digits := stripSeparators(mobile)
+	switch {
+	case strings.HasPrefix(digits, "+91"):
+		digits = digits[3:]
+	case len(digits) == 12 && strings.HasPrefix(digits, "91"):
+		digits = digits[2:]
+	}
+	if len(digits) != 10 || !allDigits(digits) {
+		return "", errors.New("must be a 10-digit number, optionally prefixed with +91")
+	}
+	if digits[0] < '6' {
+		return "", errors.New("must start with 6, 7, 8 or 9")
+	}
+	return digits, nil
+}
+
+// stateCodes are the state and union territory codes that prefix driving
+// licence and registration numbers, including codes still found on those
+// issued before states were renamed or merged.
+var stateCodes = map[string]bool{
+	"AN": true, "AP": true, "AR": true, "AS": true, "BR": true, "CG": true, "CH": true, "DD": true,
+	"DL": true, "DN": true, "GA": true, "GJ": true, "HP": true, "HR": true, "JH": true, "JK": true,
+	"KA": true, "KL": true, "LA": true, "LD": true, "MH": true, "ML": true, "MN": true, "MP": true,
+	"MZ": true, "NL": true, "OD": true, "OR": true, "PB": true, "PY": true, "RJ": true, "SK": true,
+	"TN": true, "TR": true, "TS": true, "UA": true, "UK": true, "UP": true, "WB": true,
+}
+
+// NormalizeLicense returns the 15-character form of a driving licence number:
+// a state code, a 2-digit RTO code, the 4-digit year of issue and a 7-digit
+// serial number, e.g. MH1220110012345. Spaces and hyphens are dropped and
+// letters upper-cased, so "mh-12 2011 0012345" is accepted too.
+func NormalizeLicense(license string) (string, error) {
+	normalized := strings.ToUpper(stripSeparators(license))
+	if len(normalized) != 15 || !allDigits(normalized[2:]) {
+		return "", errors.New("must be a state code, 2-digit RTO code, 4-digit year of issue and 7-digit number, e.g. MH1220110012345")
+	}
+	if !stateCodes[normalized[:2]] {
+		return "", fmt.Errorf("unknown state code %s", normalized[:2])
+	}
+	if year, _ := strconv.Atoi(normalized[4:8]); year < 1950 || year > time.Now().Year() {
+		return "", fmt.Errorf("year of issue %d is not valid", year)
+	}
+	return normalized, nil
+}
+
+// LicenseExpiry rejects a license that has already expired as of now.
+func LicenseExpiry(expiry, now time.Time) error {
+	if Expired(expiry, now) {
+		return errors.New("must not be in the past")
+	}
+	return nil
+}
+
+// Expiry and renewal dates are calendar days, stored as midnight UTC. A
+// document is valid through the whole of its expiry date and expired from
+// the next day, as reckoned in UTC; every expiry check goes through these
+// functions so they all agree on when a day starts.
+
+// StartOfDay returns midnight UTC at the start of t's day in UTC.
+func StartOfDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Expired reports whether a document expiring on the day of due has expired as of now.
+func Expired(due, now time.Time) bool {
+	return StartOfDay(due).Before(StartOfDay(now))
+}
+
+// DaysUntil counts the days from the day of now to the day of due, negative once due has passed.
+func DaysUntil(due, now time.Time) int {
+	return int(StartOfDay(due).Sub(StartOfDay(now)).Hours() / 24)
+}
+
+// separators are the characters stripSeparators removes: hyphens and the ASCII
+// whitespace PostgreSQL's \s matches, as stripped by migration 8's backfill.
+const separators = "- \t\n\v\f\r"
+
+func stripSeparators(s string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(separators, r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func allDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// Verhoeff tables: multiplication in the dihedral group D5, and the
+// permutation applied to each digit according to its position.
+var (
+	verhoeffD = [10][10]byte{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 2, 3, 4, 0, 6, 7, 8, 9, 5},
+		{2, 3, 4, 0, 1, 7, 8, 9, 5, 6},
+		{3, 4, 0, 1, 2, 8, 9, 5, 6, 7},
+		{4, 0, 1, 2, 3, 9, 5, 6, 7, 8},
+		{5, 9, 8, 7, 6, 0, 4, 3, 2, 1},
+		{6, 5, 9, 8, 7, 1, 0, 4, 3, 2},
+		{7, 6, 5, 9, 8, 2, 1, 0, 4, 3},
+		{8, 7, 6, 5, 9, 3, 2, 1, 0, 4},
+		{9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+	}
+	verhoeffP = [8][10]byte{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 5, 7, 6, 2, 8, 3, 0, 9, 4},
+		{5, 8, 0, 3, 7, 9, 6, 1, 4, 2},
+		{8, 9, 1, 6, 0, 4, 3, 5, 2, 7},
+		{9, 4, 5, 3, 1, 2, 6, 8, 7, 0},
+		{4, 2, 8, 6, 5, 7, 3, 9, 0, 1},
+		{2, 7, 9, 3, 8, 0, 6, 4, 1, 5},
+		{7, 0, 4, 6, 9, 1, 3, 2, 5, 8},
+	}
+)
+
+// verhoeffValid reports whether the last of digits is the Verhoeff check
+// digit of the others.
+func verhoeffValid(digits string) bool {
+	var c byte
+	for i := 0; i < len(digits); i++ {
+		c = verhoeffD[c][verhoeffP[i%8][digits[len(digits)-1-i]-'0']]
+	}
+	return c == 0
+}
@@ -0,0 +1,170 @@
+package validation
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestNormalizeAadhaar(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "234567890124", want: "234567890124"},
+		{in: "3456 7890 1238", want: "345678901238"},
+		{in: "3456-7890-1238", want: "345678901238"},
+		{in: "234567890125", wantErr: true},  // last digit mistyped
+		{in: "234567990124", wantErr: true},  // middle digit mistyped
+		{in: "324567890124", wantErr: true},  // first two digits transposed
+		{in: "234567890214", wantErr: true},  // check digit transposed with its neighbour
+		{in: "134567890124", wantErr: true},  // starts with 1
+		{in: "034567890124", wantErr: true},  // starts with 0
+		{in: "23456789012", wantErr: true},   // 11 digits
+		{in: "2345678901245", wantErr: true}, // 13 digits
+		{in: "23456789012A", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := NormalizeAadhaar(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeAadhaar(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeAadhaar(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerhoeffDetectsSingleDigitErrors(t *testing.T) {
+	const valid = "234567890124"
+	for i := range valid {
+		for d := byte('0'); d <= '9'; d++ {
+			if d == valid[i] {
+				continue
+			}
+			typo := valid[:i] + string(d) + valid[i+1:]
+			if verhoeffValid(typo) {
+				t.Errorf("verhoeffValid(%q) = true for a typo of %q", typo, valid)
+			}
+		}
+	}
+}
+
+func TestVerhoeffDetectsAdjacentTranspositions(t *testing.T) {
+	const valid = "345678901238"
+	for i := 0; i+1 < len(valid); i++ {
+		if valid[i] == valid[i+1] {
+			continue
+		}
+		swapped := valid[:i] + string(valid[i+1]) + string(valid[i]) + valid[i+2:]
+		if verhoeffValid(swapped) {
+			t.Errorf("verhoeffValid(%q) = true for a transposition of %q", swapped, valid)
+		}
+	}
+}
+
+func TestNormalizeMobile(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "9876543210", want: "9876543210"},
+		{in: "+919876543210", want: "9876543210"},
+		{in: "+91 98765 43210", want: "9876543210"},
+		{in: "919876543210", want: "9876543210"},
+		{in: "98765 43210", want: "9876543210"},
+		{in: "98765-43210", want: "9876543210"},
+		{in: "6000000000", want: "6000000000"},
+		{in: "5876543210", wantErr: true},
+		{in: "4876543210", wantErr: true},
+		{in: "3876543210", wantErr: true},
+		{in: "2876543210", wantErr: true},
+		{in: "1876543210", wantErr: true},
+		{in: "0876543210", wantErr: true},
+		{in: "+915876543210", wantErr: true},
+		{in: "987654321", wantErr: true},
+		{in: "98765432100", wantErr: true},
+		{in: "98765x3210", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := NormalizeMobile(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeMobile(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeMobile(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeLicense(t *testing.T) {
+	nextYear := fmt.Sprintf("MH12%d0012345", time.Now().Year()+1)
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "MH1220110012345", want: "MH1220110012345"},
+		{in: "mh-12 2011 0012345", want: "MH1220110012345"},
+		{in: "DL0119500000001", want: "DL0119500000001"},
+		{in: "XX1220110012345", wantErr: true}, // unknown state code
+		{in: "ZZ1220110012345", wantErr: true}, // unknown state code
+		{in: "MH1219490012345", wantErr: true}, // issued before 1950
+		{in: nextYear, wantErr: true},          // issued in the future
+		{in: "MH122011001234", wantErr: true},  // 14 characters
+		{in: "MH12201100123456", wantErr: true},
+		{in: "MH12A0110012345", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := NormalizeLicense(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeLicense(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeLicense(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpired(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+1800)
+	due := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		now     time.Time
+		expired bool
+		days    int
+	}{
+		{name: "day before", now: time.Date(2025, 3, 9, 23, 59, 0, 0, time.UTC), days: 1},
+		{name: "start of due date", now: due, days: 0},
+		{name: "end of due date", now: time.Date(2025, 3, 10, 23, 59, 59, 0, time.UTC), days: 0},
+		{name: "day after", now: time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC), expired: true, days: -1},
+		// 02:00 in India on the 11th is still the 10th in UTC.
+		{name: "due date in UTC, next day locally", now: time.Date(2025, 3, 11, 2, 0, 0, 0, ist), days: 0},
+		{name: "next day in UTC", now: time.Date(2025, 3, 11, 6, 0, 0, 0, ist), expired: true, days: -1},
+		{name: "month ahead", now: time.Date(2025, 2, 8, 12, 0, 0, 0, time.UTC), days: 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Expired(due, tt.now); got != tt.expired {
+				t.Errorf("Expired(%v, %v) = %v, want %v", due, tt.now, got, tt.expired)
+			}
+			if got := DaysUntil(due, tt.now); got != tt.days {
+				t.Errorf("DaysUntil(%v, %v) = %d, want %d", due, tt.now, got, tt.days)
+			}
+			if err := LicenseExpiry(due, tt.now); (err != nil) != tt.expired {
+				t.Errorf("LicenseExpiry(%v, %v) = %v, want error %v", due, tt.now, err, tt.expired)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/auth"
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+type APIKeyHandler struct {
+	Store model.APIKeyStore
+}
+
+func NewAPIKeyHandler(store model.APIKeyStore) *APIKeyHandler {
+	return &APIKeyHandler{Store: store}
+}
+
+type mintAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (r mintAPIKeyRequest) validate(principal auth.Principal, now time.Time) error {
+	verr := &model.ValidationError{}
+	if strings.TrimSpace(r.Name) == "" {
+		verr.Add("name", "name is required")
+	}
+	auth.ValidateScopes(verr, r.Scopes, principal)
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(now) {
+		verr.Add("expires_at", "invalid expires_at: %s; must be in the future", r.ExpiresAt.Format(time.RFC3339))
+	}
+	return verr.Err()
+}
+
+// MintAPIKey creates a key and returns it in full; only its hash is kept, so
+// this response is the one chance to record it.
+func (h *APIKeyHandler) MintAPIKey(c *gin.Context) {
+	var req mintAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	principal, _ := auth.FromContext(c.Request.Context())
+	now := time.Now()
+	if err := req.validate(principal, now); err != nil {
+		respondError(c, err, "Failed to create API key")
+		return
+	}
+
+	secret, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		respondError(c, err, "Failed to create API key")
+		return
+	}
+	key := model.APIKey{
+		ID:        uuid.New(),
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: model.Actor(c.Request.Context()),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := h.Store.CreateAPIKey(c.Request.Context(), &key); err != nil {
+		respondError(c, err, "Failed to create API key")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "API key created successfully", "api_key": key, "key": secret})
+}
+
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	keys, err := h.Store.APIKeys(c.Request.Context())
+	if err != nil {
+		respondError(c, err, "Failed to fetch API keys")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// RotateAPIKey replaces a key's secret; the old secret stops working at once.
+func (h *APIKeyHandler) RotateAPIKey(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	secret, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		respondError(c, err, "Failed to rotate API key")
+		return
+	}
+	key, err := h.Store.RotateAPIKey(c.Request.Context(), id, prefix, hash)
+	if err != nil {
+		respondError(c, err, "Failed to rotate API key")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key rotated successfully", "api_key": key, "key": secret})
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	key, err := h.Store.RevokeAPIKey(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "Failed to revoke API key")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully", "api_key": key})
+}
@@ -0,0 +1,76 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/auth"
+	"github.com/arjunsaxaena/driver_vehicle_profile/memstore"
+)
+
+func TestMintAPIKeyScopesBoundedByCaller(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name   string
+		caller auth.Principal
+		scopes string
+		want   int
+	}{
+		{
+			name:   "caller holds every scope",
+			caller: auth.Principal{Subject: "admin", Roles: []auth.Role{auth.RoleAdmin}},
+			scopes: `["roster:read","pii:read"]`,
+			want:   http.StatusCreated,
+		},
+		{
+			name:   "caller lacks pii:read",
+			caller: auth.Principal{Subject: "keys", Scopes: []auth.Permission{auth.PermAPIKeysManage, auth.PermRosterRead}},
+			scopes: `["roster:read","pii:read"]`,
+			want:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "caller lacks roster:write",
+			caller: auth.Principal{Subject: "keys", Scopes: []auth.Permission{auth.PermAPIKeysManage, auth.PermRosterRead}},
+			scopes: `["roster:write"]`,
+			want:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "caller's own subset",
+			caller: auth.Principal{Subject: "keys", Scopes: []auth.Permission{auth.PermAPIKeysManage, auth.PermRosterRead}},
+			scopes: `["roster:read"]`,
+			want:   http.StatusCreated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memstore.NewAPIKeyStore(memstore.NewDB())
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), tt.caller))
+			})
+			router.POST("/api_keys", Require(auth.PermAPIKeysManage), NewAPIKeyHandler(store).MintAPIKey)
+
+			body := `{"name":"erp","scopes":` + tt.scopes + `}`
+			req := httptest.NewRequest(http.MethodPost, "/api_keys", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.want, rec.Body)
+			}
+			keys, err := store.APIKeys(context.Background())
+			if err != nil {
+				t.Fatalf("APIKeys: %v", err)
+			}
+			if created := tt.want == http.StatusCreated; (len(keys) == 1) != created {
+				t.Errorf("stored %d keys, want created = %v", len(keys), created)
+			}
+		})
+	}
+}
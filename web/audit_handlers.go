@@ -0,0 +1,55 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+type AuditHandler struct {
+	Store model.AuditStore
+}
+
+func NewAuditHandler(store model.AuditStore) *AuditHandler {
+	return &AuditHandler{Store: store}
+}
+
+func (h *AuditHandler) GetDriverHelperHistory(c *gin.Context) {
+	h.history(c, model.EntityDriverHelper)
+}
+
+func (h *AuditHandler) GetVehicleHistory(c *gin.Context) {
+	h.history(c, model.EntityVehicle)
+}
+
+// history lists the audit entries of the entity named by the :id parameter.
+// Entries outlive the entity itself, so a purged ID still has a history.
+func (h *AuditHandler) history(c *gin.Context, entityType string) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var filter model.AuditFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": err.Error()})
+		return
+	}
+	if err := filter.Normalize(); err != nil {
+		respondBadRequest(c, err, "Invalid query parameters")
+		return
+	}
+
+	page, err := h.Store.AuditHistory(c.Request.Context(), entityType, id, filter)
+	if err != nil {
+		respondError(c, err, "Failed to retrieve history")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"history": redactAuditEntries(c, page.Items), "next_cursor": page.NextCursor, "total": page.Total})
+}
@@ -0,0 +1,92 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/auth"
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+// Authenticate rejects requests without a valid "Authorization: Bearer" token
+// or "Authorization: ApiKey" key, and makes the caller it names the actor of
+// the request.
+func Authenticate(verifier *auth.Verifier, apiKeys *auth.APIKeyAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheme, credentials, _ := strings.Cut(c.GetHeader("Authorization"), " ")
+		credentials = strings.TrimSpace(credentials)
+
+		var principal auth.Principal
+		var err error
+		switch {
+		case credentials == "":
+			err = errMissingCredentials
+		case strings.EqualFold(scheme, "Bearer"):
+			principal, err = verifier.Verify(credentials)
+		case strings.EqualFold(scheme, "ApiKey"):
+			principal, err = apiKeys.Authenticate(c.Request.Context(), credentials)
+		default:
+			err = errMissingCredentials
+		}
+
+		switch {
+		case errors.Is(err, errMissingCredentials):
+			c.Header("WWW-Authenticate", "Bearer, ApiKey")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			return
+		case errors.Is(err, auth.ErrInvalidToken), errors.Is(err, auth.ErrInvalidAPIKey):
+			c.Header("WWW-Authenticate", scheme+` error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials", "details": err.Error()})
+			return
+		case err != nil:
+			respondError(c, err, "Failed to authenticate")
+			c.Abort()
+			return
+		}
+
+		ctx := auth.WithPrincipal(c.Request.Context(), principal)
+		c.Request = c.Request.WithContext(model.WithActor(ctx, principal.Subject))
+		c.Next()
+	}
+}
+
+var errMissingCredentials = errors.New("missing credentials")
+
+// Unauthenticated stands in for Authenticate when authentication is disabled,
+// granting every caller the roster and audit permissions only. Personal data
+// stays masked, and anonymous callers can neither mint API keys, which would
+// outlive the switch to authentication, nor subscribe to roster events.
+func Unauthenticated() gin.HandlerFunc {
+	var scopes []auth.Permission
+	for _, perm := range auth.Permissions {
+		switch perm {
+		case auth.PermPIIRead, auth.PermAPIKeysManage, auth.PermWebhooksManage:
+		default:
+			scopes = append(scopes, perm)
+		}
+	}
+	return func(c *gin.Context) {
+		principal := auth.Principal{Subject: model.Actor(c.Request.Context()), Scopes: scopes}
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// Require lets the request through only if its caller has perm.
+func Require(perm auth.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := auth.FromContext(c.Request.Context())
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			return
+		}
+		if !principal.Can(perm) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden", "details": "missing permission " + string(perm)})
+			return
+		}
+		c.Next()
+	}
+}
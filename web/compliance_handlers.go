@@ -0,0 +1,116 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/compliance"
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+type ComplianceHandler struct {
+	Stores model.Stores
+	Engine compliance.Engine
+}
+
+func NewComplianceHandler(stores model.Stores, engine compliance.Engine) *ComplianceHandler {
+	return &ComplianceHandler{Stores: stores, Engine: engine}
+}
+
+// MaxWindowDays bounds the day-count query parameters, keeping the windows they
+// describe far from overflowing a time.Duration.
+const MaxWindowDays = 3650
+
+// engine returns the handler's engine with ExpiringWithin overridden by the
+// expiring_within_days query parameter.
+func (h *ComplianceHandler) engine(c *gin.Context) (compliance.Engine, bool) {
+	engine := h.Engine
+	if days := c.Query("expiring_within_days"); days != "" {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 || n > MaxWindowDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid expiring_within_days; must be an integer from 0 to %d", MaxWindowDays)})
+			return engine, false
+		}
+		engine.ExpiringWithin = time.Duration(n) * 24 * time.Hour
+	}
+	return engine, true
+}
+
+// filterStatus keeps the reports with the status named by the status query parameter, if any.
+func filterStatus(c *gin.Context, reports []compliance.Report) ([]compliance.Report, error) {
+	if reports == nil {
+		reports = []compliance.Report{}
+	}
+	s := c.Query("status")
+	if s == "" {
+		return reports, nil
+	}
+	status, err := compliance.ParseStatus(s)
+	if err != nil {
+		return nil, err
+	}
+	return compliance.WithStatus(reports, status), nil
+}
+
+// GetVehicleCompliance checks every vehicle's insurance, pollution and fitness certificates.
+func (h *ComplianceHandler) GetVehicleCompliance(c *gin.Context) {
+	engine, ok := h.engine(c)
+	if !ok {
+		return
+	}
+
+	reports, err := engine.Vehicles(c.Request.Context(), h.Stores.Vehicles, time.Now())
+	if err == nil {
+		reports, err = filterStatus(c, reports)
+	}
+	if err != nil {
+		respondError(c, err, "Failed to check vehicle compliance")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"vehicles": reports})
+}
+
+// GetDriverHelperCompliance checks every driver's license and every driver/helper's police verification.
+func (h *ComplianceHandler) GetDriverHelperCompliance(c *gin.Context) {
+	engine, ok := h.engine(c)
+	if !ok {
+		return
+	}
+
+	reports, err := engine.DriverHelpers(c.Request.Context(), h.Stores.DriverHelpers, time.Now())
+	if err == nil {
+		reports, err = filterStatus(c, reports)
+	}
+	if err != nil {
+		respondError(c, err, "Failed to check driver/helper compliance")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"driver_helpers": reports})
+}
+
+func (h *ComplianceHandler) GetComplianceSummary(c *gin.Context) {
+	engine, ok := h.engine(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	vehicles, err := engine.Vehicles(c.Request.Context(), h.Stores.Vehicles, now)
+	if err != nil {
+		respondError(c, err, "Failed to check vehicle compliance")
+		return
+	}
+	driverHelpers, err := engine.DriverHelpers(c.Request.Context(), h.Stores.DriverHelpers, now)
+	if err != nil {
+		respondError(c, err, "Failed to check driver/helper compliance")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"summary": compliance.Summarize(vehicles, driverHelpers)})
+}
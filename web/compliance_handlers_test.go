@@ -0,0 +1,45 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/compliance"
+	"github.com/arjunsaxaena/driver_vehicle_profile/memstore"
+)
+
+func TestComplianceExpiringWithinDays(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewComplianceHandler(memstore.NewStores(memstore.NewDB()), compliance.Engine{ExpiringWithin: 30 * 24 * time.Hour})
+	router.GET("/compliance/vehicles", handler.GetVehicleCompliance)
+
+	tests := []struct {
+		days string
+		want int
+	}{
+		{days: "", want: http.StatusOK},
+		{days: "0", want: http.StatusOK},
+		{days: "90", want: http.StatusOK},
+		{days: strconv.Itoa(MaxWindowDays), want: http.StatusOK},
+		{days: strconv.Itoa(MaxWindowDays + 1), want: http.StatusBadRequest},
+		{days: "106752", want: http.StatusBadRequest}, // overflows a time.Duration
+		{days: "9223372036854775807", want: http.StatusBadRequest},
+		{days: "-1", want: http.StatusBadRequest},
+		{days: "ten", want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.days, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/compliance/vehicles?expiring_within_days="+tt.days, nil))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
@@ -1,23 +1,26 @@
 package web
 
 import (
-	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
-	"github.com/arjunsaxaena/driver_vehicle_profile/controllers"
 	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+	"github.com/arjunsaxaena/driver_vehicle_profile/validation"
 )
 
 type Handler struct {
-	Store *controllers.DBDriverHelperStore
+	Store                  model.DriverHelperStore
+	VerificationRenewalAge time.Duration
+	LicenseExpiringWithin  time.Duration
+	// RequireIfMatch rejects writes that do not name the version they were based on.
+	RequireIfMatch bool
 }
 
-func NewHandler(store *controllers.DBDriverHelperStore) *Handler {
-	return &Handler{Store: store}
+func NewHandler(store model.DriverHelperStore) *Handler {
+	return &Handler{Store: store, VerificationRenewalAge: DefaultVerificationRenewalAge, LicenseExpiringWithin: DefaultLicenseExpiringWithin}
 }
 
 func (h *Handler) GetDriverHelperByID(c *gin.Context) {
@@ -28,44 +31,54 @@ func (h *Handler) GetDriverHelperByID(c *gin.Context) {
 		return
 	}
 
-	dh, err := h.Store.DriverHelperByID(id)
+	dh, err := h.Store.DriverHelperByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Driver/Helper not found"})
+		respondError(c, err, "Failed to retrieve driver/helper")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"driver_helper": dh})
+	setETag(c, dh.Version)
+	c.JSON(http.StatusOK, gin.H{"driver_helper": redactDriverHelper(c, dh)})
 }
 
 func (h *Handler) GetAllDriverHelpers(c *gin.Context) {
-	dhs, err := h.Store.DriverHelpers()
+	var filter model.DriverHelperFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": err.Error()})
+		return
+	}
+	if err := filter.Normalize(); err != nil {
+		respondBadRequest(c, err, "Invalid query parameters")
+		return
+	}
+
+	page, err := h.Store.DriverHelpers(c.Request.Context(), filter)
 	if err != nil {
-		log.Printf("Error retrieving driver/helpers: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve driver or helpers"})
+		respondError(c, err, "Failed to retrieve driver or helpers")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"driver_helpers": dhs})
+	c.JSON(http.StatusOK, gin.H{"driver_helpers": redactDriverHelpers(c, page.Items), "next_cursor": page.NextCursor, "total": page.Total})
 }
 
 func (h *Handler) GetDrivers(c *gin.Context) {
-	drivers, err := h.Store.Drivers()
+	drivers, err := h.Store.Drivers(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve drivers"})
+		respondError(c, err, "Failed to retrieve drivers")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"drivers": drivers})
+	c.JSON(http.StatusOK, gin.H{"drivers": redactDriverHelpers(c, drivers)})
 }
 
 func (h *Handler) GetHelpers(c *gin.Context) {
-	helpers, err := h.Store.Helpers()
+	helpers, err := h.Store.Helpers(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve helpers"})
+		respondError(c, err, "Failed to retrieve helpers")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"helpers": helpers})
+	c.JSON(http.StatusOK, gin.H{"helpers": redactDriverHelpers(c, helpers)})
 }
 
 func (h *Handler) CreateDriverHelper(c *gin.Context) {
@@ -79,12 +92,13 @@ func (h *Handler) CreateDriverHelper(c *gin.Context) {
 	dh.CreatedAt = time.Now()
 	dh.UpdatedAt = time.Now()
 
-	if err := h.Store.CreateDriverHelper(&dh); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create driver/helper", "details": err.Error()})
+	if err := h.Store.CreateDriverHelper(c.Request.Context(), &dh); err != nil {
+		respondError(c, err, "Failed to create driver/helper")
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Driver/Helper created successfully", "driver_helper": dh})
+	setETag(c, dh.Version)
+	c.JSON(http.StatusCreated, gin.H{"message": "Driver/Helper created successfully", "driver_helper": redactDriverHelper(c, dh)})
 }
 
 func (h *Handler) UpdateDriverHelper(c *gin.Context) {
@@ -95,6 +109,11 @@ func (h *Handler) UpdateDriverHelper(c *gin.Context) {
 		return
 	}
 
+	version, ok := ifMatchVersion(c, h.RequireIfMatch)
+	if !ok {
+		return
+	}
+
 	var dh model.DriverHelper
 	if err := c.ShouldBindJSON(&dh); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
@@ -103,13 +122,66 @@ func (h *Handler) UpdateDriverHelper(c *gin.Context) {
 
 	dh.ID = id
 	dh.UpdatedAt = time.Now()
+	dh.Version = version
+
+	if err := h.Store.UpdateDriverHelper(c.Request.Context(), &dh); err != nil {
+		respondError(c, err, "Failed to update driver/helper")
+		return
+	}
+
+	setETag(c, dh.Version)
+	c.JSON(http.StatusOK, gin.H{"message": "Driver/Helper updated successfully", "driver_helper": redactDriverHelper(c, dh)})
+}
+
+// PatchDriverHelper applies a JSON Merge Patch (RFC 7396) to a driver/helper;
+// only the fields whose values change are written.
+func (h *Handler) PatchDriverHelper(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	version, ok := ifMatchVersion(c, h.RequireIfMatch)
+	if !ok {
+		return
+	}
+	patch, ok := readMergePatch(c)
+	if !ok {
+		return
+	}
+
+	current, err := h.Store.DriverHelperByID(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "Failed to retrieve driver/helper")
+		return
+	}
+	if version != 0 && version != current.Version {
+		respondError(c, model.PreconditionFailedError("driver/helper", id, version), "Failed to update driver/helper")
+		return
+	}
+
+	var dh model.DriverHelper
+	if err := applyMergePatch(current, patch, &dh); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	dh.ID = id
 
-	if err := h.Store.UpdateDriverHelper(&dh); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update driver/helper", "details": err.Error()})
+	columns := model.ChangedColumns(current, dh)
+	if len(columns) == 0 {
+		setETag(c, current.Version)
+		c.JSON(http.StatusOK, gin.H{"message": "Driver/Helper unchanged", "driver_helper": redactDriverHelper(c, current)})
+		return
+	}
+	if err := h.Store.PatchDriverHelper(c.Request.Context(), &dh, columns); err != nil {
+		respondError(c, err, "Failed to update driver/helper")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Driver/Helper updated successfully", "driver_helper": dh})
+	setETag(c, dh.Version)
+	c.JSON(http.StatusOK, gin.H{"message": "Driver/Helper updated successfully", "driver_helper": redactDriverHelper(c, dh)})
 }
 
 func (h *Handler) DeleteDriverHelper(c *gin.Context) {
@@ -120,26 +192,54 @@ func (h *Handler) DeleteDriverHelper(c *gin.Context) {
 		return
 	}
 
-	if err := h.Store.DeleteDriverHelper(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete driver/helper", "details": err.Error()})
+	version, ok := ifMatchVersion(c, h.RequireIfMatch)
+	if !ok {
+		return
+	}
+
+	if err := h.Store.DeleteDriverHelper(c.Request.Context(), id, version, c.Query("reason")); err != nil {
+		respondError(c, err, "Failed to delete driver/helper")
 		return
 	}
 
 	c.JSON(http.StatusNoContent, gin.H{"message": "Driver/Helper deleted successfully"})
 }
 
+func (h *Handler) RestoreDriverHelper(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	dh, err := h.Store.RestoreDriverHelper(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "Failed to restore driver/helper")
+		return
+	}
+
+	setETag(c, dh.Version)
+	c.JSON(http.StatusOK, gin.H{"message": "Driver/Helper restored successfully", "driver_helper": redactDriverHelper(c, dh)})
+}
+
 func (h *Handler) GetDriverHelperByMobileNumber(c *gin.Context) {
 	mobile := c.Param("mobile")
 	if mobile == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Mobile number is required"})
 		return
 	}
+	// Mobile numbers are stored normalized; look up +91 and spaced forms by that too.
+	if normalized, err := validation.NormalizeMobile(mobile); err == nil {
+		mobile = normalized
+	}
 
-	dh, err := h.Store.DriverHelperByMobileNumber(mobile)
+	dh, err := h.Store.DriverHelperByMobileNumber(c.Request.Context(), mobile)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Driver/Helper not found with the given mobile number"})
+		respondError(c, err, "Failed to retrieve driver/helper by mobile number")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"driver_helper": dh})
+	setETag(c, dh.Version)
+	c.JSON(http.StatusOK, gin.H{"driver_helper": redactDriverHelper(c, dh)})
 }
@@ -0,0 +1,59 @@
+package web
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+// statusForError is the single place store errors are mapped to HTTP status codes.
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, model.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, model.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, model.ErrValidation), errors.Is(err, model.ErrForeignKey):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, model.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// respondError writes message alongside the error details, adding per-field
+// details for validation failures. Unexpected errors are also logged.
+func respondError(c *gin.Context, err error, message string) {
+	status := statusForError(err)
+	body := gin.H{"error": message, "details": err.Error()}
+
+	var verr *model.ValidationError
+	if errors.As(err, &verr) {
+		body["fields"] = verr.Fields
+	}
+	if status == http.StatusInternalServerError {
+		log.Printf("%s: %s", message, model.RedactText(err.Error()))
+	}
+
+	c.JSON(status, body)
+}
+
+// respondBadRequest reports malformed request parameters as 400, including
+// per-field details when err is a validation error.
+func respondBadRequest(c *gin.Context, err error, message string) {
+	body := gin.H{"error": message, "details": err.Error()}
+
+	var verr *model.ValidationError
+	if errors.As(err, &verr) {
+		body["fields"] = verr.Fields
+	}
+
+	c.JSON(http.StatusBadRequest, body)
+}
@@ -0,0 +1,48 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+func TestStatusForError(t *testing.T) {
+	id := uuid.New()
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "not found", err: model.ErrNotFound, want: http.StatusNotFound},
+		{name: "not found helper", err: model.NotFoundError("vehicle", id), want: http.StatusNotFound},
+		{name: "conflict", err: model.ErrConflict, want: http.StatusConflict},
+		{name: "restore of a live row", err: model.NotDeletedError("vehicle", id), want: http.StatusConflict},
+		{name: "precondition failed", err: model.ErrPreconditionFailed, want: http.StatusPreconditionFailed},
+		{name: "stale version", err: model.PreconditionFailedError("vehicle", id, 3), want: http.StatusPreconditionFailed},
+		{name: "validation", err: model.ErrValidation, want: http.StatusUnprocessableEntity},
+		{name: "field validation", err: model.NewFieldError("seats_available", "must not be negative"), want: http.StatusUnprocessableEntity},
+		{name: "foreign key", err: model.ErrForeignKey, want: http.StatusUnprocessableEntity},
+		{name: "unavailable", err: model.ErrUnavailable, want: http.StatusServiceUnavailable},
+		{name: "wrapped not found", err: fmt.Errorf("failed to fetch vehicle: %w", model.ErrNotFound), want: http.StatusNotFound},
+		{name: "wrapped conflict", err: fmt.Errorf("failed to insert vehicle: %w", model.ErrConflict), want: http.StatusConflict},
+		{name: "wrapped validation", err: fmt.Errorf("vehicle: %w", model.NewFieldError("vehicle_number", "required")), want: http.StatusUnprocessableEntity},
+		{name: "wrapped foreign key", err: fmt.Errorf("driver helper does not exist: %w", model.ErrForeignKey), want: http.StatusUnprocessableEntity},
+		{name: "wrapped unavailable", err: fmt.Errorf("%w: %w", model.ErrUnavailable, context.DeadlineExceeded), want: http.StatusServiceUnavailable},
+		{name: "doubly wrapped", err: fmt.Errorf("update: %w", fmt.Errorf("check version: %w", model.ErrPreconditionFailed)), want: http.StatusPreconditionFailed},
+		{name: "unknown", err: errors.New("connection reset by peer"), want: http.StatusInternalServerError},
+		{name: "context canceled", err: context.Canceled, want: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statusForError(tt.err); got != tt.want {
+				t.Errorf("statusForError(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
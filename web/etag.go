@@ -0,0 +1,40 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setETag publishes a row version as a strong entity tag, e.g. "3".
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// ifMatchVersion returns the row version named by the If-Match header, or 0
+// when the header is absent or "*". It responds and returns false when the
+// header is missing but required, or cannot name a version we issued.
+func ifMatchVersion(c *gin.Context, required bool) (int, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	switch {
+	case header == "" && required:
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required"})
+		return 0, false
+	case header == "" || header == "*":
+		return 0, true
+	case strings.Contains(header, ","):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "If-Match must contain a single entity tag"})
+		return 0, false
+	}
+
+	// Weak tags never match under the strong comparison If-Match requires.
+	tag, err := strconv.Unquote(header)
+	version, convErr := strconv.Atoi(tag)
+	if err != nil || convErr != nil || version <= 0 {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "If-Match does not match the current version"})
+		return 0, false
+	}
+	return version, true
+}
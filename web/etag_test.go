@@ -0,0 +1,66 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIfMatchVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name        string
+		header      string
+		required    bool
+		wantVersion int
+		wantOK      bool
+		wantStatus  int
+	}{
+		{name: "strong tag", header: `"3"`, wantVersion: 3, wantOK: true},
+		{name: "strong tag with spaces", header: ` "3" `, wantVersion: 3, wantOK: true},
+		{name: "strong tag when required", header: `"3"`, required: true, wantVersion: 3, wantOK: true},
+		{name: "missing", wantOK: true},
+		{name: "missing but required", required: true, wantStatus: http.StatusPreconditionRequired},
+		{name: "star", header: "*", wantOK: true},
+		{name: "star when required", header: "*", required: true, wantOK: true},
+		{name: "weak tag", header: `W/"3"`, wantStatus: http.StatusPreconditionFailed},
+		{name: "list", header: `"3", "4"`, wantStatus: http.StatusBadRequest},
+		{name: "unquoted", header: "3", wantStatus: http.StatusPreconditionFailed},
+		{name: "not a version", header: `"abc"`, wantStatus: http.StatusPreconditionFailed},
+		{name: "zero", header: `"0"`, wantStatus: http.StatusPreconditionFailed},
+		{name: "negative", header: `"-1"`, wantStatus: http.StatusPreconditionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPut, "/", nil)
+			if tt.header != "" {
+				c.Request.Header.Set("If-Match", tt.header)
+			}
+
+			version, ok := ifMatchVersion(c, tt.required)
+			if ok != tt.wantOK || version != tt.wantVersion {
+				t.Errorf("ifMatchVersion = %d, %v; want %d, %v", version, ok, tt.wantVersion, tt.wantOK)
+			}
+			if !ok && w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestSetETagRoundTrips(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	setETag(c, 7)
+
+	c.Request = httptest.NewRequest(http.MethodPut, "/", nil)
+	c.Request.Header.Set("If-Match", w.Header().Get("ETag"))
+	if version, ok := ifMatchVersion(c, true); !ok || version != 7 {
+		t.Errorf("ifMatchVersion(%s) = %d, %v; want 7", w.Header().Get("ETag"), version, ok)
+	}
+}
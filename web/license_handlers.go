@@ -0,0 +1,49 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/validation"
+)
+
+// DefaultLicenseExpiringWithin is how far ahead GetExpiringLicenses looks by default.
+const DefaultLicenseExpiringWithin = 30 * 24 * time.Hour
+
+// GetExpiringLicenses lists drivers whose license is still valid but expires
+// within the handler's window, which the within_days query parameter overrides.
+func (h *Handler) GetExpiringLicenses(c *gin.Context) {
+	within := h.LicenseExpiringWithin
+	if days := c.Query("within_days"); days != "" {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 || n > MaxWindowDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid within_days; must be an integer from 1 to %d", MaxWindowDays)})
+			return
+		}
+		within = time.Duration(n) * 24 * time.Hour
+	}
+
+	today := validation.StartOfDay(time.Now())
+	dhs, err := h.Store.LicensesExpiringBetween(c.Request.Context(), today, today.Add(within))
+	if err != nil {
+		respondError(c, err, "Failed to retrieve drivers with expiring licenses")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"driver_helpers": redactDriverHelpers(c, dhs)})
+}
+
+// GetExpiredLicenses lists drivers whose license expired before today.
+func (h *Handler) GetExpiredLicenses(c *gin.Context) {
+	dhs, err := h.Store.ExpiredLicenses(c.Request.Context(), validation.StartOfDay(time.Now()))
+	if err != nil {
+		respondError(c, err, "Failed to retrieve drivers with expired licenses")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"driver_helpers": redactDriverHelpers(c, dhs)})
+}
@@ -0,0 +1,41 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/memstore"
+)
+
+func TestExpiringLicensesWithinDays(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/driver_helpers/licenses/expiring", NewHandler(memstore.NewDriverHelperStore(memstore.NewDB())).GetExpiringLicenses)
+
+	tests := []struct {
+		days string
+		want int
+	}{
+		{days: "", want: http.StatusOK},
+		{days: "1", want: http.StatusOK},
+		{days: strconv.Itoa(MaxWindowDays), want: http.StatusOK},
+		{days: strconv.Itoa(MaxWindowDays + 1), want: http.StatusBadRequest},
+		{days: "106752", want: http.StatusBadRequest}, // overflows a time.Duration
+		{days: "9223372036854775807", want: http.StatusBadRequest},
+		{days: "0", want: http.StatusBadRequest},
+		{days: "ten", want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.days, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/driver_helpers/licenses/expiring?within_days="+tt.days, nil))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const mergePatchContentType = "application/merge-patch+json"
+
+// mergePatch applies an RFC 7396 JSON Merge Patch to the JSON document target:
+// object members in patch replace those in target, null members delete them,
+// and any non-object patch replaces target outright.
+func mergePatch(target, patch []byte) ([]byte, error) {
+	targetValue, err := decodeJSON(target)
+	if err != nil {
+		return nil, err
+	}
+	patchValue, err := decodeJSON(patch)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := patchValue.(map[string]any); !ok {
+		return nil, errors.New("merge patch must be a JSON object")
+	}
+	return json.Marshal(mergeValue(targetValue, patchValue))
+}
+
+func mergeValue(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = make(map[string]any, len(patchObj))
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergeValue(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// decodeJSON keeps numbers as json.Number so integers survive the round trip unchanged.
+func decodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// applyMergePatch patches the JSON form of current and decodes the result
+// into patched, rejecting members that patched has no field for.
+func applyMergePatch(current any, patch []byte, patched any) error {
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	merged, err := mergePatch(doc, patch)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(merged))
+	dec.DisallowUnknownFields()
+	return dec.Decode(patched)
+}
+
+// readMergePatch returns the request body of a merge patch request, responding
+// with an error and returning false for other content types or unreadable bodies.
+// Plain application/json is read as a merge patch too, for clients that cannot
+// set the merge patch media type.
+func readMergePatch(c *gin.Context) ([]byte, bool) {
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+	if mediaType != mergePatchContentType && mediaType != "application/json" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be " + mergePatchContentType + " or application/json"})
+		return nil, false
+	}
+
+	patch, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return nil, false
+	}
+	return patch, true
+}
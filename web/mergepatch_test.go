@@ -0,0 +1,146 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		patch   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "replaces and adds members",
+			target: `{"name":"Asha","phone":"111"}`,
+			patch:  `{"phone":"222","email":"asha@example.com"}`,
+			want:   `{"name":"Asha","phone":"222","email":"asha@example.com"}`,
+		},
+		{
+			name:   "null deletes a member",
+			target: `{"name":"Asha","phone":"111"}`,
+			patch:  `{"phone":null}`,
+			want:   `{"name":"Asha"}`,
+		},
+		{
+			name:   "null for a missing member is a no-op",
+			target: `{"name":"Asha"}`,
+			patch:  `{"phone":null}`,
+			want:   `{"name":"Asha"}`,
+		},
+		{
+			name:   "nested objects merge",
+			target: `{"address":{"city":"Pune","pin":"411001"},"name":"Asha"}`,
+			patch:  `{"address":{"pin":"411002","line1":null}}`,
+			want:   `{"address":{"city":"Pune","pin":"411002"},"name":"Asha"}`,
+		},
+		{
+			name:   "null inside a nested object deletes that member only",
+			target: `{"address":{"city":"Pune","pin":"411001"}}`,
+			patch:  `{"address":{"pin":null}}`,
+			want:   `{"address":{"city":"Pune"}}`,
+		},
+		{
+			name:   "object replaces a scalar",
+			target: `{"address":"Pune"}`,
+			patch:  `{"address":{"city":"Pune"}}`,
+			want:   `{"address":{"city":"Pune"}}`,
+		},
+		{
+			name:   "arrays are replaced, not merged",
+			target: `{"tags":["a","b"]}`,
+			patch:  `{"tags":["c"]}`,
+			want:   `{"tags":["c"]}`,
+		},
+		{
+			name:   "large integers survive unchanged",
+			target: `{"odometer":9007199254740993}`,
+			patch:  `{}`,
+			want:   `{"odometer":9007199254740993}`,
+		},
+		{name: "array patch", target: `{"name":"Asha"}`, patch: `["name"]`, wantErr: true},
+		{name: "null patch", target: `{"name":"Asha"}`, patch: `null`, wantErr: true},
+		{name: "scalar patch", target: `{"name":"Asha"}`, patch: `"Asha"`, wantErr: true},
+		{name: "malformed patch", target: `{"name":"Asha"}`, patch: `{"name":`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mergePatch([]byte(tt.target), []byte(tt.patch))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("mergePatch = %s, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("mergePatch: %v", err)
+			}
+			gotValue, _ := decodeJSON(got)
+			wantValue, _ := decodeJSON([]byte(tt.want))
+			if !reflect.DeepEqual(gotValue, wantValue) {
+				t.Errorf("mergePatch = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyMergePatchRejectsUnknownFields(t *testing.T) {
+	type doc struct {
+		Name string `json:"name"`
+	}
+	var patched doc
+	if err := applyMergePatch(doc{Name: "Asha"}, []byte(`{"name":"Ravi"}`), &patched); err != nil || patched.Name != "Ravi" {
+		t.Errorf("applyMergePatch = %+v, %v; want Ravi", patched, err)
+	}
+	if err := applyMergePatch(doc{Name: "Asha"}, []byte(`{"nickname":"Ravi"}`), &patched); err == nil {
+		t.Error("applyMergePatch accepted an unknown member")
+	}
+}
+
+func TestReadMergePatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		contentType string
+		want        int
+	}{
+		{contentType: "application/merge-patch+json", want: http.StatusOK},
+		{contentType: "application/merge-patch+json; charset=utf-8", want: http.StatusOK},
+		{contentType: "application/json", want: http.StatusOK},
+		{contentType: "text/plain", want: http.StatusUnsupportedMediaType},
+		{contentType: "", want: http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(`{"name":"Asha"}`))
+			c.Request.Header.Set("Content-Type", tt.contentType)
+
+			patch, ok := readMergePatch(c)
+			if ok {
+				c.Status(http.StatusOK)
+			}
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if ok && string(patch) != `{"name":"Asha"}` {
+				t.Errorf("patch = %s", patch)
+			}
+			if !ok {
+				var body struct{ Error string }
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || !strings.Contains(body.Error, "application/json") {
+					t.Errorf("body = %s, want it to name every accepted type", w.Body)
+				}
+			}
+		})
+	}
+}
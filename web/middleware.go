@@ -0,0 +1,76 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+// QueryTimeout puts a deadline on the request context, so the store calls a
+// handler makes are cancelled once it passes or when the client disconnects.
+func QueryTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// IncludeDeleted lets any read endpoint return soft-deleted rows when the
+// request carries ?include_deleted=true.
+func IncludeDeleted() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		param, ok := c.GetQuery("include_deleted")
+		if !ok {
+			c.Next()
+			return
+		}
+
+		include, err := strconv.ParseBool(param)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": "include_deleted must be true or false"})
+			return
+		}
+		if include {
+			c.Request = c.Request.WithContext(model.WithDeleted(c.Request.Context()))
+		}
+		c.Next()
+	}
+}
+
+// ActorHeader names the caller recorded as deleted_by.
+const ActorHeader = "X-Actor"
+
+// Actor records the ActorHeader value as the actor of the request's store writes.
+func Actor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if actor := c.GetHeader(ActorHeader); actor != "" {
+			c.Request = c.Request.WithContext(model.WithActor(c.Request.Context(), actor))
+		}
+		c.Next()
+	}
+}
+
+// Logger is gin's request logger with mobile and Aadhaar numbers masked in
+// the logged path and query.
+func Logger() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(p gin.LogFormatterParams) string {
+		return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v\n%s",
+			p.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			p.StatusCode,
+			p.Latency,
+			p.ClientIP,
+			p.Method,
+			model.RedactText(p.Path),
+			model.RedactText(p.ErrorMessage),
+		)
+	})
+}
@@ -0,0 +1,90 @@
+package web
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+type OnboardingHandler struct {
+	UnitOfWork model.UnitOfWork
+}
+
+func NewOnboardingHandler(uow model.UnitOfWork) *OnboardingHandler {
+	return &OnboardingHandler{UnitOfWork: uow}
+}
+
+// onboardingRequest describes a new crew and their vehicle. user_type may be
+// omitted, and the vehicle is always assigned to the new driver.
+type onboardingRequest struct {
+	Driver  *model.DriverHelper `json:"driver" binding:"required"`
+	Helper  *model.DriverHelper `json:"helper" binding:"required"`
+	Vehicle *model.Vehicle      `json:"vehicle" binding:"required"`
+}
+
+// Onboard creates a driver, a helper and a vehicle in one transaction: either
+// all three are created or none is. Field errors are prefixed with "driver.",
+// "helper." or "vehicle.".
+func (h *OnboardingHandler) Onboard(c *gin.Context) {
+	var req onboardingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	verr := &model.ValidationError{}
+	setUserType(verr, req.Driver, "driver", "Driver")
+	setUserType(verr, req.Helper, "helper", "Helper")
+	if err := verr.Err(); err != nil {
+		respondError(c, err, "Failed to onboard crew")
+		return
+	}
+
+	var driver, helper model.DriverHelper
+	var vehicle model.Vehicle
+	err := h.UnitOfWork.WithTx(c.Request.Context(), func(tx model.Stores) error {
+		ctx := c.Request.Context()
+		driver, helper, vehicle = *req.Driver, *req.Helper, *req.Vehicle
+		now := time.Now()
+		driver.ID, driver.CreatedAt, driver.UpdatedAt = uuid.New(), now, now
+		helper.ID, helper.CreatedAt, helper.UpdatedAt = uuid.New(), now, now
+		vehicle.ID, vehicle.CreatedAt, vehicle.UpdatedAt = uuid.New(), now, now
+
+		if err := tx.DriverHelpers.CreateDriverHelper(ctx, &driver); err != nil {
+			return model.PrefixFields(err, "driver")
+		}
+		if err := tx.DriverHelpers.CreateDriverHelper(ctx, &helper); err != nil {
+			return model.PrefixFields(err, "helper")
+		}
+		vehicle.DriverHelperID = driver.ID
+		if err := tx.Vehicles.CreateVehicle(ctx, &vehicle); err != nil {
+			return model.PrefixFields(err, "vehicle")
+		}
+		return nil
+	})
+	if err != nil {
+		respondError(c, err, "Failed to onboard crew")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Crew onboarded successfully",
+		"driver":  redactDriverHelper(c, driver),
+		"helper":  redactDriverHelper(c, helper),
+		"vehicle": vehicle,
+	})
+}
+
+func setUserType(verr *model.ValidationError, dh *model.DriverHelper, field, userType string) {
+	switch dh.UserType {
+	case "":
+		dh.UserType = userType
+	case userType:
+	default:
+		verr.Add(field+".user_type", "invalid %s.user_type: %s; must be '%s'", field, dh.UserType, userType)
+	}
+}
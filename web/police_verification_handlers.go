@@ -0,0 +1,123 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// DefaultVerificationRenewalAge is how old a police verification may get before it is flagged for renewal.
+const DefaultVerificationRenewalAge = 365 * 24 * time.Hour
+
+type policeVerificationRequest struct {
+	Date         time.Time `json:"police_verification_date"`
+	DocumentPath string    `json:"police_verification_document_path"`
+}
+
+type revokeVerificationRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (h *Handler) GetVerifiedDriverHelpers(c *gin.Context) {
+	dhs, err := h.Store.VerifiedDriverHelpers(c.Request.Context())
+	if err != nil {
+		respondError(c, err, "Failed to retrieve verified driver/helpers")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"driver_helpers": redactDriverHelpers(c, dhs)})
+}
+
+func (h *Handler) GetPendingVerificationDriverHelpers(c *gin.Context) {
+	dhs, err := h.Store.PendingVerificationDriverHelpers(c.Request.Context())
+	if err != nil {
+		respondError(c, err, "Failed to retrieve driver/helpers pending verification")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"driver_helpers": redactDriverHelpers(c, dhs)})
+}
+
+// GetVerificationsDueForRenewal lists verifications older than the handler's
+// renewal age, which the max_age_days query parameter overrides.
+func (h *Handler) GetVerificationsDueForRenewal(c *gin.Context) {
+	maxAge := h.VerificationRenewalAge
+	if days := c.Query("max_age_days"); days != "" {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid max_age_days; must be a positive integer"})
+			return
+		}
+		maxAge = time.Duration(n) * 24 * time.Hour
+	}
+
+	verifiedBefore := time.Now().Add(-maxAge)
+	dhs, err := h.Store.VerificationsDueForRenewal(c.Request.Context(), verifiedBefore)
+	if err != nil {
+		respondError(c, err, "Failed to retrieve verifications due for renewal")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"driver_helpers": redactDriverHelpers(c, dhs), "verified_before": verifiedBefore})
+}
+
+func (h *Handler) SubmitPoliceVerification(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var req policeVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	version, ok := ifMatchVersion(c, h.RequireIfMatch)
+	if !ok {
+		return
+	}
+
+	dh, err := h.Store.SubmitPoliceVerification(c.Request.Context(), id, version, req.Date, req.DocumentPath)
+	if err != nil {
+		respondError(c, err, "Failed to submit police verification")
+		return
+	}
+
+	setETag(c, dh.Version)
+	c.JSON(http.StatusOK, gin.H{"message": "Police verification submitted successfully", "driver_helper": redactDriverHelper(c, dh)})
+}
+
+func (h *Handler) RevokePoliceVerification(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var req revokeVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	version, ok := ifMatchVersion(c, h.RequireIfMatch)
+	if !ok {
+		return
+	}
+
+	dh, err := h.Store.RevokePoliceVerification(c.Request.Context(), id, version, req.Reason)
+	if err != nil {
+		respondError(c, err, "Failed to revoke police verification")
+		return
+	}
+
+	setETag(c, dh.Version)
+	c.JSON(http.StatusOK, gin.H{"message": "Police verification revoked successfully", "driver_helper": redactDriverHelper(c, dh)})
+}
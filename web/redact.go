@@ -0,0 +1,53 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/auth"
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+// canSeePII reports whether the caller holds auth.PermPIIRead; everyone else
+// gets personal data masked.
+func canSeePII(c *gin.Context) bool {
+	principal, ok := auth.FromContext(c.Request.Context())
+	return ok && principal.Can(auth.PermPIIRead)
+}
+
+func redactDriverHelper(c *gin.Context, dh model.DriverHelper) model.DriverHelper {
+	if canSeePII(c) {
+		return dh
+	}
+	return dh.Redacted()
+}
+
+// redactDriverHelpers also turns nil into an empty list, so that lists render as [] rather than null.
+func redactDriverHelpers(c *gin.Context, dhs []model.DriverHelper) []model.DriverHelper {
+	if dhs == nil {
+		return []model.DriverHelper{}
+	}
+	if canSeePII(c) {
+		return dhs
+	}
+	redacted := make([]model.DriverHelper, len(dhs))
+	for i, dh := range dhs {
+		redacted[i] = dh.Redacted()
+	}
+	return redacted
+}
+
+// redactAuditEntries, like redactDriverHelpers, renders nil as an empty list.
+func redactAuditEntries(c *gin.Context, entries []model.AuditEntry) []model.AuditEntry {
+	if entries == nil {
+		return []model.AuditEntry{}
+	}
+	if canSeePII(c) {
+		return entries
+	}
+	redacted := make([]model.AuditEntry, len(entries))
+	for i, entry := range entries {
+		entry.Changes = entry.Changes.Redacted()
+		redacted[i] = entry
+	}
+	return redacted
+}
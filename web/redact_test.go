@@ -0,0 +1,126 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/auth"
+	"github.com/arjunsaxaena/driver_vehicle_profile/memstore"
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+func TestEmptyListsRenderAsArrays(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	stores := memstore.NewStores(memstore.NewDB())
+	handler, vehicles, audit := NewHandler(stores.DriverHelpers), NewVehicleHandler(stores.Vehicles), NewAuditHandler(stores.Audit)
+
+	for _, caller := range []auth.Principal{
+		{Subject: "admin", Roles: []auth.Role{auth.RoleAdmin}},
+		{Subject: "reader", Scopes: []auth.Permission{auth.PermRosterRead}},
+	} {
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), caller))
+		})
+		router.GET("/driver_helpers", handler.GetAllDriverHelpers)
+		router.GET("/drivers", handler.GetDrivers)
+		router.GET("/licenses/expired", handler.GetExpiredLicenses)
+		router.GET("/police_verifications/pending", handler.GetPendingVerificationDriverHelpers)
+		router.GET("/driver_helpers/:id/history", audit.GetDriverHelperHistory)
+		router.GET("/vehicles", vehicles.GetAllVehicles)
+		router.GET("/vehicles/driver_helper/:driver_helper_id", vehicles.GetVehiclesByDriverHelperID)
+		router.GET("/vehicles/route/:route_number", vehicles.GetVehiclesByRouteNumber)
+
+		tests := []struct {
+			path string
+			key  string
+		}{
+			{path: "/driver_helpers", key: "driver_helpers"},
+			{path: "/drivers", key: "drivers"},
+			{path: "/licenses/expired", key: "driver_helpers"},
+			{path: "/police_verifications/pending", key: "driver_helpers"},
+			{path: "/driver_helpers/" + uuid.NewString() + "/history", key: "history"},
+			{path: "/vehicles", key: "vehicles"},
+			{path: "/vehicles/driver_helper/" + uuid.NewString(), key: "vehicles"},
+			{path: "/vehicles/route/R1", key: "vehicles"},
+		}
+		for _, tt := range tests {
+			t.Run(caller.Subject+tt.path, func(t *testing.T) {
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+				if rec.Code != http.StatusOK {
+					t.Fatalf("status = %d, want %d; body %s", rec.Code, http.StatusOK, rec.Body)
+				}
+				if !strings.Contains(rec.Body.String(), `"`+tt.key+`":[]`) {
+					t.Errorf("body = %s, want an empty %s array", rec.Body, tt.key)
+				}
+			})
+		}
+	}
+}
+
+func TestPersonalDataMaskedUnlessPIIRead(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	stores := memstore.NewStores(memstore.NewDB())
+	expiry := time.Now().AddDate(1, 0, 0)
+	dh := model.DriverHelper{UserType: "Driver", FirstName: "Ravi", BloodGroup: "O+", AadharNumber: "234567890124",
+		MobileNumber: "9876543210", LicenseNumber: "MH1220110012345", LicenseExpiryDate: &expiry, PoliceVerification: "No"}
+	if err := stores.DriverHelpers.CreateDriverHelper(ctx, &dh); err != nil {
+		t.Fatalf("CreateDriverHelper: %v", err)
+	}
+	entry := model.AuditEntry{ID: uuid.New(), EntityType: model.EntityDriverHelper, EntityID: dh.ID, Operation: model.OperationCreate,
+		Actor: "clerk", Changes: model.Diff(model.DriverHelper{}, dh), CreatedAt: time.Now()}
+	if err := stores.Audit.RecordAudit(ctx, &entry); err != nil {
+		t.Fatalf("RecordAudit: %v", err)
+	}
+
+	withCaller := func(p auth.Principal) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), p))
+		}
+	}
+	tests := []struct {
+		name   string
+		caller gin.HandlerFunc
+		masked bool
+	}{
+		{name: "admin", caller: withCaller(auth.Principal{Subject: "admin", Roles: []auth.Role{auth.RoleAdmin}})},
+		{name: "pii:read scope", caller: withCaller(auth.Principal{Subject: "erp", Scopes: []auth.Permission{auth.PermRosterRead, auth.PermAuditRead, auth.PermPIIRead}})},
+		{name: "roster reader", caller: withCaller(auth.Principal{Subject: "viewer", Roles: []auth.Role{auth.RoleSchoolViewer, auth.RoleAuditor}}), masked: true},
+		{name: "authentication disabled", caller: Unauthenticated(), masked: true},
+	}
+	for _, tt := range tests {
+		router := gin.New()
+		router.Use(tt.caller)
+		router.GET("/driver_helpers", NewHandler(stores.DriverHelpers).GetAllDriverHelpers)
+		router.GET("/driver_helpers/:id", NewHandler(stores.DriverHelpers).GetDriverHelperByID)
+		router.GET("/driver_helpers/:id/history", NewAuditHandler(stores.Audit).GetDriverHelperHistory)
+
+		for _, path := range []string{"/driver_helpers", "/driver_helpers/" + dh.ID.String(), "/driver_helpers/" + dh.ID.String() + "/history"} {
+			t.Run(tt.name+path, func(t *testing.T) {
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+				if rec.Code != http.StatusOK {
+					t.Fatalf("status = %d, want %d; body %s", rec.Code, http.StatusOK, rec.Body)
+				}
+				body := rec.Body.String()
+				for _, raw := range []string{"234567890124", "9876543210", "MH1220110012345"} {
+					if strings.Contains(body, raw) == tt.masked {
+						t.Errorf("body = %s; want %s masked = %v", body, raw, tt.masked)
+					}
+				}
+				if tt.masked && !strings.Contains(body, "XXXX-XXXX-0124") {
+					t.Errorf("body = %s, want the masked Aadhaar number", body)
+				}
+			})
+		}
+	}
+}
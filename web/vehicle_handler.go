@@ -1,22 +1,24 @@
 package web
 
 import (
+	"encoding/json"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/google/uuid"
 
-	"github.com/arjunsaxaena/driver_vehicle_profile/controllers"
 	"github.com/arjunsaxaena/driver_vehicle_profile/model"
 )
 
 type VehicleHandler struct {
-	Store *controllers.DBVehicleStore
+	Store model.VehicleStore
+	// RequireIfMatch rejects writes that do not name the version they were based on.
+	RequireIfMatch bool
 }
 
-func NewVehicleHandler(store *controllers.DBVehicleStore) *VehicleHandler {
+func NewVehicleHandler(store model.VehicleStore) *VehicleHandler {
 	return &VehicleHandler{Store: store}
 }
 
@@ -31,22 +33,17 @@ func (h *VehicleHandler) CreateVehicle(c *gin.Context) {
 	v.CreatedAt = time.Now()
 	v.UpdatedAt = time.Now()
 
-	if err := h.Store.CreateVehicle(&v); err != nil {
-		if strings.Contains(err.Error(), "unique_violation") {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":   "Vehicle with number already exists",
-				"details": "A vehicle with the same vehicle number already exists.",
-			})
-			return
-		}
-
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create vehicle", "details": err.Error()})
+	if err := h.Store.CreateVehicle(c.Request.Context(), &v); err != nil {
+		respondError(c, err, "Failed to create vehicle")
 		return
 	}
 
+	setETag(c, v.Version)
 	c.JSON(http.StatusCreated, gin.H{"message": "Vehicle created successfully", "vehicle": v})
 }
 
+// UpdateVehicle replaces a vehicle. The body must name driver_helper_id, as
+// null for a vehicle without a driver/helper; see requireMembers.
 func (h *VehicleHandler) UpdateVehicle(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := uuid.Parse(idParam)
@@ -55,28 +52,105 @@ func (h *VehicleHandler) UpdateVehicle(c *gin.Context) {
 		return
 	}
 
+	version, ok := ifMatchVersion(c, h.RequireIfMatch)
+	if !ok {
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
 	var v model.Vehicle
-	if err := c.ShouldBindJSON(&v); err != nil {
+	if err := binding.JSON.BindBody(body, &v); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
 		return
 	}
+	if err := requireMembers(body, "driver_helper_id"); err != nil {
+		respondBadRequest(c, err, "Invalid input")
+		return
+	}
 
 	v.ID = id
 	v.UpdatedAt = time.Now()
+	v.Version = version
+
+	if err := h.Store.UpdateVehicle(c.Request.Context(), &v); err != nil {
+		respondError(c, err, "Failed to update vehicle")
+		return
+	}
 
-	if err := h.Store.UpdateVehicle(&v); err != nil {
-		if strings.Contains(err.Error(), "constraint violation") {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":   "Failed to update vehicle due to constraint violation",
-				"details": "Please check the input values (e.g., seats_available should not exceed total_students_capacity).",
-			})
-			return
+	setETag(c, v.Version)
+	c.JSON(http.StatusOK, gin.H{"message": "Vehicle updated successfully", "vehicle": v})
+}
+
+// requireMembers rejects a JSON object body that leaves out any of members.
+// A PUT replaces the whole record, so an optional member such as
+// driver_helper_id must be sent, as null to clear it, rather than omitted:
+// omitting it would otherwise clear it silently.
+func requireMembers(body []byte, members ...string) error {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return err
+	}
+	verr := &model.ValidationError{}
+	for _, member := range members {
+		if _, ok := obj[member]; !ok {
+			verr.Add(member, "%s is required; send null to leave it unset, or use PATCH to keep it", member)
 		}
+	}
+	return verr.Err()
+}
+
+// PatchVehicle applies a JSON Merge Patch (RFC 7396) to a vehicle;
+// only the fields whose values change are written.
+func (h *VehicleHandler) PatchVehicle(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	version, ok := ifMatchVersion(c, h.RequireIfMatch)
+	if !ok {
+		return
+	}
+	patch, ok := readMergePatch(c)
+	if !ok {
+		return
+	}
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update vehicle", "details": err.Error()})
+	current, err := h.Store.VehicleByID(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "Failed to retrieve vehicle")
+		return
+	}
+	if version != 0 && version != current.Version {
+		respondError(c, model.PreconditionFailedError("vehicle", id, version), "Failed to update vehicle")
 		return
 	}
 
+	var v model.Vehicle
+	if err := applyMergePatch(current, patch, &v); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	v.ID = id
+
+	columns := model.ChangedColumns(current, v)
+	if len(columns) == 0 {
+		setETag(c, current.Version)
+		c.JSON(http.StatusOK, gin.H{"message": "Vehicle unchanged", "vehicle": current})
+		return
+	}
+	if err := h.Store.PatchVehicle(c.Request.Context(), &v, columns); err != nil {
+		respondError(c, err, "Failed to update vehicle")
+		return
+	}
+
+	setETag(c, v.Version)
 	c.JSON(http.StatusOK, gin.H{"message": "Vehicle updated successfully", "vehicle": v})
 }
 
@@ -88,22 +162,58 @@ func (h *VehicleHandler) DeleteVehicle(c *gin.Context) {
 		return
 	}
 
-	if err := h.Store.DeleteVehicle(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete vehicle", "details": err.Error()})
+	version, ok := ifMatchVersion(c, h.RequireIfMatch)
+	if !ok {
+		return
+	}
+
+	if err := h.Store.DeleteVehicle(c.Request.Context(), id, version, c.Query("reason")); err != nil {
+		respondError(c, err, "Failed to delete vehicle")
 		return
 	}
 
 	c.JSON(http.StatusNoContent, gin.H{"message": "Vehicle deleted successfully"})
 }
 
+func (h *VehicleHandler) RestoreVehicle(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	v, err := h.Store.RestoreVehicle(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "Failed to restore vehicle")
+		return
+	}
+
+	setETag(c, v.Version)
+	c.JSON(http.StatusOK, gin.H{"message": "Vehicle restored successfully", "vehicle": v})
+}
+
 func (h *VehicleHandler) GetAllVehicles(c *gin.Context) {
-	vehicles, err := h.Store.Vehicles()
+	var filter model.VehicleFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": err.Error()})
+		return
+	}
+	if err := filter.Normalize(); err != nil {
+		respondBadRequest(c, err, "Invalid query parameters")
+		return
+	}
+
+	page, err := h.Store.Vehicles(c.Request.Context(), filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve vehicles", "details": err.Error()})
+		respondError(c, err, "Failed to retrieve vehicles")
 		return
 	}
+	if page.Items == nil {
+		page.Items = []model.Vehicle{}
+	}
 
-	c.JSON(http.StatusOK, gin.H{"vehicles": vehicles})
+	c.JSON(http.StatusOK, gin.H{"vehicles": page.Items, "next_cursor": page.NextCursor, "total": page.Total})
 }
 
 func (h *VehicleHandler) GetVehicleByID(c *gin.Context) {
@@ -114,12 +224,26 @@ func (h *VehicleHandler) GetVehicleByID(c *gin.Context) {
 		return
 	}
 
-	v, err := h.Store.VehicleByID(id)
+	v, err := h.Store.VehicleByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Vehicle not found"})
+		respondError(c, err, "Failed to retrieve vehicle")
 		return
 	}
 
+	setETag(c, v.Version)
+	c.JSON(http.StatusOK, gin.H{"vehicle": v})
+}
+
+// GetVehicleByNumber looks a vehicle up by its registration number in any
+// spelling, e.g. "DL 1P C 1234", "dl1pc1234" or "DL-1PC-1234".
+func (h *VehicleHandler) GetVehicleByNumber(c *gin.Context) {
+	v, err := h.Store.VehicleByNumber(c.Request.Context(), c.Param("number"))
+	if err != nil {
+		respondError(c, err, "Failed to retrieve vehicle by number")
+		return
+	}
+
+	setETag(c, v.Version)
 	c.JSON(http.StatusOK, gin.H{"vehicle": v})
 }
 
@@ -131,35 +255,41 @@ func (h *VehicleHandler) GetVehiclesByDriverHelperID(c *gin.Context) {
 		return
 	}
 
-	vehicles, err := h.Store.VehiclesByDriverHelperID(driverHelperID)
+	vehicles, err := h.Store.VehiclesByDriverHelperID(c.Request.Context(), driverHelperID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve vehicles"})
+		respondError(c, err, "Failed to retrieve vehicles")
 		return
 	}
+	if vehicles == nil {
+		vehicles = []model.Vehicle{}
+	}
 
 	c.JSON(http.StatusOK, gin.H{"vehicles": vehicles})
 }
 
 func (h *VehicleHandler) GetVehiclesByRouteNumber(c *gin.Context) {
-	routeNumber := c.Param("route _number")
+	routeNumber := c.Param("route_number")
 	if routeNumber == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Route number is required"})
 		return
 	}
 
-	vehicles, err := h.Store.VehiclesByRouteNumber(routeNumber)
+	vehicles, err := h.Store.VehiclesByRouteNumber(c.Request.Context(), routeNumber)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve vehicles"})
+		respondError(c, err, "Failed to retrieve vehicles")
 		return
 	}
+	if vehicles == nil {
+		vehicles = []model.Vehicle{}
+	}
 
 	c.JSON(http.StatusOK, gin.H{"vehicles": vehicles})
 }
 
 func (h *VehicleHandler) GetExpiredCertificatesVehicles(c *gin.Context) {
-	vehicles, err := h.Store.ExpiredCertificatesVehicles()
+	vehicles, err := h.Store.ExpiredCertificatesVehicles(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve vehicles with expired certificates", "details": err.Error()})
+		respondError(c, err, "Failed to retrieve vehicles with expired certificates")
 		return
 	}
 
@@ -0,0 +1,76 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/memstore"
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+func TestUpdateVehicleRequiresDriverHelperID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	stores := memstore.NewStores(memstore.NewDB())
+	expiry := time.Now().AddDate(1, 0, 0)
+	dh := model.DriverHelper{UserType: "Driver", FirstName: "Ravi", BloodGroup: "O+", AadharNumber: "234567890124",
+		MobileNumber: "9876543210", LicenseNumber: "MH1220110012345", LicenseExpiryDate: &expiry, PoliceVerification: "No"}
+	if err := stores.DriverHelpers.CreateDriverHelper(ctx, &dh); err != nil {
+		t.Fatalf("CreateDriverHelper: %v", err)
+	}
+	v := model.Vehicle{VehicleNumber: "DL1PC0001", RouteNumber: "R1", TotalStudentsCapacity: 30, SeatsAvailable: 30,
+		DriverHelperID: dh.ID, InsuranceExpiryDate: expiry, PollutionCertificateExpiryDate: expiry, FitnessCertificateExpiryDate: expiry}
+	if err := stores.Vehicles.CreateVehicle(ctx, &v); err != nil {
+		t.Fatalf("CreateVehicle: %v", err)
+	}
+
+	router := gin.New()
+	router.PUT("/vehicles/:id", NewVehicleHandler(stores.Vehicles).UpdateVehicle)
+	date := expiry.Format(time.RFC3339)
+	body := func(driverHelperID string) string {
+		return `{"vehicle_number":"DL1PC0001","route_number":"R2","total_students_capacity":30,"seats_available":30,` +
+			driverHelperID + `"insurance_expiry_date":"` + date + `","pollution_certificate_expiry_date":"` + date +
+			`","fitness_certificate_expiry_date":"` + date + `"}`
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want int
+		// wantDriverHelperID is the assignment stored after the request.
+		wantDriverHelperID uuid.UUID
+	}{
+		{name: "omitted", body: body(""), want: http.StatusBadRequest, wantDriverHelperID: dh.ID},
+		{name: "kept", body: body(`"driver_helper_id":"` + dh.ID.String() + `",`), want: http.StatusOK, wantDriverHelperID: dh.ID},
+		{name: "null", body: body(`"driver_helper_id":null,`), want: http.StatusOK, wantDriverHelperID: uuid.Nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/vehicles/"+v.ID.String(), strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want == http.StatusBadRequest && !strings.Contains(rec.Body.String(), `"field":"driver_helper_id"`) {
+				t.Errorf("body = %s, want a driver_helper_id field error", rec.Body)
+			}
+			stored, err := stores.Vehicles.VehicleByID(ctx, v.ID)
+			if err != nil {
+				t.Fatalf("VehicleByID: %v", err)
+			}
+			if stored.DriverHelperID != tt.wantDriverHelperID {
+				t.Errorf("driver_helper_id = %s, want %s", stored.DriverHelperID, tt.wantDriverHelperID)
+			}
+		})
+	}
+}
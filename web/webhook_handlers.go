@@ -0,0 +1,269 @@
+package web
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+	"github.com/arjunsaxaena/driver_vehicle_profile/webhooks"
+)
+
+// minWebhookSecretLength is the shortest secret a subscriber may choose.
+const minWebhookSecretLength = 16
+
+type WebhookHandler struct {
+	Store      model.WebhookStore
+	Dispatcher *webhooks.Dispatcher
+}
+
+func NewWebhookHandler(store model.WebhookStore, dispatcher *webhooks.Dispatcher) *WebhookHandler {
+	return &WebhookHandler{Store: store, Dispatcher: dispatcher}
+}
+
+type registerWebhookRequest struct {
+	URL string `json:"url"`
+	// Secret is generated when left empty.
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+}
+
+func (r registerWebhookRequest) validate() error {
+	verr := &model.ValidationError{}
+	if u, err := url.Parse(r.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		verr.Add("url", "invalid url: %s; must be an absolute http or https URL", r.URL)
+	}
+	if r.Secret != "" && len(r.Secret) < minWebhookSecretLength {
+		verr.Add("secret", "secret must be at least %d characters", minWebhookSecretLength)
+	}
+	webhooks.ValidateEvents(verr, r.Events)
+	return verr.Err()
+}
+
+// RegisterWebhook subscribes a URL to events. The response carries the
+// signing secret, which is not shown again.
+//
+// Each delivery is a JSON POST with X-DVP-Event, X-DVP-Delivery,
+// X-DVP-Timestamp (Unix seconds) and X-DVP-Signature headers. To verify one,
+// the receiver computes the hex HMAC-SHA256, under the secret, of the
+// timestamp, a ".", and the raw body; compares "sha256=" and that, in
+// constant time, with X-DVP-Signature; and rejects the delivery if the
+// timestamp is more than five minutes (notify.SignatureTolerance) from its
+// own clock. Redeliveries are signed afresh, and the same X-DVP-Delivery ID
+// may arrive more than once, so receivers should also deduplicate on it.
+func (h *WebhookHandler) RegisterWebhook(c *gin.Context) {
+	var req registerWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+	if err := req.validate(); err != nil {
+		respondError(c, err, "Failed to register webhook")
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			respondError(c, err, "Failed to register webhook")
+			return
+		}
+		secret = hex.EncodeToString(b)
+	}
+	now := time.Now()
+	sub := model.WebhookSubscription{
+		ID:        uuid.New(),
+		URL:       req.URL,
+		Secret:    secret,
+		Events:    req.Events,
+		CreatedBy: model.Actor(c.Request.Context()),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := h.Store.CreateWebhook(c.Request.Context(), &sub); err != nil {
+		respondError(c, err, "Failed to register webhook")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Webhook registered successfully", "webhook": sub, "secret": secret})
+}
+
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	subs, err := h.Store.Webhooks(c.Request.Context())
+	if err != nil {
+		respondError(c, err, "Failed to fetch webhooks")
+		return
+	}
+	if subs == nil {
+		subs = []model.WebhookSubscription{}
+	}
+	c.JSON(http.StatusOK, gin.H{"webhooks": subs})
+}
+
+func (h *WebhookHandler) GetWebhookByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	sub, err := h.Store.WebhookByID(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "Failed to fetch webhook")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"webhook": sub})
+}
+
+// DeleteWebhook unsubscribes a webhook, discarding its queued and past deliveries.
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	if err := h.Store.DeleteWebhook(c.Request.Context(), id); err != nil {
+		respondError(c, err, "Failed to delete webhook")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// TestWebhook sends a webhook.test event to the webhook straight away and
+// reports how the receiver answered.
+func (h *WebhookHandler) TestWebhook(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	sub, err := h.Store.WebhookByID(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "Failed to test webhook")
+		return
+	}
+	delivery, attempt, err := h.Dispatcher.Test(c.Request.Context(), sub)
+	if err != nil {
+		respondError(c, err, "Failed to test webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"delivered": delivery.Status == model.DeliveryDelivered,
+		"delivery":  delivery,
+		"attempt":   attempt,
+	})
+}
+
+// GetWebhookDeliveries lists a webhook's deliveries, newest first, optionally
+// only those with the given status.
+func (h *WebhookHandler) GetWebhookDeliveries(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	if _, err := h.Store.WebhookByID(c.Request.Context(), id); err != nil {
+		respondError(c, err, "Failed to fetch webhook deliveries")
+		return
+	}
+
+	filter, err := deliveryFilter(c)
+	if err != nil {
+		respondError(c, err, "Failed to fetch webhook deliveries")
+		return
+	}
+	filter.SubscriptionID = id
+	h.respondDeliveries(c, filter)
+}
+
+// GetDeadLetters lists the deliveries of every webhook that ran out of attempts.
+func (h *WebhookHandler) GetDeadLetters(c *gin.Context) {
+	filter, err := deliveryFilter(c)
+	if err != nil {
+		respondError(c, err, "Failed to fetch dead letters")
+		return
+	}
+	filter.Status = model.DeliveryDead
+	h.respondDeliveries(c, filter)
+}
+
+func (h *WebhookHandler) respondDeliveries(c *gin.Context, filter model.WebhookDeliveryFilter) {
+	deliveries, err := h.Store.WebhookDeliveries(c.Request.Context(), filter)
+	if err != nil {
+		respondError(c, err, "Failed to fetch webhook deliveries")
+		return
+	}
+	if deliveries == nil {
+		deliveries = []model.WebhookDelivery{}
+	}
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+// deliveryFilter reads the status and limit query parameters.
+func deliveryFilter(c *gin.Context) (model.WebhookDeliveryFilter, error) {
+	filter := model.WebhookDeliveryFilter{Status: c.Query("status"), Limit: model.DefaultPageLimit}
+	switch filter.Status {
+	case "", model.DeliveryPending, model.DeliveryDelivered, model.DeliveryDead:
+	default:
+		return filter, model.NewFieldError("status", "invalid status: %s; must be 'pending', 'delivered' or 'dead'", filter.Status)
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > model.MaxPageLimit {
+			return filter, model.NewFieldError("limit", "invalid limit: %s; must be between 1 and %d", limit, model.MaxPageLimit)
+		}
+		filter.Limit = n
+	}
+	return filter, nil
+}
+
+// GetWebhookDelivery returns a delivery with every attempt made at it.
+func (h *WebhookHandler) GetWebhookDelivery(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("delivery_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	delivery, err := h.Store.WebhookDeliveryByID(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "Failed to fetch webhook delivery")
+		return
+	}
+	attempts, err := h.Store.WebhookAttempts(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "Failed to fetch webhook delivery")
+		return
+	}
+	if attempts == nil {
+		attempts = []model.WebhookAttempt{}
+	}
+	c.JSON(http.StatusOK, gin.H{"delivery": delivery, "attempts": attempts})
+}
+
+// RedeliverWebhookDelivery takes a delivery off the dead-letter list and
+// queues it again with a fresh set of attempts.
+func (h *WebhookHandler) RedeliverWebhookDelivery(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("delivery_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	delivery, err := h.Store.RequeueDelivery(c.Request.Context(), id, time.Now())
+	if err != nil {
+		respondError(c, err, "Failed to redeliver webhook delivery")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook delivery queued for redelivery", "delivery": delivery})
+}
@@ -0,0 +1,177 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+	"github.com/arjunsaxaena/driver_vehicle_profile/notify"
+)
+
+// Headers sent with every delivery besides notify.TimestampHeader and
+// notify.SignatureHeader, which sign it.
+const (
+	EventHeader    = "X-DVP-Event"
+	DeliveryHeader = "X-DVP-Delivery"
+)
+
+// Dispatcher posts queued deliveries to their subscriptions.
+type Dispatcher struct {
+	Store  model.WebhookStore
+	Client *http.Client
+	// MaxAttempts is how many times a delivery is tried before it is dead.
+	MaxAttempts int
+	// InitialBackoff is the wait after the first failed attempt; it doubles
+	// after every further failure, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// BatchSize is how many due deliveries are claimed at a time.
+	BatchSize int
+}
+
+// Run delivers due deliveries every interval until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.DeliverDue(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("failed to dispatch webhook deliveries", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue attempts every delivery that is due, a batch at a time. A
+// delivery that cannot be attempted or recorded is logged and left to be
+// claimed again once its lease runs out; only failing to claim a batch is an
+// error.
+func (d *Dispatcher) DeliverDue(ctx context.Context) error {
+	for {
+		now := time.Now()
+		// Claimed deliveries are leased for as long as a batch can take, so a
+		// dispatcher that dies mid-batch leaves them to be retried.
+		lease := now.Add(time.Duration(d.BatchSize) * d.Client.Timeout)
+		batch, err := d.Store.ClaimDueDeliveries(ctx, now, lease, d.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		subs := make(map[uuid.UUID]model.WebhookSubscription)
+		for _, delivery := range batch {
+			sub, ok := subs[delivery.SubscriptionID]
+			if !ok {
+				if sub, err = d.Store.WebhookByID(ctx, delivery.SubscriptionID); err != nil {
+					slog.Error("failed to load webhook for delivery", "delivery", delivery.ID, "webhook", delivery.SubscriptionID, "error", err)
+					continue
+				}
+				subs[sub.ID] = sub
+			}
+			if _, _, err := d.Attempt(ctx, sub, delivery); err != nil {
+				slog.Error("failed to record webhook attempt", "delivery", delivery.ID, "webhook", sub.ID, "error", err)
+			}
+		}
+		if len(batch) < d.BatchSize {
+			return nil
+		}
+	}
+}
+
+// Attempt posts delivery to sub once and records the outcome: delivered on a
+// 2xx response, otherwise pending a retry after the backoff or, once
+// MaxAttempts is reached, dead. The returned error is only for failing to
+// record the attempt; a failed delivery is reported in the attempt.
+func (d *Dispatcher) Attempt(ctx context.Context, sub model.WebhookSubscription, delivery model.WebhookDelivery) (model.WebhookDelivery, model.WebhookAttempt, error) {
+	started := time.Now()
+	statusCode, sendErr := d.post(ctx, sub, delivery)
+	now := time.Now()
+
+	delivery.Attempts++
+	delivery.UpdatedAt = now
+	attempt := model.WebhookAttempt{
+		ID:          uuid.New(),
+		DeliveryID:  delivery.ID,
+		Attempt:     delivery.Attempts,
+		StatusCode:  statusCode,
+		DurationMS:  now.Sub(started).Milliseconds(),
+		AttemptedAt: started,
+	}
+
+	switch {
+	case sendErr == nil:
+		delivery.Status, delivery.NextAttemptAt, delivery.DeliveredAt, delivery.LastError = model.DeliveryDelivered, nil, &now, ""
+	case delivery.Attempts >= d.MaxAttempts:
+		attempt.Error = sendErr.Error()
+		delivery.Status, delivery.NextAttemptAt, delivery.LastError = model.DeliveryDead, nil, attempt.Error
+		slog.Warn("webhook delivery is dead", "delivery", delivery.ID, "webhook", sub.ID, "event", delivery.EventType, "error", sendErr)
+	default:
+		attempt.Error = sendErr.Error()
+		next := now.Add(d.backoff(delivery.Attempts))
+		delivery.NextAttemptAt, delivery.LastError = &next, attempt.Error
+	}
+
+	if err := d.Store.RecordAttempt(ctx, delivery, attempt); err != nil {
+		return delivery, attempt, err
+	}
+	return delivery, attempt, nil
+}
+
+// backoff is the wait after the given number of failed attempts.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.InitialBackoff
+	for i := 1; i < attempts && wait < d.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, d.MaxBackoff)
+}
+
+func (d *Dispatcher) post(ctx context.Context, sub model.WebhookSubscription, delivery model.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, delivery.ID.String())
+	notify.SetSignature(req.Header, sub.Secret, time.Now(), delivery.Payload)
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("responded %s: %s", resp.Status, bytes.TrimSpace(snippet))
+	}
+	return resp.StatusCode, nil
+}
+
+// Test queues a webhook.test event for sub alone and attempts it at once.
+// Failed test deliveries are retried like any other.
+func (d *Dispatcher) Test(ctx context.Context, sub model.WebhookSubscription) (model.WebhookDelivery, model.WebhookAttempt, error) {
+	event := Event{ID: uuid.New(), Type: EventTest, OccurredAt: time.Now().UTC(), Actor: model.Actor(ctx)}
+	delivery, err := newDelivery(sub.ID, event)
+	if err != nil {
+		return delivery, model.WebhookAttempt{}, err
+	}
+	// Queue it leased, so that no dispatcher sends it while this attempt is under way.
+	lease := delivery.CreatedAt.Add(d.Client.Timeout)
+	delivery.NextAttemptAt = &lease
+	if err := d.Store.EnqueueDeliveries(ctx, []model.WebhookDelivery{delivery}); err != nil {
+		return delivery, model.WebhookAttempt{}, err
+	}
+	return d.Attempt(ctx, sub, delivery)
+}
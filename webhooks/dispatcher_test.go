@@ -0,0 +1,151 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/memstore"
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+	"github.com/arjunsaxaena/driver_vehicle_profile/notify"
+)
+
+func TestDeliverySignature(t *testing.T) {
+	const secret = "0123456789abcdef0123456789abcdef"
+	var header http.Header
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			t.Errorf("read body: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	store := memstore.NewWebhookStore(memstore.NewDB())
+	sub := model.WebhookSubscription{ID: uuid.New(), URL: srv.URL, Secret: secret, Events: model.EventFilter{"*"}}
+	if err := store.CreateWebhook(ctx, &sub); err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+	d := &Dispatcher{Store: store, Client: &http.Client{Timeout: 5 * time.Second}, MaxAttempts: 3,
+		InitialBackoff: time.Second, MaxBackoff: time.Minute}
+	delivery, attempt, err := d.Test(ctx, sub)
+	if err != nil {
+		t.Fatalf("Test: %v", err)
+	}
+	if attempt.Error != "" {
+		t.Fatalf("attempt failed: %s", attempt.Error)
+	}
+
+	if got := header.Get(EventHeader); got != EventTest {
+		t.Errorf("%s = %q, want %q", EventHeader, got, EventTest)
+	}
+	if got := header.Get(DeliveryHeader); got != delivery.ID.String() {
+		t.Errorf("%s = %q, want %q", DeliveryHeader, got, delivery.ID)
+	}
+	timestamp, signature := header.Get(notify.TimestampHeader), header.Get(notify.SignatureHeader)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		t.Fatalf("%s = %q: %v", notify.TimestampHeader, timestamp, err)
+	}
+	if skew := time.Since(time.Unix(unix, 0)).Abs(); skew > time.Minute {
+		t.Errorf("%s is %s off", notify.TimestampHeader, skew)
+	}
+	// The body alone, as deliveries were once signed, must not verify.
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if signature == "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+		t.Errorf("%s signs the body without the timestamp", notify.SignatureHeader)
+	}
+
+	now := time.Now()
+	if err := notify.Verify(secret, timestamp, signature, body, now); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      string
+		now       time.Time
+	}{
+		{name: "wrong secret", secret: "fedcba9876543210", timestamp: timestamp, body: string(body), now: now},
+		{name: "tampered body", secret: secret, timestamp: timestamp, body: string(body) + " ", now: now},
+		{name: "restamped", secret: secret, timestamp: strconv.FormatInt(unix+60, 10), body: string(body), now: now},
+		{name: "malformed timestamp", secret: secret, timestamp: "yesterday", body: string(body), now: now},
+		{name: "replayed later", secret: secret, timestamp: timestamp, body: string(body), now: now.Add(notify.SignatureTolerance + time.Minute)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := notify.Verify(tt.secret, tt.timestamp, signature, []byte(tt.body), tt.now); err == nil {
+				t.Error("Verify accepted the delivery")
+			}
+		})
+	}
+}
+
+// failingWebhookStore fails to load the subscription with ID missing.
+type failingWebhookStore struct {
+	model.WebhookStore
+	missing uuid.UUID
+}
+
+func (s failingWebhookStore) WebhookByID(ctx context.Context, id uuid.UUID) (model.WebhookSubscription, error) {
+	if id == s.missing {
+		return model.WebhookSubscription{}, errors.New("connection reset")
+	}
+	return s.WebhookStore.WebhookByID(ctx, id)
+}
+
+func TestDeliverDueSkipsFailedDeliveries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	store := memstore.NewWebhookStore(memstore.NewDB())
+	broken := model.WebhookSubscription{ID: uuid.New(), URL: srv.URL, Secret: "s", Events: model.EventFilter{"*"}}
+	working := model.WebhookSubscription{ID: uuid.New(), URL: srv.URL, Secret: "s", Events: model.EventFilter{"*"}}
+	for _, sub := range []*model.WebhookSubscription{&broken, &working} {
+		if err := store.CreateWebhook(ctx, sub); err != nil {
+			t.Fatalf("CreateWebhook: %v", err)
+		}
+	}
+	event := Event{ID: uuid.New(), Type: EventDriverHelperCreated, OccurredAt: time.Now().UTC()}
+	if err := NewEmitter(store).Emit(ctx, event); err != nil {
+		t.Fatalf("Emit: %v", err)
+	}
+
+	d := &Dispatcher{Store: failingWebhookStore{WebhookStore: store, missing: broken.ID}, Client: &http.Client{Timeout: 5 * time.Second},
+		MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: time.Minute, BatchSize: 10}
+	if err := d.DeliverDue(ctx); err != nil {
+		t.Fatalf("DeliverDue: %v", err)
+	}
+
+	for _, tt := range []struct {
+		sub    model.WebhookSubscription
+		status string
+	}{{broken, model.DeliveryPending}, {working, model.DeliveryDelivered}} {
+		ds, err := store.WebhookDeliveries(ctx, model.WebhookDeliveryFilter{SubscriptionID: tt.sub.ID})
+		if err != nil {
+			t.Fatalf("WebhookDeliveries: %v", err)
+		}
+		if len(ds) != 1 || ds[0].Status != tt.status {
+			t.Errorf("deliveries to %s = %+v, want one %s", tt.sub.ID, ds, tt.status)
+		}
+	}
+}
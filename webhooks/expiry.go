@@ -0,0 +1,103 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/compliance"
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+	"github.com/arjunsaxaena/driver_vehicle_profile/validation"
+)
+
+// expiryChannel is the reminder channel that expiry events are deduplicated on.
+const expiryChannel = "webhook_event"
+
+// ExpiryWatcher emits vehicle.certificate_expired and
+// driver_helper.license_expired events. Expiring is not a store write, so
+// the watcher looks for expired documents periodically and claims each event
+// in the reminder store to emit it only once per document and expiry date.
+type ExpiryWatcher struct {
+	Stores    model.Stores
+	Reminders model.ReminderStore
+	Emitter   *Emitter
+}
+
+// Run checks for expired documents every interval until ctx is cancelled.
+func (w *ExpiryWatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Check(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			slog.Error("failed to emit document expiry events", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check emits an event for each document expired as of now that has not had one yet.
+func (w *ExpiryWatcher) Check(ctx context.Context, now time.Time) error {
+	vehicles, err := w.Stores.Vehicles.ExpiredCertificatesVehicles(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list vehicles with expired certificates: %w", err)
+	}
+	for _, v := range vehicles {
+		for _, c := range (compliance.Engine{}).Vehicle(v, now).Checks {
+			if c.Status != compliance.StatusExpired || c.DueDate == nil {
+				continue
+			}
+			event := newEvent(ctx, EventCertificateExpired, model.EntityVehicle, v.ID, v)
+			event.Document = string(c.Rule)
+			if err := w.emitOnce(ctx, event, c.Rule, *c.DueDate, now); err != nil {
+				return err
+			}
+		}
+	}
+
+	drivers, err := w.Stores.DriverHelpers.ExpiredLicenses(ctx, validation.StartOfDay(now))
+	if err != nil {
+		return fmt.Errorf("failed to list expired licenses: %w", err)
+	}
+	for _, dh := range drivers {
+		if dh.LicenseExpiryDate == nil {
+			continue
+		}
+		event := driverHelperEvent(ctx, EventLicenseExpired, dh)
+		event.Document = string(compliance.RuleLicense)
+		if err := w.emitOnce(ctx, event, compliance.RuleLicense, *dh.LicenseExpiryDate, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// emitOnce emits event unless one was already emitted for the same document
+// and due date, releasing its claim again if it cannot be queued.
+func (w *ExpiryWatcher) emitOnce(ctx context.Context, event Event, document compliance.Rule, dueDate, now time.Time) error {
+	r := model.Reminder{
+		EntityType: event.EntityType,
+		EntityID:   *event.EntityID,
+		Document:   string(document),
+		DueDate:    dueDate,
+		Channel:    expiryChannel,
+		SentAt:     now,
+	}
+	claimed, err := w.Reminders.ClaimReminder(ctx, r)
+	if err != nil || !claimed {
+		return err
+	}
+	if err := w.Emitter.Emit(ctx, event); err != nil {
+		if releaseErr := w.Reminders.ReleaseReminder(ctx, r); releaseErr != nil {
+			return releaseErr
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,252 @@
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+// runner runs fn with stores that share one transaction. fn runs again if the
+// transaction is retried, so writes work on a fresh copy of their input in
+// every attempt.
+type runner func(ctx context.Context, fn func(tx model.Stores) error) error
+
+// Wrap returns stores that read through stores and run each write in its own
+// transaction of uow, queueing the deliveries of its events in the same
+// transaction: an event is delivered if and only if its write commits.
+func Wrap(stores model.Stores, uow model.UnitOfWork) model.Stores {
+	return wrap(stores, uow.WithTx)
+}
+
+func wrap(stores model.Stores, run runner) model.Stores {
+	return model.Stores{
+		DriverHelpers: &DriverHelperStore{DriverHelperStore: stores.DriverHelpers, run: run},
+		Vehicles:      &VehicleStore{VehicleStore: stores.Vehicles, run: run},
+		Audit:         stores.Audit,
+		Webhooks:      stores.Webhooks,
+	}
+}
+
+// UnitOfWork hands each transaction stores that queue the events of their writes within it.
+type UnitOfWork struct {
+	uow model.UnitOfWork
+}
+
+var _ model.UnitOfWork = (*UnitOfWork)(nil)
+
+func NewUnitOfWork(uow model.UnitOfWork) *UnitOfWork {
+	return &UnitOfWork{uow: uow}
+}
+
+func (u *UnitOfWork) WithTx(ctx context.Context, fn func(tx model.Stores) error) error {
+	return u.uow.WithTx(ctx, func(tx model.Stores) error {
+		run := func(ctx context.Context, fn func(tx model.Stores) error) error { return fn(tx) }
+		return fn(wrap(tx, run))
+	})
+}
+
+// emit queues the deliveries of events in tx.
+func emit(ctx context.Context, tx model.Stores, events ...Event) error {
+	return NewEmitter(tx.Webhooks).Emit(ctx, events...)
+}
+
+// DriverHelperStore emits events for the writes made through it; reads go straight to the embedded store.
+type DriverHelperStore struct {
+	model.DriverHelperStore
+	run runner
+}
+
+var _ model.DriverHelperStore = (*DriverHelperStore)(nil)
+
+func (s *DriverHelperStore) CreateDriverHelper(ctx context.Context, dh *model.DriverHelper) error {
+	input := *dh
+	return s.run(ctx, func(tx model.Stores) error {
+		attempt := input
+		if err := tx.DriverHelpers.CreateDriverHelper(ctx, &attempt); err != nil {
+			return err
+		}
+		*dh = attempt
+		return emit(ctx, tx, driverHelperEvent(ctx, EventDriverHelperCreated, attempt))
+	})
+}
+
+func (s *DriverHelperStore) UpdateDriverHelper(ctx context.Context, dh *model.DriverHelper) error {
+	input := *dh
+	return s.update(ctx, dh.ID, func(tx model.Stores) (model.DriverHelper, error) {
+		attempt := input
+		if err := tx.DriverHelpers.UpdateDriverHelper(ctx, &attempt); err != nil {
+			return attempt, err
+		}
+		*dh = attempt
+		return attempt, nil
+	})
+}
+
+func (s *DriverHelperStore) PatchDriverHelper(ctx context.Context, dh *model.DriverHelper, columns []string) error {
+	input := *dh
+	return s.update(ctx, dh.ID, func(tx model.Stores) (model.DriverHelper, error) {
+		attempt := input
+		if err := tx.DriverHelpers.PatchDriverHelper(ctx, &attempt, columns); err != nil {
+			return attempt, err
+		}
+		*dh = attempt
+		return attempt, nil
+	})
+}
+
+func (s *DriverHelperStore) SubmitPoliceVerification(ctx context.Context, id uuid.UUID, expectedVersion int, date time.Time, documentPath string) (model.DriverHelper, error) {
+	var after model.DriverHelper
+	err := s.update(ctx, id, func(tx model.Stores) (model.DriverHelper, error) {
+		var err error
+		after, err = tx.DriverHelpers.SubmitPoliceVerification(ctx, id, expectedVersion, date, documentPath)
+		return after, err
+	})
+	return after, err
+}
+
+func (s *DriverHelperStore) RevokePoliceVerification(ctx context.Context, id uuid.UUID, expectedVersion int, reason string) (model.DriverHelper, error) {
+	var after model.DriverHelper
+	err := s.update(ctx, id, func(tx model.Stores) (model.DriverHelper, error) {
+		var err error
+		after, err = tx.DriverHelpers.RevokePoliceVerification(ctx, id, expectedVersion, reason)
+		return after, err
+	})
+	return after, err
+}
+
+// update runs write and emits driver_helper.updated with the fields it
+// changed, reading the row as it was before within the same transaction.
+func (s *DriverHelperStore) update(ctx context.Context, id uuid.UUID, write func(tx model.Stores) (model.DriverHelper, error)) error {
+	return s.run(ctx, func(tx model.Stores) error {
+		before, err := tx.DriverHelpers.DriverHelperByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		after, err := write(tx)
+		if err != nil {
+			return err
+		}
+		event := driverHelperEvent(ctx, EventDriverHelperUpdated, after)
+		event.Changes = model.Diff(before, after).Redacted()
+		return emit(ctx, tx, event)
+	})
+}
+
+func (s *DriverHelperStore) DeleteDriverHelper(ctx context.Context, id uuid.UUID, expectedVersion int, reason string) error {
+	return s.run(ctx, func(tx model.Stores) error {
+		before, err := tx.DriverHelpers.DriverHelperByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := tx.DriverHelpers.DeleteDriverHelper(ctx, id, expectedVersion, reason); err != nil {
+			return err
+		}
+		return emit(ctx, tx, driverHelperEvent(ctx, EventDriverHelperDeleted, before))
+	})
+}
+
+func (s *DriverHelperStore) RestoreDriverHelper(ctx context.Context, id uuid.UUID) (model.DriverHelper, error) {
+	var after model.DriverHelper
+	err := s.run(ctx, func(tx model.Stores) error {
+		var err error
+		if after, err = tx.DriverHelpers.RestoreDriverHelper(ctx, id); err != nil {
+			return err
+		}
+		return emit(ctx, tx, driverHelperEvent(ctx, EventDriverHelperRestored, after))
+	})
+	return after, err
+}
+
+func driverHelperEvent(ctx context.Context, eventType string, dh model.DriverHelper) Event {
+	return newEvent(ctx, eventType, model.EntityDriverHelper, dh.ID, dh.Redacted())
+}
+
+// VehicleStore emits events for the writes made through it; reads go straight to the embedded store.
+type VehicleStore struct {
+	model.VehicleStore
+	run runner
+}
+
+var _ model.VehicleStore = (*VehicleStore)(nil)
+
+func (s *VehicleStore) CreateVehicle(ctx context.Context, v *model.Vehicle) error {
+	input := *v
+	return s.run(ctx, func(tx model.Stores) error {
+		attempt := input
+		if err := tx.Vehicles.CreateVehicle(ctx, &attempt); err != nil {
+			return err
+		}
+		*v = attempt
+		return emit(ctx, tx, newEvent(ctx, EventVehicleCreated, model.EntityVehicle, attempt.ID, attempt))
+	})
+}
+
+func (s *VehicleStore) UpdateVehicle(ctx context.Context, v *model.Vehicle) error {
+	return s.update(ctx, v, func(tx model.Stores, attempt *model.Vehicle) error {
+		return tx.Vehicles.UpdateVehicle(ctx, attempt)
+	})
+}
+
+func (s *VehicleStore) PatchVehicle(ctx context.Context, v *model.Vehicle, columns []string) error {
+	return s.update(ctx, v, func(tx model.Stores, attempt *model.Vehicle) error {
+		return tx.Vehicles.PatchVehicle(ctx, attempt, columns)
+	})
+}
+
+// update runs write on a copy of v and emits vehicle.updated with the fields
+// it changed, followed by vehicle.route_changed if the route number was one
+// of them. The row as it was before is read within the same transaction.
+func (s *VehicleStore) update(ctx context.Context, v *model.Vehicle, write func(tx model.Stores, attempt *model.Vehicle) error) error {
+	input := *v
+	return s.run(ctx, func(tx model.Stores) error {
+		attempt := input
+		before, err := tx.Vehicles.VehicleByID(ctx, attempt.ID)
+		if err != nil {
+			return err
+		}
+		if err := write(tx, &attempt); err != nil {
+			return err
+		}
+		*v = attempt
+
+		changes := model.Diff(before, attempt)
+		events := []Event{newEvent(ctx, EventVehicleUpdated, model.EntityVehicle, attempt.ID, attempt)}
+		events[0].Changes = changes
+		for _, change := range changes {
+			if change.Field == "route_number" {
+				routeChanged := newEvent(ctx, EventRouteChanged, model.EntityVehicle, attempt.ID, attempt)
+				routeChanged.Changes = model.Changes{change}
+				events = append(events, routeChanged)
+			}
+		}
+		return emit(ctx, tx, events...)
+	})
+}
+
+func (s *VehicleStore) DeleteVehicle(ctx context.Context, id uuid.UUID, expectedVersion int, reason string) error {
+	return s.run(ctx, func(tx model.Stores) error {
+		before, err := tx.Vehicles.VehicleByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := tx.Vehicles.DeleteVehicle(ctx, id, expectedVersion, reason); err != nil {
+			return err
+		}
+		return emit(ctx, tx, newEvent(ctx, EventVehicleDeleted, model.EntityVehicle, id, before))
+	})
+}
+
+func (s *VehicleStore) RestoreVehicle(ctx context.Context, id uuid.UUID) (model.Vehicle, error) {
+	var after model.Vehicle
+	err := s.run(ctx, func(tx model.Stores) error {
+		var err error
+		if after, err = tx.Vehicles.RestoreVehicle(ctx, id); err != nil {
+			return err
+		}
+		return emit(ctx, tx, newEvent(ctx, EventVehicleRestored, model.EntityVehicle, id, after))
+	})
+	return after, err
+}
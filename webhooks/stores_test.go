@@ -0,0 +1,71 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/memstore"
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+func TestDeliveriesCommitWithTheirWrite(t *testing.T) {
+	ctx := context.Background()
+	db := memstore.NewDB()
+	webhookStore := memstore.NewWebhookStore(db)
+	sub := model.WebhookSubscription{ID: uuid.New(), URL: "https://erp.example/hooks", Secret: "s", Events: model.EventFilter{"*"}}
+	if err := webhookStore.CreateWebhook(ctx, &sub); err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+	uow := memstore.NewUnitOfWork(db)
+	stores := Wrap(memstore.NewStores(db), uow)
+
+	newDriver := func(aadhaar, mobile, license string) *model.DriverHelper {
+		expiry := time.Now().AddDate(1, 0, 0)
+		return &model.DriverHelper{UserType: "Driver", FirstName: "Ravi", BloodGroup: "O+", AadharNumber: aadhaar,
+			MobileNumber: mobile, LicenseNumber: license, LicenseExpiryDate: &expiry, PoliceVerification: "No"}
+	}
+	deliveries := func() []model.WebhookDelivery {
+		t.Helper()
+		ds, err := webhookStore.WebhookDeliveries(ctx, model.WebhookDeliveryFilter{})
+		if err != nil {
+			t.Fatalf("WebhookDeliveries: %v", err)
+		}
+		return ds
+	}
+
+	dh := newDriver("234567890124", "9876543210", "MH1220110012345")
+	if err := stores.DriverHelpers.CreateDriverHelper(ctx, dh); err != nil {
+		t.Fatalf("CreateDriverHelper: %v", err)
+	}
+	if ds := deliveries(); len(ds) != 1 || ds[0].EventType != EventDriverHelperCreated {
+		t.Fatalf("deliveries after create = %+v, want one %s", ds, EventDriverHelperCreated)
+	}
+
+	boom := errors.New("boom")
+	err := NewUnitOfWork(uow).WithTx(ctx, func(tx model.Stores) error {
+		if err := tx.DriverHelpers.CreateDriverHelper(ctx, newDriver("345678901238", "9876543211", "MH1220110012346")); err != nil {
+			return err
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("WithTx = %v, want %v", err, boom)
+	}
+	if ds := deliveries(); len(ds) != 1 {
+		t.Errorf("a rolled back create left %d deliveries, want 1", len(ds))
+	}
+
+	dh.FirstName = "Ravindra"
+	if err := stores.DriverHelpers.UpdateDriverHelper(ctx, dh); err != nil {
+		t.Fatalf("UpdateDriverHelper: %v", err)
+	}
+	ds := deliveries()
+	if len(ds) != 2 || !slices.ContainsFunc(ds, func(d model.WebhookDelivery) bool { return d.EventType == EventDriverHelperUpdated }) {
+		t.Fatalf("deliveries after update = %+v, want a second one for %s", ds, EventDriverHelperUpdated)
+	}
+}
@@ -0,0 +1,150 @@
+// Package webhooks tells other systems, such as the school ERP, about changes
+// to driver/helpers and vehicles. Store writes emit events; each event is
+// queued as a delivery to every subscription whose filter matches it, and a
+// Dispatcher posts the queued deliveries, signed with the subscription's
+// secret over a timestamp and the body (see notify.Sign and notify.Verify),
+// retrying failures with exponential backoff until they run out of attempts
+// and land on the dead-letter list.
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/arjunsaxaena/driver_vehicle_profile/model"
+)
+
+// Event types. Every entity write emits its entity's created, updated, deleted
+// or restored event; the others are emitted alongside them or on a schedule.
+const (
+	EventDriverHelperCreated  = "driver_helper.created"
+	EventDriverHelperUpdated  = "driver_helper.updated"
+	EventDriverHelperDeleted  = "driver_helper.deleted"
+	EventDriverHelperRestored = "driver_helper.restored"
+	// EventLicenseExpired is emitted once for each driving license that expires.
+	EventLicenseExpired = "driver_helper.license_expired"
+
+	EventVehicleCreated  = "vehicle.created"
+	EventVehicleUpdated  = "vehicle.updated"
+	EventVehicleDeleted  = "vehicle.deleted"
+	EventVehicleRestored = "vehicle.restored"
+	// EventRouteChanged accompanies vehicle.updated when the route number changed.
+	EventRouteChanged = "vehicle.route_changed"
+	// EventCertificateExpired is emitted once for each insurance, pollution or
+	// fitness certificate that expires.
+	EventCertificateExpired = "vehicle.certificate_expired"
+
+	// EventTest is only sent when a subscription is test-fired, whatever its filter.
+	EventTest = "webhook.test"
+)
+
+// EventTypes lists the event types a subscription can filter on.
+var EventTypes = []string{
+	EventDriverHelperCreated, EventDriverHelperUpdated, EventDriverHelperDeleted, EventDriverHelperRestored, EventLicenseExpired,
+	EventVehicleCreated, EventVehicleUpdated, EventVehicleDeleted, EventVehicleRestored, EventRouteChanged, EventCertificateExpired,
+}
+
+// ValidateEvents adds an error to verr for every entry of filter that matches
+// no event type: each must be an event type, "*", or an entity followed by
+// ".*", e.g. "vehicle.*".
+func ValidateEvents(verr *model.ValidationError, filter []string) {
+	if len(filter) == 0 {
+		verr.Add("events", "events must not be empty")
+	}
+	for _, pattern := range filter {
+		if !slices.ContainsFunc(EventTypes, model.EventFilter{pattern}.Matches) {
+			verr.Add("events", "invalid event: %s; must be one of %s, or a wildcard such as vehicle.*", pattern, strings.Join(EventTypes, ", "))
+		}
+	}
+}
+
+// Event is the JSON body posted to subscribers.
+type Event struct {
+	ID         uuid.UUID `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	// Actor made the change, if the event comes from one.
+	Actor      string     `json:"actor,omitempty"`
+	EntityType string     `json:"entity_type,omitempty"`
+	EntityID   *uuid.UUID `json:"entity_id,omitempty"`
+	// Data is the entity as it is after the change, or was before a delete,
+	// with personal data masked.
+	Data any `json:"data,omitempty"`
+	// Changes are the fields an update changed, with personal data masked.
+	Changes model.Changes `json:"changes,omitempty"`
+	// Document names the certificate or license an expiry event is about,
+	// e.g. "insurance".
+	Document string `json:"document,omitempty"`
+}
+
+func newEvent(ctx context.Context, eventType, entityType string, entityID uuid.UUID, data any) Event {
+	return Event{
+		ID:         uuid.New(),
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		Actor:      model.Actor(ctx),
+		EntityType: entityType,
+		EntityID:   &entityID,
+		Data:       data,
+	}
+}
+
+// Emitter queues deliveries of events to the subscriptions they match.
+type Emitter struct {
+	Store model.WebhookStore
+}
+
+func NewEmitter(store model.WebhookStore) *Emitter {
+	return &Emitter{Store: store}
+}
+
+// Emit queues a delivery of each event to every subscription it matches.
+func (e *Emitter) Emit(ctx context.Context, events ...Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	subs, err := e.Store.Webhooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	var deliveries []model.WebhookDelivery
+	for _, event := range events {
+		for _, sub := range subs {
+			if !sub.Events.Matches(event.Type) {
+				continue
+			}
+			d, err := newDelivery(sub.ID, event)
+			if err != nil {
+				return err
+			}
+			deliveries = append(deliveries, d)
+		}
+	}
+	return e.Store.EnqueueDeliveries(ctx, deliveries)
+}
+
+func newDelivery(subscriptionID uuid.UUID, event Event) (model.WebhookDelivery, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return model.WebhookDelivery{}, fmt.Errorf("failed to encode %s event: %w", event.Type, err)
+	}
+	now := time.Now()
+	return model.WebhookDelivery{
+		ID:             uuid.New(),
+		SubscriptionID: subscriptionID,
+		EventID:        event.ID,
+		EventType:      event.Type,
+		Payload:        payload,
+		Status:         model.DeliveryPending,
+		NextAttemptAt:  &now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}, nil
+}